@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var aggregateCmd = &cobra.Command{
+	Use:   "aggregate",
+	Short: "Aggregate change coefficients by hour, day and week",
+	RunE:  runComponents((*app).aggregateComponent),
+}
+
+func init() {
+	rootCmd.AddCommand(aggregateCmd)
+}
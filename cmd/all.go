@@ -0,0 +1,29 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var allComponents = []func(a *app) (component, error){
+	(*app).loaderComponent,
+	(*app).backfillComponent,
+	(*app).resampleComponent,
+	(*app).calculateComponent,
+	(*app).historyComponent,
+	(*app).techAnalysisComponent,
+	(*app).patternComponent,
+	(*app).levelComponent,
+	(*app).alertComponent,
+	(*app).notifierComponent,
+	(*app).aggregateComponent,
+	(*app).cleanerComponent,
+	(*app).serveComponent,
+}
+
+var allCmd = &cobra.Command{
+	Use:   "all",
+	Short: "Run loader, backfill, resample, calculate, history, aggregate, cleaner and serve in one process",
+	RunE:  runComponents(allComponents...),
+}
+
+func init() {
+	rootCmd.AddCommand(allCmd)
+}
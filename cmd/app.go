@@ -0,0 +1,579 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/calculation"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/cleaner"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/controller"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/loader"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/config"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/indicator"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/level"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/notifier"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/binance"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/bybit"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/cryptoloader"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/replay"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/stream"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage/cache"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage/db"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/database"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/metrics"
+	http_server "github.com/AlekseyPorandaykin/crypto_analyst/pkg/server/http"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/shutdown"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var errBackfillNotSupported = errors.New("source does not support candlestick history")
+
+type component struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// app lazily creates dependencies, so every subcommand wires only repositories and caches it needs,
+// and components started together (command "all") share them.
+type app struct {
+	conf config.AppConfig
+	conn *sqlx.DB
+
+	priceStorage       *storage.PriceComposite
+	candlestickStorage *storage.CandlestickComposite
+	watchlistStorage   *db.Watchlist
+	indicatorStorage   *storage.IndicatorComposite
+	instanceStorage    *indicator.Instances
+	source             domain.MarketDataSource
+	alertNotifier      *notifier.Notifier
+	history            *calculation.PriceHistory
+	timescaleStatus    *db.TimescaleStatus
+}
+
+func newApp(conf config.AppConfig) (*app, error) {
+	conn, err := database.CreateConnection(conf.Database)
+	if err != nil {
+		return nil, err
+	}
+	return &app{conf: conf, conn: conn}, nil
+}
+
+func (a *app) Close() {
+	_ = a.conn.Close()
+}
+
+func (a *app) prices() *storage.PriceComposite {
+	if a.priceStorage == nil {
+		a.priceStorage = storage.NewComposite(cache.NewPrice(), db.NewPriceRepository(a.conn))
+	}
+	return a.priceStorage
+}
+
+func (a *app) candlesticks() *storage.CandlestickComposite {
+	if a.candlestickStorage == nil {
+		a.candlestickStorage = storage.NewCandlestickComposite(cache.NewCandlestick(), db.NewCandlestick(a.conn))
+	}
+	return a.candlestickStorage
+}
+
+func (a *app) indicators() *storage.IndicatorComposite {
+	if a.indicatorStorage == nil {
+		a.indicatorStorage = storage.NewIndicatorComposite(cache.NewIndicator(), db.NewIndicator(a.conn))
+	}
+	return a.indicatorStorage
+}
+
+func (a *app) indicatorInstances() (*indicator.Instances, error) {
+	if a.instanceStorage == nil {
+		instances, err := indicator.NewInstances(
+			indicator.Default, a.conf.IndicatorInstances(), db.NewIndicatorInstances(a.conn),
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.instanceStorage = instances
+	}
+	return a.instanceStorage, nil
+}
+
+func (a *app) watchlist() *db.Watchlist {
+	if a.watchlistStorage == nil {
+		a.watchlistStorage = db.NewWatchlist(a.conn)
+	}
+	return a.watchlistStorage
+}
+
+// subscription returns watchlist symbols of exchange with their intervals.
+func (a *app) subscription(exchange string) stream.SubscriptionLoader {
+	return func(ctx context.Context) (stream.Subscription, error) {
+		items, err := a.watchlist().Watchlist(ctx)
+		if err != nil {
+			return nil, err
+		}
+		subscription := make(stream.Subscription)
+		for _, item := range items {
+			if item.Exchange == exchange && len(item.Intervals) > 0 {
+				subscription[item.Symbol] = item.Intervals
+			}
+		}
+		return subscription, nil
+	}
+}
+
+// marketDataSource is shared by components, so requests to exchanges are limited by the same rate limiter.
+func (a *app) marketDataSource() (domain.MarketDataSource, error) {
+	if a.source == nil {
+		source, err := a.newMarketDataSource()
+		if err != nil {
+			return nil, err
+		}
+		a.source = source
+	}
+	return a.source, nil
+}
+
+func (a *app) newMarketDataSource() (domain.MarketDataSource, error) {
+	switch a.conf.Loader.Source {
+	case config.SourceReplay:
+		if a.conf.Loader.ReplayFile != "" {
+			return replay.Load(a.conf.Loader.ReplayFile)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		items, err := a.watchlist().Watchlist(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "load watchlist")
+		}
+		symbols := make([]string, 0, len(items))
+		for _, item := range items {
+			if !slices.Contains(symbols, item.Symbol) {
+				symbols = append(symbols, item.Symbol)
+			}
+		}
+		frames := replay.Generate(
+			1, domain.ListExchanges, symbols, time.Now().Add(-24*time.Hour), 24*60, time.Minute,
+		)
+		return replay.New(frames...), nil
+	case config.SourceExchanges:
+		binanceConf, bybitConf := a.conf.Exchanges.Binance, a.conf.Exchanges.Bybit
+		binanceClient, err := binance.New(
+			binanceConf.URL, source.NewRequester(http.DefaultClient, binanceConf.RateLimit, binanceConf.Burst),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "init binance")
+		}
+		bybitClient, err := bybit.New(
+			bybitConf.URL, source.NewRequester(http.DefaultClient, bybitConf.RateLimit, bybitConf.Burst),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "init bybit")
+		}
+		return source.NewExchanges(binanceClient, bybitClient), nil
+	default:
+		return cryptoloader.New(a.conf.Loader.URL, http.DefaultClient)
+	}
+}
+
+func (a *app) loaderComponent() (component, error) {
+	source, err := a.marketDataSource()
+	if err != nil {
+		return component{}, errors.Wrap(err, "init market data source")
+	}
+	loaderConf := loader.Config{
+		LoadPricesDuration:         a.conf.Intervals.LoadPrices,
+		LoadExchangePricesDuration: a.conf.Intervals.LoadExchangePrices,
+		LoadSymbolsDuration:        a.conf.Intervals.LoadSymbols,
+		LoadSnapshotDuration:       a.conf.Intervals.LoadSnapshot,
+		LoadCandlesticksDuration:   a.conf.Intervals.LoadCandlesticks,
+	}
+	price := loader.NewPrice(source, db.NewSymbols(a.conn), db.NewPriceRepository(a.conn), a.prices(), loaderConf)
+	price.WithListings(db.NewListing(a.conn))
+	if a.conf.Loader.Mode == config.ModeStream {
+		clients := []*stream.Client{
+			stream.NewClient(
+				binance.NewStream(a.conf.Stream.BinanceURL), a.subscription(domain.BinanceExchange), a.conf.Stream.Heartbeat,
+			),
+			stream.NewClient(
+				bybit.NewStream(a.conf.Stream.BybitURL), a.subscription(domain.BybitExchange), a.conf.Stream.Heartbeat,
+			),
+		}
+		loaderStream := loader.NewStream(clients, source, a.prices(), a.candlesticks(), price, a.conf.Stream.Flush)
+		return component{name: "loader", run: loaderStream.Run}, nil
+	}
+	loaderPrice := loader.NewLoader(source, a.prices(), a.candlesticks(), a.watchlist(), price, loaderConf)
+	return component{name: "loader", run: loaderPrice.Run}, nil
+}
+
+func (a *app) backfill() (*loader.Backfill, error) {
+	source, err := a.marketDataSource()
+	if err != nil {
+		return nil, errors.Wrap(err, "init market data source")
+	}
+	history, ok := source.(domain.CandlestickHistory)
+	if !ok {
+		return nil, errBackfillNotSupported
+	}
+	candlesticks := db.NewCandlestick(a.conn)
+	backfill := loader.NewBackfill(history, candlesticks, candlesticks, db.NewBackfill(a.conn), a.watchlist())
+	backfill.WithLookback(a.conf.Backfill.Lookback)
+	return backfill, nil
+}
+
+func (a *app) backfillComponent() (component, error) {
+	backfill, err := a.backfill()
+	if errors.Is(err, errBackfillNotSupported) {
+		return component{name: "backfill", run: func(ctx context.Context) error {
+			zap.L().Warn("gap detector is disabled", zap.String("source", a.conf.Loader.Source), zap.Error(err))
+			<-ctx.Done()
+			return ctx.Err()
+		}}, nil
+	}
+	if err != nil {
+		return component{}, err
+	}
+	return component{name: "backfill", run: func(ctx context.Context) error {
+		return backfill.Run(ctx, a.conf.Backfill.Detect)
+	}}, nil
+}
+
+func (a *app) resampler() (*calculation.Resampler, error) {
+	sources, err := domain.ParseIntervals(a.conf.Resample.Sources)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := domain.ParseIntervals(a.conf.Resample.Targets)
+	if err != nil {
+		return nil, err
+	}
+	resampler := calculation.NewResampler(db.NewCandlestick(a.conn), a.candlesticks(), a.watchlist())
+	resampler.WithIntervals(sources, targets)
+	resampler.WithSessionOffset(a.conf.Resample.SessionOffset)
+	resampler.WithLookback(a.conf.Resample.Lookback)
+	resampler.WithTolerance(a.conf.Resample.Tolerance)
+	return resampler, nil
+}
+
+func (a *app) resampleComponent() (component, error) {
+	resampler, err := a.resampler()
+	if err != nil {
+		return component{}, err
+	}
+	return component{name: "resample", run: func(ctx context.Context) error {
+		return resampler.Run(ctx, a.conf.Resample.Period)
+	}}, nil
+}
+
+func (a *app) calculateComponent() (component, error) {
+	calculatorApp := calculation.NewChangeCalculator(
+		db.NewPriceRepository(a.conn), db.NewPriceChanges(a.conn), db.NewSymbols(a.conn),
+	)
+	if a.conf.Loader.Source != config.SourceCryptoLoader {
+		// Exchanges and replay sources have prices of listed exchanges only.
+		calculatorApp.WithMinExchanges(len(domain.ListExchanges))
+	}
+	alertEvaluator := calculation.NewAlertEvaluator(db.NewAlert(a.conn), db.NewAlert(a.conn))
+	alertEvaluator.WithIndicatorValues(a.indicators())
+	alertNotifier, err := a.notifier()
+	if err != nil {
+		return component{}, err
+	}
+	alertEvaluator.WithNotifications(alertNotifier.Enabled())
+	spreadCalculator := calculation.NewSpreadCalculator(db.NewSpread(a.conn), db.NewPriceChanges(a.conn))
+	spreadCalculator.WithFees(a.conf.Spread.DefaultFee, a.conf.Spread.Fees)
+	calculatorApp.WithHandlers(
+		calculation.NewLevelCrossing(db.NewLevel(a.conn), db.NewLevel(a.conn)),
+		alertEvaluator,
+		spreadCalculator,
+	)
+	return component{name: "calculate", run: func(ctx context.Context) error {
+		return calculatorApp.Run(ctx, a.conf.Intervals.Recalculate)
+	}}, nil
+}
+
+func (a *app) techAnalysisComponent() (component, error) {
+	instances, err := a.indicatorInstances()
+	if err != nil {
+		return component{}, err
+	}
+	techAnalysis := calculation.NewTechAnalysis(a.candlesticks(), a.indicators(), a.watchlist())
+	techAnalysis.WithInstances(instances, a.indicators())
+	techAnalysis.WithLevels(db.NewLevel(a.conn))
+	return component{name: "tech analysis", run: func(ctx context.Context) error {
+		return techAnalysis.Run(ctx, a.conf.Intervals.TechAnalysis)
+	}}, nil
+}
+
+func (a *app) patternComponent() (component, error) {
+	detector := calculation.NewPatternDetector(a.candlesticks(), db.NewPattern(a.conn), a.watchlist())
+	return component{name: "patterns", run: func(ctx context.Context) error {
+		return detector.Run(ctx, a.conf.Intervals.Patterns)
+	}}, nil
+}
+
+func (a *app) levelComponent() (component, error) {
+	detector := calculation.NewLevelDetector(db.NewCandlestick(a.conn), db.NewLevel(a.conn), a.watchlist())
+	detector.WithLookback(a.conf.Levels.Lookback)
+	detector.WithParams(level.Params{
+		SwingWindow: a.conf.Levels.SwingWindow,
+		Tolerance:   a.conf.Levels.Tolerance,
+		MinTouches:  a.conf.Levels.MinTouches,
+	})
+	return component{name: "levels", run: func(ctx context.Context) error {
+		return detector.Run(ctx, a.conf.Levels.Period)
+	}}, nil
+}
+
+func (a *app) alertComponent() (component, error) {
+	alertEvaluator := calculation.NewAlertEvaluator(db.NewAlert(a.conn), db.NewAlert(a.conn))
+	alertEvaluator.WithNewSymbols(db.NewPriceRepository(a.conn))
+	alertNotifier, err := a.notifier()
+	if err != nil {
+		return component{}, err
+	}
+	alertEvaluator.WithNotifications(alertNotifier.Enabled())
+	return component{name: "alerts", run: func(ctx context.Context) error {
+		return alertEvaluator.Run(ctx, a.conf.Intervals.Alerts)
+	}}, nil
+}
+
+// notifier is shared by alert evaluators, alerts saved by them to outbox are delivered by notifier component.
+func (a *app) notifier() (*notifier.Notifier, error) {
+	if a.alertNotifier != nil {
+		return a.alertNotifier, nil
+	}
+	sinks, err := notifier.NewSinks(a.conf.Notifier, http.DefaultClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "notifier")
+	}
+	a.alertNotifier = notifier.NewNotifier(db.NewNotificationOutbox(a.conn), db.NewDeadLetter(a.conn), sinks...)
+	a.alertNotifier.WithPoll(a.conf.Notifier.Poll)
+	a.alertNotifier.WithMaxElapsed(a.conf.Notifier.MaxElapsed)
+	return a.alertNotifier, nil
+}
+
+func (a *app) notifierComponent() (component, error) {
+	alertNotifier, err := a.notifier()
+	if err != nil {
+		return component{}, err
+	}
+	return component{name: "notifier", run: alertNotifier.Run}, nil
+}
+
+// timescale returns TimescaleDB objects which repositories use, plain Postgres is used without them.
+func (a *app) timescale() (db.TimescaleStatus, error) {
+	if a.timescaleStatus != nil {
+		return *a.timescaleStatus, nil
+	}
+	var status db.TimescaleStatus
+	if a.conf.Timescale.Mode != config.TimescaleOff {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		var err error
+		if status, err = db.NewTimescale(a.conn).Status(ctx); err != nil {
+			return db.TimescaleStatus{}, errors.Wrap(err, "timescale")
+		}
+		if !status.Installed && a.conf.Timescale.Mode == config.TimescaleRequired {
+			return db.TimescaleStatus{}, errors.New("timescale: extension timescaledb is not installed, run migrate up")
+		}
+		zap.L().Info(
+			"timescale storage mode",
+			zap.Bool("installed", status.Installed),
+			zap.Strings("hypertables", status.Hypertables),
+			zap.Strings("continuous_aggregates", status.ContinuousAggregates),
+		)
+	}
+	a.timescaleStatus = &status
+	return status, nil
+}
+
+func (a *app) aggregateComponent() (component, error) {
+	status, err := a.timescale()
+	if err != nil {
+		return component{}, err
+	}
+	metricCalculator := calculation.NewChangeCoefficient(
+		db.NewPriceChanges(a.conn), db.NewAggregation(a.conn), db.NewSymbols(a.conn),
+	)
+	metricCalculator.WithContinuousAggregates(status.HasContinuousAggregates())
+	return component{name: "aggregate", run: func(ctx context.Context) error {
+		metricCalculator.Run(ctx, a.conf.Intervals.PriceAggregation)
+		<-ctx.Done()
+		return ctx.Err()
+	}}, nil
+}
+
+func (a *app) priceHistory() *calculation.PriceHistory {
+	if a.history == nil {
+		a.history = calculation.NewPriceHistory(db.NewPriceHistory(a.conn))
+		a.history.WithMaxBars(a.conf.History.MaxBars)
+		a.history.WithLateness(a.conf.History.Lateness)
+	}
+	return a.history
+}
+
+func (a *app) historyComponent() (component, error) {
+	history := a.priceHistory()
+	return component{name: "history", run: func(ctx context.Context) error {
+		return history.Run(ctx, a.conf.History.Period)
+	}}, nil
+}
+
+// cleaner keeps rows of prices and tiers of price history until they are rolled up into the next tier.
+func (a *app) cleaner() (*cleaner.Cleaner, error) {
+	status, err := a.timescale()
+	if err != nil {
+		return nil, err
+	}
+	retentionRepo := db.NewRetention(a.conn)
+	retentionRepo.WithHypertables(status.Hypertables...)
+	retention, err := cleaner.NewCleaner(retentionRepo, a.conf.Retention.Tables)
+	if err != nil {
+		return nil, err
+	}
+	retention.WithBatch(a.conf.Retention.BatchSize, a.conf.Retention.Pause)
+	retention.WithDryRun(a.conf.Retention.DryRun)
+	for table, guard := range a.priceHistory().Guards() {
+		retention.WithGuard(table, guard)
+	}
+	return retention, nil
+}
+
+func (a *app) cleanerComponent() (component, error) {
+	retention, err := a.cleaner()
+	if err != nil {
+		return component{}, err
+	}
+	return component{name: "cleaner", run: func(ctx context.Context) error {
+		return retention.Run(ctx, a.conf.Retention.Period)
+	}}, nil
+}
+
+func (a *app) serveComponent() (component, error) {
+	instances, err := a.indicatorInstances()
+	if err != nil {
+		return component{}, err
+	}
+	symbolRepo := db.NewSymbols(a.conn)
+	priceController := controller.NewPrice(
+		db.NewPriceRepository(a.conn), a.candlesticks(), symbolRepo, db.NewPriceChanges(a.conn), a.watchlist(),
+	)
+	priceController.WithPatterns(db.NewPattern(a.conn))
+	patternController := controller.NewPattern(db.NewPattern(a.conn))
+	spreadController := controller.NewSpread(db.NewSpread(a.conn))
+	spreadController.WithOpportunities(a.conf.Spread.Window, a.conf.Spread.Top, a.conf.Spread.MinNet)
+	serv := http_server.NewServer()
+	serv.RegistrationPage(priceController)
+	serv.RegistrationPage(patternController)
+	serv.RegistrationPage(spreadController)
+	serv.RegistrationApi(patternController)
+	serv.RegistrationApi(spreadController)
+	serv.RegistrationApi(controller.NewLevel(db.NewLevel(a.conn), db.NewLevel(a.conn)))
+	serv.RegistrationApi(controller.NewAlert(db.NewAlert(a.conn), db.NewAlert(a.conn)))
+	serv.RegistrationApi(controller.NewNotification(db.NewDeadLetter(a.conn)))
+	serv.RegistrationApi(controller.NewListing(db.NewListing(a.conn)))
+	serv.RegistrationApi(controller.NewPriceHistory(a.priceHistory()))
+	serv.RegistrationApi(priceController)
+	serv.RegistrationApi(controller.NewWatchlist(a.watchlist()))
+	serv.RegistrationApi(controller.NewIndicator(a.indicators(), indicator.Default, instances, a.indicators()))
+	serv.WithAuthor("developer")
+	serv.WithApplicationName("crypto_analyst")
+	return component{name: "serve", run: func(ctx context.Context) error {
+		go func() {
+			<-ctx.Done()
+			serv.Close()
+		}()
+		err := serv.Run(net.JoinHostPort(a.conf.Server.Host, a.conf.Server.Port))
+		if errors.Is(err, http.ErrServerClosed) {
+			return ctx.Err()
+		}
+		return err
+	}}, nil
+}
+
+// metricsComponent serves metrics of command on its port, ok is false when metrics of command are disabled.
+func (a *app) metricsComponent(command string) (component, bool) {
+	port := a.conf.Metrics.CommandPort(command)
+	if port == config.MetricsDisabled {
+		return component{}, false
+	}
+	return component{name: "metrics", run: func(ctx context.Context) error {
+		return metrics.Handler(ctx, a.conf.Metrics.Host, port)
+	}}, true
+}
+
+// runComponents starts long-running components with metrics server of command and stops all of them
+// when one fails or on signal.
+func runComponents(builders ...func(a *app) (component, error)) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return run(commandName(cmd), true, builders...)
+	}
+}
+
+// runOnce runs one-shot components without metrics server, so it does not conflict with running services.
+func runOnce(builders ...func(a *app) (component, error)) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return run(commandName(cmd), false, builders...)
+	}
+}
+
+// commandName is key of command in metrics.commands, root command runs all components.
+func commandName(cmd *cobra.Command) string {
+	if !cmd.HasParent() {
+		return "all"
+	}
+	return cmd.Name()
+}
+
+func run(command string, withMetrics bool, builders ...func(a *app) (component, error)) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	a, err := newApp(appConfig)
+	if err != nil {
+		return errors.Wrap(err, "init database")
+	}
+	defer a.Close()
+	components := make([]component, 0, len(builders))
+	for _, build := range builders {
+		c, err := build(a)
+		if err != nil {
+			return err
+		}
+		components = append(components, c)
+	}
+	if withMetrics {
+		if c, ok := a.metricsComponent(command); ok {
+			components = append(components, c)
+		}
+	}
+	errCh := make(chan error, len(components))
+	for _, c := range components {
+		go func(c component) {
+			defer shutdown.HandlePanic()
+			defer cancel()
+			err := c.run(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				zap.L().Error("error execute component", zap.String("component", c.name), zap.Error(err))
+				errCh <- err
+			}
+		}(c)
+	}
+	<-ctx.Done()
+	select {
+	case err := <-errCh:
+		return err
+	default:
+		return nil
+	}
+}
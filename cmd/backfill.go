@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/spf13/cobra"
+)
+
+var backfillFlags struct {
+	exchange string
+	symbol   string
+	interval string
+	from     string
+	to       string
+}
+
+// backfillTimeLayouts are accepted formats of --from and --to, time without zone is UTC.
+var backfillTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+var backfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Load missed candlesticks of symbol for time range, interrupted backfill is resumed on the next run",
+	RunE: runOnce(func(a *app) (component, error) {
+		interval, err := domain.ParseInterval(backfillFlags.interval)
+		if err != nil {
+			return component{}, err
+		}
+		from, err := parseBackfillTime(backfillFlags.from)
+		if err != nil {
+			return component{}, err
+		}
+		to := time.Now()
+		if backfillFlags.to != "" {
+			if to, err = parseBackfillTime(backfillFlags.to); err != nil {
+				return component{}, err
+			}
+		}
+		backfill, err := a.backfill()
+		if err != nil {
+			return component{}, err
+		}
+		return component{name: "backfill", run: func(ctx context.Context) error {
+			return backfill.Fill(
+				ctx,
+				strings.ToLower(backfillFlags.exchange),
+				strings.ToUpper(backfillFlags.symbol),
+				interval,
+				from,
+				to,
+			)
+		}}, nil
+	}),
+}
+
+func parseBackfillTime(val string) (time.Time, error) {
+	for _, layout := range backfillTimeLayouts {
+		if t, err := time.ParseInLocation(layout, val, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("wrong time %q, expected one of: %s", val, strings.Join(backfillTimeLayouts, ", "))
+}
+
+func init() {
+	backfillCmd.Flags().StringVar(&backfillFlags.exchange, "exchange", domain.BinanceExchange, "exchange of symbol")
+	backfillCmd.Flags().StringVar(&backfillFlags.symbol, "symbol", "", "symbol, e.g. BTCUSDT")
+	backfillCmd.Flags().StringVar(
+		&backfillFlags.interval, "interval", domain.OneHourInterval.String(), "interval of candlesticks",
+	)
+	backfillCmd.Flags().StringVar(&backfillFlags.from, "from", "", "start of range, e.g. 2024-01-01")
+	backfillCmd.Flags().StringVar(&backfillFlags.to, "to", "", "end of range, now by default")
+	_ = backfillCmd.MarkFlagRequired("symbol")
+	_ = backfillCmd.MarkFlagRequired("from")
+	rootCmd.AddCommand(backfillCmd)
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var calculateCmd = &cobra.Command{
+	Use:   "calculate",
+	Short: "Calculate price changes, price history, indicators, candlestick patterns, levels and alerts of watchlist symbols",
+	RunE: runComponents(
+		(*app).calculateComponent,
+		(*app).historyComponent,
+		(*app).techAnalysisComponent,
+		(*app).patternComponent,
+		(*app).levelComponent,
+		(*app).alertComponent,
+		(*app).notifierComponent,
+	),
+}
+
+func init() {
+	rootCmd.AddCommand(calculateCmd)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+var cleanFlags struct {
+	dryRun bool
+	once   bool
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Delete old rows of tables by retention policies",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cleanFlags.once {
+			return runOnce(cleanComponent)(cmd, args)
+		}
+		return runComponents(cleanComponent)(cmd, args)
+	},
+}
+
+func cleanComponent(a *app) (component, error) {
+	retention, err := a.cleaner()
+	if err != nil {
+		return component{}, err
+	}
+	if cleanFlags.dryRun {
+		retention.WithDryRun(true)
+	}
+	return component{name: "cleaner", run: func(ctx context.Context) error {
+		if cleanFlags.once {
+			retention.Clean(ctx)
+			return ctx.Err()
+		}
+		return retention.Run(ctx, a.conf.Retention.Period)
+	}}, nil
+}
+
+func init() {
+	cleanCmd.Flags().BoolVar(&cleanFlags.dryRun, "dry-run", false, "log rows which would be deleted without deletion")
+	cleanCmd.Flags().BoolVar(&cleanFlags.once, "once", false, "apply policies once and exit")
+	rootCmd.AddCommand(cleanCmd)
+}
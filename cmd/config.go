@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect application configuration",
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print effective configuration (defaults, file, env and flags merged)",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, line := range config.Settings(v) {
+			fmt.Fprintln(cmd.OutOrStdout(), line)
+		}
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configPrintCmd)
+}
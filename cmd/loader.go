@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var loaderCmd = &cobra.Command{
+	Use:   "loader",
+	Short: "Load prices and candlesticks, fill gaps of candlesticks",
+	RunE:  runComponents((*app).loaderComponent, (*app).backfillComponent),
+}
+
+func init() {
+	rootCmd.AddCommand(loaderCmd)
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/config"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage/db"
+	"github.com/AlekseyPorandaykin/crypto_analyst/migrations"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/database"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/migration"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var migrateDownSteps int
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database schema",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator, closeFn, err := createMigrator()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		applied, err := migrator.Up(cmd.Context())
+		for _, item := range applied {
+			fmt.Fprintf(cmd.OutOrStdout(), "applied %04d_%s\n", item.Version, item.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "no pending migrations")
+		}
+		return setupTimescale(cmd)
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Rollback last applied migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator, closeFn, err := createMigrator()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		rolledBack, err := migrator.Down(cmd.Context(), migrateDownSteps)
+		for _, item := range rolledBack {
+			fmt.Fprintf(cmd.OutOrStdout(), "rolled back %04d_%s\n", item.Version, item.Name)
+		}
+		return err
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		migrator, closeFn, err := createMigrator()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		statuses, err := migrator.Status(cmd.Context())
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%04d_%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+	},
+}
+
+func createMigrator() (*migration.Migrator, func(), error) {
+	conn, err := database.CreateConnection(appConfig.Database)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "init database")
+	}
+	migrator, err := migration.New(conn, migrations.FS, migrations.Schema, migrations.Table)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, errors.Wrap(err, "init migrator")
+	}
+	migrator.WithHook(db.PriceChangesBackfillVersion, db.BackfillPriceChangesTime)
+	migrator.WithHook(db.PricesNumericVersion, db.DecompressHypertables)
+	return migrator, func() { _ = conn.Close() }, nil
+}
+
+// setupTimescale converts tables to hypertables and creates continuous aggregates when timescale mode is on.
+func setupTimescale(cmd *cobra.Command) error {
+	if appConfig.Timescale.Mode == config.TimescaleOff {
+		return nil
+	}
+	conn, err := database.CreateConnection(appConfig.Database)
+	if err != nil {
+		return errors.Wrap(err, "init database")
+	}
+	defer func() { _ = conn.Close() }()
+	timescale := db.NewTimescale(conn)
+	available, err := timescale.Available(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "check timescaledb")
+	}
+	if !available {
+		if appConfig.Timescale.Mode == config.TimescaleRequired {
+			return errors.New("extension timescaledb is not available")
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "timescaledb is not available, plain postgres is used")
+		return nil
+	}
+	steps, err := timescale.Setup(cmd.Context(), db.TimescaleOptions{
+		ChunkInterval: appConfig.Timescale.ChunkInterval,
+		CompressAfter: appConfig.Timescale.CompressAfter,
+	})
+	for _, step := range steps {
+		fmt.Fprintf(cmd.OutOrStdout(), "created %s\n", step)
+	}
+	return errors.Wrap(err, "setup timescaledb")
+}
+
+func init() {
+	migrateDownCmd.Flags().IntVar(&migrateDownSteps, "steps", 1, "number of migrations to rollback")
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/spf13/cobra"
+)
+
+var notifyFlags struct {
+	message string
+}
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Deliver alerts of outbox to notifier sinks",
+	RunE:  runComponents((*app).notifierComponent),
+}
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Send test alert to every notifier sink and print result of sinks",
+	RunE: runOnce(func(a *app) (component, error) {
+		alertNotifier, err := a.notifier()
+		if err != nil {
+			return component{}, err
+		}
+		return component{name: "notify test", run: func(ctx context.Context) error {
+			now := time.Now()
+			failed := alertNotifier.Send(ctx, domain.Alert{
+				RuleName:  "test",
+				Kind:      domain.PriceAlert,
+				Exchange:  domain.BinanceExchange,
+				Symbol:    "BTCUSDT",
+				Message:   notifyFlags.message,
+				Date:      now,
+				CreatedAt: now,
+			})
+			for _, sink := range a.conf.Notifier.Sinks {
+				name := sink.Name
+				if name == "" {
+					name = sink.Type
+				}
+				status := "sent"
+				if err, has := failed[name]; has {
+					status = "failed: " + err.Error()
+				}
+				_, _ = fmt.Fprintf(rootCmd.OutOrStdout(), "%s: %s\n", name, status)
+			}
+			if len(failed) > 0 {
+				return fmt.Errorf("%d of %d sinks failed", len(failed), len(a.conf.Notifier.Sinks))
+			}
+			return nil
+		}}, nil
+	}),
+}
+
+func init() {
+	notifyTestCmd.Flags().StringVar(&notifyFlags.message, "message", "test notification", "body of test alert")
+	notifyCmd.AddCommand(notifyTestCmd)
+	rootCmd.AddCommand(notifyCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/spf13/cobra"
+)
+
+var resampleFlags struct {
+	exchange string
+	symbol   string
+	from     string
+	to       string
+}
+
+var resampleCmd = &cobra.Command{
+	Use:   "resample",
+	Short: "Build higher interval candlesticks of watchlist symbols from lower interval candlesticks",
+	RunE:  runComponents((*app).resampleComponent),
+}
+
+var resampleCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Compare derived candlesticks of symbol with exchange candlesticks and print divergences",
+	RunE: runOnce(func(a *app) (component, error) {
+		from, err := parseBackfillTime(resampleFlags.from)
+		if err != nil {
+			return component{}, err
+		}
+		to := time.Now()
+		if resampleFlags.to != "" {
+			if to, err = parseBackfillTime(resampleFlags.to); err != nil {
+				return component{}, err
+			}
+		}
+		resampler, err := a.resampler()
+		if err != nil {
+			return component{}, err
+		}
+		return component{name: "resample check", run: func(ctx context.Context) error {
+			divergences, err := resampler.Check(
+				ctx, strings.ToLower(resampleFlags.exchange), strings.ToUpper(resampleFlags.symbol), from, to,
+			)
+			if err != nil {
+				return err
+			}
+			encoder := json.NewEncoder(rootCmd.OutOrStdout())
+			for _, item := range divergences {
+				if err := encoder.Encode(item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}}, nil
+	}),
+}
+
+func init() {
+	resampleCheckCmd.Flags().StringVar(&resampleFlags.exchange, "exchange", domain.BinanceExchange, "exchange of symbol")
+	resampleCheckCmd.Flags().StringVar(&resampleFlags.symbol, "symbol", "", "symbol, e.g. BTCUSDT")
+	resampleCheckCmd.Flags().StringVar(&resampleFlags.from, "from", "", "start of range, e.g. 2024-01-01")
+	resampleCheckCmd.Flags().StringVar(&resampleFlags.to, "to", "", "end of range, now by default")
+	_ = resampleCheckCmd.MarkFlagRequired("symbol")
+	_ = resampleCheckCmd.MarkFlagRequired("from")
+	resampleCmd.AddCommand(resampleCheckCmd)
+	rootCmd.AddCommand(resampleCmd)
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run read-only http server",
+	RunE:  runComponents((*app).serveComponent),
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage/db"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/database"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var watchlistItem struct {
+	exchange  string
+	symbol    string
+	intervals []string
+	rank      int
+}
+
+var watchlistCmd = &cobra.Command{
+	Use:   "watchlist",
+	Short: "Manage symbols for which candlesticks and snapshots are loaded",
+}
+
+var watchlistListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print watchlist",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, closeFn, err := createWatchlist()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		items, err := repo.Watchlist(cmd.Context())
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			intervals := make([]string, 0, len(item.Intervals))
+			for _, interval := range item.Intervals {
+				intervals = append(intervals, interval.String())
+			}
+			fmt.Fprintf(
+				cmd.OutOrStdout(), "%s\t%s\t%s\t%d\n", item.Exchange, item.Symbol, strings.Join(intervals, ","), item.Rank,
+			)
+		}
+		return nil
+	},
+}
+
+var watchlistAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add symbol to watchlist or update its intervals and rank",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		intervals, err := domain.ParseIntervals(watchlistItem.intervals)
+		if err != nil {
+			return err
+		}
+		item := domain.WatchlistItem{
+			Exchange:  watchlistItem.exchange,
+			Symbol:    watchlistItem.symbol,
+			Intervals: intervals,
+			Rank:      watchlistItem.rank,
+		}.Normalize()
+		if err := item.Validate(); err != nil {
+			return err
+		}
+		repo, closeFn, err := createWatchlist()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		if err := repo.SaveWatchlistItem(cmd.Context(), item); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "saved %s %s\n", item.Exchange, item.Symbol)
+		return nil
+	},
+}
+
+var watchlistRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove symbol from watchlist",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		item := domain.WatchlistItem{Exchange: watchlistItem.exchange, Symbol: watchlistItem.symbol}.Normalize()
+		repo, closeFn, err := createWatchlist()
+		if err != nil {
+			return err
+		}
+		defer closeFn()
+		if err := repo.DeleteWatchlistItem(cmd.Context(), item.Exchange, item.Symbol); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "removed %s %s\n", item.Exchange, item.Symbol)
+		return nil
+	},
+}
+
+func createWatchlist() (*db.Watchlist, func(), error) {
+	conn, err := database.CreateConnection(appConfig.Database)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "init database")
+	}
+	return db.NewWatchlist(conn), func() { _ = conn.Close() }, nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{watchlistAddCmd, watchlistRemoveCmd} {
+		c.Flags().StringVar(&watchlistItem.exchange, "exchange", domain.BinanceExchange, "exchange of symbol")
+		c.Flags().StringVar(&watchlistItem.symbol, "symbol", "", "symbol, e.g. BTCUSDT")
+		_ = c.MarkFlagRequired("symbol")
+	}
+	watchlistAddCmd.Flags().StringSliceVar(
+		&watchlistItem.intervals,
+		"intervals",
+		[]string{domain.OneHourInterval.String(), domain.FourHourInterval.String()},
+		"intervals of candlesticks: 1m, 5m, 15m, 30m, 1h, 4h, 1d, 1w",
+	)
+	watchlistAddCmd.Flags().IntVar(&watchlistItem.rank, "rank", 0, "rank of symbol, popular symbols are shown first")
+	watchlistCmd.AddCommand(watchlistListCmd, watchlistAddCmd, watchlistRemoveCmd)
+	rootCmd.AddCommand(watchlistCmd)
+}
@@ -0,0 +1,264 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrAlertRuleNotFound = errors.New("alert rule not found")
+
+type AlertKind string
+
+const (
+	// CoefficientAlert compares CoefficientOfChange of price change with threshold.
+	CoefficientAlert AlertKind = "coefficient"
+	// PriceAlert compares price of price change with threshold.
+	PriceAlert AlertKind = "price"
+	// IndicatorAlert compares output of indicator instance with threshold.
+	IndicatorAlert AlertKind = "indicator"
+	// NewSymbolAlert fires on every symbol listed on exchange.
+	NewSymbolAlert AlertKind = "new_symbol"
+)
+
+var ListAlertKinds = []AlertKind{CoefficientAlert, PriceAlert, IndicatorAlert, NewSymbolAlert}
+
+type AlertOperator string
+
+const (
+	AboveOperator     AlertOperator = ">"
+	BelowOperator     AlertOperator = "<"
+	CrossOperator     AlertOperator = "cross"
+	CrossUpOperator   AlertOperator = "cross_up"
+	CrossDownOperator AlertOperator = "cross_down"
+)
+
+var ListAlertOperators = []AlertOperator{
+	AboveOperator, BelowOperator, CrossOperator, CrossUpOperator, CrossDownOperator,
+}
+
+// AlertRule fires alert when its condition becomes true. Fired rule is active and does not fire again
+// until value returns by Hysteresis behind Threshold and Cooldown passes since the last alert.
+type AlertRule struct {
+	ID       int64         `json:"id" db:"id"`
+	Name     string        `json:"name" db:"name"`
+	Kind     AlertKind     `json:"kind" db:"kind"`
+	Exchange string        `json:"exchange" db:"exchange"`
+	Symbol   string        `json:"symbol" db:"symbol"`
+	Operator AlertOperator `json:"operator" db:"operator"`
+	// Threshold is compared with value of rule kind: CoefficientOfChange, price or indicator output.
+	Threshold float64 `json:"threshold" db:"threshold"`
+	// IndicatorID is ID of indicator instance and Output is its output compared by IndicatorAlert rule.
+	IndicatorID string  `json:"indicator_id" db:"indicator_id"`
+	Output      string  `json:"output" db:"output"`
+	CooldownSec int64   `json:"cooldown_sec" db:"cooldown_sec"`
+	Hysteresis  float64 `json:"hysteresis" db:"hysteresis"`
+	Enabled     bool    `json:"enabled" db:"enabled"`
+
+	AlertRuleState
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AlertRuleState is changed by evaluation of rule.
+type AlertRuleState struct {
+	Active        bool       `json:"active" db:"active"`
+	LastTriggered *time.Time `json:"last_triggered" db:"last_triggered"`
+	// LastValue is value of the previous evaluation, it is compared with threshold by cross operators.
+	LastValue *float64 `json:"last_value" db:"last_value"`
+}
+
+func (r AlertRule) Cooldown() time.Duration {
+	return time.Duration(r.CooldownSec) * time.Second
+}
+
+// Normalize fixes case of exchange and symbol.
+func (r AlertRule) Normalize() AlertRule {
+	r.Exchange = strings.ToLower(strings.TrimSpace(r.Exchange))
+	r.Symbol = strings.ToUpper(strings.TrimSpace(r.Symbol))
+	r.IndicatorID = strings.TrimSpace(r.IndicatorID)
+	return r
+}
+
+func (r AlertRule) Validate() error {
+	if !slices.Contains(ListAlertKinds, r.Kind) {
+		return fmt.Errorf("unknown kind: %s", r.Kind)
+	}
+	if r.Exchange != "" && !slices.Contains(ListExchanges, r.Exchange) {
+		return fmt.Errorf("unknown exchange: %s", r.Exchange)
+	}
+	if r.CooldownSec < 0 {
+		return errors.New("cooldown_sec must not be negative")
+	}
+	if r.Hysteresis < 0 {
+		return errors.New("hysteresis must not be negative")
+	}
+	if r.Kind == NewSymbolAlert {
+		return nil
+	}
+	if r.Exchange == "" || r.Symbol == "" {
+		return fmt.Errorf("%s rule requires exchange and symbol", r.Kind)
+	}
+	if !slices.Contains(ListAlertOperators, r.Operator) {
+		return fmt.Errorf("unknown operator: %s", r.Operator)
+	}
+	if math.IsNaN(r.Threshold) || math.IsInf(r.Threshold, 0) {
+		return errors.New("threshold must be finite")
+	}
+	if r.Kind == IndicatorAlert && (r.IndicatorID == "" || r.Output == "") {
+		return errors.New("indicator rule requires indicator_id and output")
+	}
+	return nil
+}
+
+// Matches reports whether rule watches symbol of exchange.
+func (r AlertRule) Matches(exchange, symbol string) bool {
+	return (r.Exchange == "" || r.Exchange == exchange) && (r.Symbol == "" || r.Symbol == symbol)
+}
+
+// Evaluated reports whether value of date is not after the last alert of rule, such value must not fire it again.
+func (r AlertRule) Evaluated(date time.Time) bool {
+	return r.LastTriggered != nil && !date.After(*r.LastTriggered)
+}
+
+// Evaluate checks value against rule and returns whether alert fires and the next state of rule.
+// Previous value of cross operators is prev when it is given, otherwise LastValue of rule.
+func (r AlertRule) Evaluate(prev *float64, value float64, now time.Time) (bool, AlertRuleState) {
+	state := r.AlertRuleState
+	if prev == nil {
+		prev = state.LastValue
+	}
+	state.LastValue = &value
+	if state.Active {
+		state.Active = !r.released(value)
+		return false, state
+	}
+	if !r.condition(prev, value) {
+		return false, state
+	}
+	if state.LastTriggered != nil && now.Sub(*state.LastTriggered) < r.Cooldown() {
+		return false, state
+	}
+	state.Active = true
+	state.LastTriggered = &now
+	return true, state
+}
+
+func (r AlertRule) condition(prev *float64, value float64) bool {
+	crossUp := prev != nil && *prev < r.Threshold && value >= r.Threshold
+	crossDown := prev != nil && *prev > r.Threshold && value <= r.Threshold
+	switch r.Operator {
+	case AboveOperator:
+		return value > r.Threshold
+	case BelowOperator:
+		return value < r.Threshold
+	case CrossUpOperator:
+		return crossUp
+	case CrossDownOperator:
+		return crossDown
+	case CrossOperator:
+		return crossUp || crossDown
+	default:
+		return false
+	}
+}
+
+// released reports whether value is far enough from threshold to rearm rule.
+func (r AlertRule) released(value float64) bool {
+	switch r.Operator {
+	case AboveOperator:
+		return value <= r.Threshold-r.Hysteresis
+	case BelowOperator:
+		return value >= r.Threshold+r.Hysteresis
+	default:
+		return math.Abs(value-r.Threshold) >= r.Hysteresis
+	}
+}
+
+// Alert is fired alert rule.
+type Alert struct {
+	ID        int64     `json:"id" db:"id"`
+	RuleID    int64     `json:"rule_id" db:"rule_id"`
+	RuleName  string    `json:"rule_name" db:"rule_name"`
+	Kind      AlertKind `json:"kind" db:"kind"`
+	Exchange  string    `json:"exchange" db:"exchange"`
+	Symbol    string    `json:"symbol" db:"symbol"`
+	Value     float64   `json:"value" db:"value"`
+	Message   string    `json:"message" db:"message"`
+	Date      time.Time `json:"date" db:"datetime"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type AlertRuleStorage interface {
+	AlertRules(ctx context.Context) ([]AlertRule, error)
+	AlertRule(ctx context.Context, id int64) (AlertRule, error)
+	// SaveAlertRule creates rule without ID and updates settings of existing one, state of rule is not changed.
+	SaveAlertRule(ctx context.Context, rule AlertRule) (AlertRule, error)
+	DeleteAlertRule(ctx context.Context, id int64) error
+	SaveAlertRuleState(ctx context.Context, id int64, state AlertRuleState) error
+}
+
+// AlertFilter selects alerts fired since Since, zero RuleID matches alerts of all rules.
+type AlertFilter struct {
+	RuleID int64
+	Since  time.Time
+	Limit  int
+}
+
+type AlertStorage interface {
+	SaveAlerts(ctx context.Context, alerts []Alert) error
+	Alerts(ctx context.Context, filter AlertFilter) ([]Alert, error)
+}
+
+// FiredAlertStorage saves alerts fired by evaluation of rules.
+type FiredAlertStorage interface {
+	// SaveFiredAlerts saves alerts and states of their rules in one transaction, alerts are added to notification
+	// outbox in the same transaction when notify is set, so alert is neither lost nor fired again by rule.
+	SaveFiredAlerts(ctx context.Context, alerts []Alert, states map[int64]AlertRuleState, notify bool) error
+}
+
+// NewSymbolLoader returns symbols first listed on exchanges since time.
+type NewSymbolLoader interface {
+	NewSymbols(ctx context.Context, from time.Time) ([]SymbolPrice, error)
+}
+
+// AlertNotifier delivers fired alerts.
+type AlertNotifier interface {
+	NotifyAlerts(ctx context.Context, alerts []Alert) error
+}
+
+// DeadLetter is alert which was not delivered to sink, Payload is json of alert.
+type DeadLetter struct {
+	ID        int64     `json:"id" db:"id"`
+	Sink      string    `json:"sink" db:"sink"`
+	Payload   string    `json:"payload" db:"payload"`
+	Error     string    `json:"error" db:"error"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type DeadLetterStorage interface {
+	SaveDeadLetter(ctx context.Context, letter DeadLetter) error
+	DeadLetters(ctx context.Context, from time.Time) ([]DeadLetter, error)
+}
+
+// OutboxNotification is alert waiting in outbox for delivery.
+type OutboxNotification struct {
+	ID    int64
+	Alert Alert
+}
+
+// NotificationOutbox keeps alerts until they are delivered, so alerts fired by one process are delivered
+// by notifier of another one and are not lost on restart.
+type NotificationOutbox interface {
+	AddNotifications(ctx context.Context, alerts []Alert) error
+	// ClaimNotification claims the oldest alert which is not claimed or whose claim is older than lease,
+	// nil is returned when there is no such alert.
+	ClaimNotification(ctx context.Context, lease time.Duration) (*OutboxNotification, error)
+	DeleteNotification(ctx context.Context, id int64) error
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	BackfillStatusPending = "pending"
+	BackfillStatusDone    = "done"
+)
+
+// BackfillJob loads candlesticks of gap from To to From, Cursor is open time of the earliest loaded candlestick,
+// so interrupted job continues from it.
+type BackfillJob struct {
+	ID        int64     `json:"id" db:"id"`
+	Exchange  string    `json:"exchange" db:"exchange"`
+	Symbol    string    `json:"symbol" db:"symbol"`
+	Interval  Interval  `json:"interval" db:"candle_interval"`
+	From      time.Time `json:"from" db:"from_time"`
+	To        time.Time `json:"to" db:"to_time"`
+	Cursor    time.Time `json:"cursor" db:"cursor_time"`
+	Status    string    `json:"status" db:"status"`
+	Loaded    int       `json:"loaded" db:"loaded"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type BackfillStorage interface {
+	// CreateBackfillJob returns existing job of the same gap instead of creating a new one.
+	CreateBackfillJob(ctx context.Context, gap CandlestickGap) (BackfillJob, error)
+	PendingBackfillJobs(ctx context.Context) ([]BackfillJob, error)
+	UpdateBackfillJob(ctx context.Context, job BackfillJob) error
+}
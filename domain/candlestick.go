@@ -8,13 +8,6 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-const (
-	FourHourInterval = "4h"
-	OneHourInterval  = "1h"
-)
-
-var ListIntervals = []string{FourHourInterval, OneHourInterval}
-
 type Candlestick struct {
 	Symbol       string
 	Exchange     string
@@ -26,7 +19,8 @@ type Candlestick struct {
 	ClosePrice   decimal.Decimal
 	Volume       decimal.Decimal
 	NumberTrades int
-	Interval     string
+	Interval     Interval
+	Derived      bool
 	CreatedAt    time.Time
 }
 
@@ -41,5 +35,35 @@ type CandlestickSaver interface {
 
 type CandlestickLoader interface {
 	Candlesticks(ctx context.Context, exchange, symbol string, from, to time.Time) ([]dto.Candlestick, error)
-	LastCandlestick(ctx context.Context, exchange, symbol, interval string) (*dto.Candlestick, error)
+	IntervalCandlesticks(
+		ctx context.Context, exchange, symbol string, interval Interval, from, to time.Time,
+	) ([]dto.Candlestick, error)
+	LastCandlestick(ctx context.Context, exchange, symbol string, interval Interval) (*dto.Candlestick, error)
+}
+
+// CandlestickGapLoader finds missed candlesticks of stored series.
+type CandlestickGapLoader interface {
+	// Gaps returns ranges between candlesticks opened in [from, to) which are not adjacent.
+	Gaps(ctx context.Context, exchange, symbol string, interval Interval, from, to time.Time) ([]CandlestickGap, error)
+	// Bounds returns open time of the first and close time of the last candlestick opened in [from, to),
+	// ok is false when there are no candlesticks.
+	Bounds(
+		ctx context.Context, exchange, symbol string, interval Interval, from, to time.Time,
+	) (first, last time.Time, ok bool, err error)
+}
+
+// CandlestickHistory loads candlesticks page by page in backward direction.
+type CandlestickHistory interface {
+	// CandlesticksBefore returns the newest page of candlesticks opened before end, ordered by open time.
+	CandlesticksBefore(ctx context.Context, exchange, symbol string, interval Interval, end time.Time) ([]dto.Candlestick, error)
+}
+
+// CandlestickGap is a range of missed candlesticks: From is close time of the previous candlestick,
+// To is open time of the next one.
+type CandlestickGap struct {
+	Exchange string    `json:"exchange"`
+	Symbol   string    `json:"symbol"`
+	Interval Interval  `json:"interval"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
 }
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ParsePrice parses price or volume of source. Prices are exact decimals, so changes of sub-cent tokens
+// are not lost in rounding of float.
+func ParsePrice(val string) (decimal.Decimal, error) {
+	price, err := decimal.NewFromString(val)
+	if err != nil {
+		return decimal.Zero, errors.Wrapf(err, "parse price %q", val)
+	}
+	return price, nil
+}
+
+// ParsePrices parses values in order, error has index of value which is not parsed.
+func ParsePrices(vals ...string) ([]decimal.Decimal, error) {
+	prices := make([]decimal.Decimal, 0, len(vals))
+	for i, val := range vals {
+		price, err := ParsePrice(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %d", i)
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+// PriceFromFloat converts price of source which returns prices as numbers, price is the shortest decimal
+// which is parsed to the same float.
+func PriceFromFloat(price float64) decimal.Decimal {
+	return decimal.NewFromFloat(price)
+}
+
+// PriceFloat converts price for statistics which do not need exact value: zones, percents, indicators.
+func PriceFloat(price decimal.Decimal) float64 {
+	return price.InexactFloat64()
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// PercentChange returns change of price relative to positive base in percent, difference is exact.
+func PercentChange(base, price decimal.Decimal) float64 {
+	return PriceFloat(price.Sub(base).Div(base).Mul(hundred))
+}
+
+func CandlestickFromDTO(item dto.Candlestick) Candlestick {
+	return Candlestick{
+		Symbol:       item.Symbol,
+		Exchange:     item.Exchange,
+		OpenTime:     item.OpenTime,
+		CloseTime:    item.CloseTime,
+		OpenPrice:    item.OpenPrice,
+		HighPrice:    item.HighPrice,
+		LowPrice:     item.LowPrice,
+		ClosePrice:   item.ClosePrice,
+		Volume:       item.Volume,
+		NumberTrades: item.NumberTrades,
+		Interval:     Interval(item.Interval),
+		Derived:      item.Derived,
+		CreatedAt:    item.CreatedAt,
+	}
+}
+
+func CandlestickToDTO(item Candlestick) dto.Candlestick {
+	return dto.Candlestick{
+		Symbol:       item.Symbol,
+		Exchange:     item.Exchange,
+		OpenTime:     item.OpenTime,
+		CloseTime:    item.CloseTime,
+		OpenPrice:    item.OpenPrice,
+		HighPrice:    item.HighPrice,
+		LowPrice:     item.LowPrice,
+		ClosePrice:   item.ClosePrice,
+		Volume:       item.Volume,
+		NumberTrades: item.NumberTrades,
+		Interval:     item.Interval.String(),
+		Derived:      item.Derived,
+		CreatedAt:    item.CreatedAt,
+	}
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParsePrice(t *testing.T) {
+	for _, val := range []string{"0.00001234", "0.000000012345678", "67012.34", "1e-8"} {
+		price, err := ParsePrice(val)
+		if err != nil {
+			t.Fatalf("%s: %v", val, err)
+		}
+		if !price.Equal(decimal.RequireFromString(val)) {
+			t.Errorf("%s: got %s", val, price)
+		}
+	}
+	// Sub-cent prices are not rounded as floats are.
+	price, err := ParsePrice("0.000012345678901234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.String() != "0.000012345678901234" {
+		t.Errorf("got %s", price)
+	}
+	if _, err := ParsePrice("price"); err == nil {
+		t.Error("expected error of not number")
+	}
+}
+
+func TestParsePrices(t *testing.T) {
+	prices, err := ParsePrices("0.00001234", "0.00002501")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 2 || prices[0].String() != "0.00001234" || prices[1].String() != "0.00002501" {
+		t.Errorf("got %v", prices)
+	}
+	if _, err := ParsePrices("0.00001234", ""); err == nil || !strings.Contains(err.Error(), "field 1") {
+		t.Errorf("got error %v, want error of field 1", err)
+	}
+}
+
+func TestPercentChange(t *testing.T) {
+	for _, item := range []struct {
+		base, price string
+		want        float64
+	}{
+		// PEPE
+		{base: "0.00001234", price: "0.00001240", want: 0.4862236628849271},
+		// SHIB
+		{base: "0.00002501", price: "0.00002500", want: -0.03998400639744102},
+		{base: "0.00000001", price: "0.00000002", want: 100},
+		{base: "67000", price: "67000", want: 0},
+	} {
+		got := PercentChange(decimal.RequireFromString(item.base), decimal.RequireFromString(item.price))
+		if math.Abs(got-item.want) > 1e-9 {
+			t.Errorf("%s -> %s: got %v, want %v", item.base, item.price, got, item.want)
+		}
+	}
+}
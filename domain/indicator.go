@@ -1,17 +1,85 @@
 package domain
 
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrIndicatorNotFound         = errors.New("indicator not found")
+	ErrIndicatorInstanceReadOnly = errors.New("indicator instance is defined in config")
+)
+
+// IndicatorSnapshot contains indicators of series calculated on candlestick opened at OpenTime.
 type IndicatorSnapshot struct {
-	MA8  float64
-	MA40 float64
+	Exchange string    `json:"exchange" db:"exchange"`
+	Symbol   string    `json:"symbol" db:"symbol"`
+	Interval Interval  `json:"interval" db:"candle_interval"`
+	OpenTime time.Time `json:"open_time" db:"open_time"`
+
+	MA8  float64 `json:"ma8" db:"ma8"`
+	MA40 float64 `json:"ma40" db:"ma40"`
+
+	EMA float64 `json:"ema" db:"ema"`
+	WMA float64 `json:"wma" db:"wma"`
+	SMA float64 `json:"sma" db:"sma"`
 
-	EMA float64
-	WMA float64
-	SMA float64
+	// RingLow and RingHigh are edges of the nearest support zone below and resistance zone above close price
+	// when levels are detected, otherwise the lowest and the highest prices of window.
+	RingLow  float64 `json:"ring_low" db:"ring_low"`
+	RingHigh float64 `json:"ring_high" db:"ring_high"`
 
-	RingLow  float64
-	RingHigh float64
+	// PinBar is share of candlestick range taken by long wick: positive for bullish pin bar (long lower wick),
+	// negative for bearish one, zero when candlestick is not a pin bar.
+	PinBar float64 `json:"pin_bar" db:"pin_bar"`
 
-	PinBar float64
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 type SymbolsIndicatorList map[string][]IndicatorSnapshot
+
+type IndicatorStorage interface {
+	SaveIndicators(ctx context.Context, snapshots []IndicatorSnapshot) error
+	LastIndicator(ctx context.Context, exchange, symbol string, interval Interval) (IndicatorSnapshot, error)
+}
+
+// IndicatorInstance is indicator with parameters calculated on candlesticks of one series.
+type IndicatorInstance struct {
+	ID        string             `json:"id" db:"id"`
+	Name      string             `json:"name" db:"name"`
+	Exchange  string             `json:"exchange" db:"exchange"`
+	Symbol    string             `json:"symbol" db:"symbol"`
+	Interval  Interval           `json:"interval" db:"candle_interval"`
+	Params    map[string]float64 `json:"params" db:"-"`
+	ReadOnly  bool               `json:"read_only" db:"-"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+}
+
+func (i IndicatorInstance) Normalize() IndicatorInstance {
+	i.Exchange = strings.ToLower(strings.TrimSpace(i.Exchange))
+	i.Symbol = strings.ToUpper(strings.TrimSpace(i.Symbol))
+	i.Name = strings.ToUpper(strings.TrimSpace(i.Name))
+	return i
+}
+
+// IndicatorValue contains outputs of indicator instance calculated on candlestick opened at OpenTime.
+type IndicatorValue struct {
+	InstanceID string             `json:"instance_id"`
+	OpenTime   time.Time          `json:"open_time"`
+	Values     map[string]float64 `json:"values"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type IndicatorInstanceStorage interface {
+	IndicatorInstances(ctx context.Context) ([]IndicatorInstance, error)
+	SaveIndicatorInstance(ctx context.Context, instance IndicatorInstance) error
+	DeleteIndicatorInstance(ctx context.Context, id string) error
+}
+
+type IndicatorValueStorage interface {
+	SaveIndicatorValues(ctx context.Context, values []IndicatorValue) error
+	LastIndicatorValue(ctx context.Context, instanceID string) (IndicatorValue, error)
+}
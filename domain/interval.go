@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Interval is a period of candlestick in exchange notation.
+type Interval string
+
+const (
+	OneMinuteInterval     Interval = "1m"
+	FiveMinuteInterval    Interval = "5m"
+	FifteenMinuteInterval Interval = "15m"
+	ThirtyMinuteInterval  Interval = "30m"
+	OneHourInterval       Interval = "1h"
+	FourHourInterval      Interval = "4h"
+	OneDayInterval        Interval = "1d"
+	OneWeekInterval       Interval = "1w"
+)
+
+// ListIntervals contains all supported intervals ordered by duration.
+var ListIntervals = []Interval{
+	OneMinuteInterval,
+	FiveMinuteInterval,
+	FifteenMinuteInterval,
+	ThirtyMinuteInterval,
+	OneHourInterval,
+	FourHourInterval,
+	OneDayInterval,
+	OneWeekInterval,
+}
+
+var intervalDurations = map[Interval]time.Duration{
+	OneMinuteInterval:     time.Minute,
+	FiveMinuteInterval:    5 * time.Minute,
+	FifteenMinuteInterval: 15 * time.Minute,
+	ThirtyMinuteInterval:  30 * time.Minute,
+	OneHourInterval:       time.Hour,
+	FourHourInterval:      4 * time.Hour,
+	OneDayInterval:        24 * time.Hour,
+	OneWeekInterval:       7 * 24 * time.Hour,
+}
+
+func ParseInterval(val string) (Interval, error) {
+	interval := Interval(strings.TrimSpace(val))
+	if !interval.Valid() {
+		return "", fmt.Errorf("unknown interval: %s", val)
+	}
+	return interval, nil
+}
+
+func ParseIntervals(values []string) ([]Interval, error) {
+	intervals := make([]Interval, 0, len(values))
+	for _, val := range values {
+		interval, err := ParseInterval(val)
+		if err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals, nil
+}
+
+func (i Interval) Valid() bool {
+	_, has := intervalDurations[i]
+	return has
+}
+
+func (i Interval) String() string {
+	return string(i)
+}
+
+func (i Interval) Duration() time.Duration {
+	return intervalDurations[i]
+}
+
+// Truncate returns open time of candlestick which contains t. Candlesticks are aligned to UTC midnight,
+// weeks start on Monday as on exchanges.
+func (i Interval) Truncate(t time.Time) time.Time {
+	t = t.In(time.UTC)
+	if i != OneWeekInterval {
+		return t.Truncate(i.Duration())
+	}
+	day := ToDatetimeWithoutHour(t)
+	daysFromMonday := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -daysFromMonday)
+}
+
+// TruncateInSession returns open time of candlestick which contains t when sessions start at offset from UTC midnight,
+// e.g. daily candlesticks of exchange which closes the day at 08:00 UTC have offset 8h.
+func (i Interval) TruncateInSession(t time.Time, offset time.Duration) time.Time {
+	return i.Truncate(t.Add(-offset)).Add(offset)
+}
+
+// CloseTime returns close time of candlestick opened at openTime, as exchanges it is the last millisecond of period.
+func (i Interval) CloseTime(openTime time.Time) time.Time {
+	return openTime.Add(i.Duration() - time.Millisecond)
+}
+
+// Next returns open time of candlestick following the one which contains t.
+func (i Interval) Next(t time.Time) time.Time {
+	return i.Truncate(t).Add(i.Duration())
+}
+
+// Count returns number of candlesticks opened in [from, to).
+func (i Interval) Count(from, to time.Time) int {
+	if !from.Before(to) {
+		return 0
+	}
+	first := i.Truncate(from)
+	if first.Before(from) {
+		first = first.Add(i.Duration())
+	}
+	if !first.Before(to) {
+		return 0
+	}
+	return int((to.Sub(first)-1)/i.Duration()) + 1
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type LevelKind string
+
+const (
+	SupportLevel    LevelKind = "support"
+	ResistanceLevel LevelKind = "resistance"
+)
+
+type LevelEventType string
+
+const (
+	LevelCrossUp   LevelEventType = "cross_up"
+	LevelCrossDown LevelEventType = "cross_down"
+)
+
+// Level is price zone [Low, High] of clustered swing highs and lows of candlestick series.
+// Kind is support when the last close is above the zone and resistance when it is below or inside the zone.
+type Level struct {
+	Exchange string    `json:"exchange" db:"exchange"`
+	Symbol   string    `json:"symbol" db:"symbol"`
+	Interval Interval  `json:"interval" db:"candle_interval"`
+	Kind     LevelKind `json:"kind" db:"kind"`
+	Low      float64   `json:"low" db:"low"`
+	High     float64   `json:"high" db:"high"`
+	// Touches is number of swings inside zone.
+	Touches    int       `json:"touches" db:"touches"`
+	FirstTouch time.Time `json:"first_touch" db:"first_touch"`
+	LastTouch  time.Time `json:"last_touch" db:"last_touch"`
+	// Breaks is number of candlesticks closed on the other side of zone than previous close outside of zone,
+	// Retests is number of candlesticks which returned to zone after break and closed on side of break.
+	Breaks     int        `json:"breaks" db:"breaks"`
+	LastBreak  *time.Time `json:"last_break" db:"last_break"`
+	Retests    int        `json:"retests" db:"retests"`
+	LastRetest *time.Time `json:"last_retest" db:"last_retest"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Crossing returns event of price moved from prevPrice into or through zone, ok is false when zone is not crossed.
+func (l Level) Crossing(prevPrice, price float64) (LevelEventType, bool) {
+	switch {
+	case prevPrice < l.Low && price >= l.Low:
+		return LevelCrossUp, true
+	case prevPrice > l.High && price <= l.High:
+		return LevelCrossDown, true
+	default:
+		return "", false
+	}
+}
+
+// LevelEvent is price change which crossed level zone.
+type LevelEvent struct {
+	Exchange  string          `json:"exchange" db:"exchange"`
+	Symbol    string          `json:"symbol" db:"symbol"`
+	Interval  Interval        `json:"interval" db:"candle_interval"`
+	Kind      LevelKind       `json:"kind" db:"kind"`
+	Low       float64         `json:"low" db:"low"`
+	High      float64         `json:"high" db:"high"`
+	Event     LevelEventType  `json:"event" db:"event"`
+	Price     decimal.Decimal `json:"price" db:"price"`
+	PrevPrice decimal.Decimal `json:"prev_price" db:"prev_price"`
+	Date      time.Time       `json:"date" db:"datetime"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+type LevelStorage interface {
+	// SaveLevels replaces levels of series.
+	SaveLevels(ctx context.Context, exchange, symbol string, interval Interval, levels []Level) error
+	// Levels returns levels of all intervals of symbol ordered by interval and price.
+	Levels(ctx context.Context, exchange, symbol string) ([]Level, error)
+}
+
+type LevelEventStorage interface {
+	// SaveLevelEvents returns only inserted events, events which are already saved are skipped.
+	SaveLevelEvents(ctx context.Context, events []LevelEvent) ([]LevelEvent, error)
+	LevelEvents(ctx context.Context, exchange, symbol string, from time.Time) ([]LevelEvent, error)
+}
+
+// PriceChangeHandler receives price changes saved by calculation.
+type PriceChangeHandler interface {
+	HandlePriceChanges(ctx context.Context, changes []PriceChange) error
+}
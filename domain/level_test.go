@@ -0,0 +1,25 @@
+package domain
+
+import "testing"
+
+func TestLevelCrossing(t *testing.T) {
+	level := Level{Low: 99, High: 101}
+	for _, item := range []struct {
+		prevPrice, price float64
+		event            LevelEventType
+		ok               bool
+	}{
+		{prevPrice: 98, price: 99, event: LevelCrossUp, ok: true},
+		{prevPrice: 98, price: 103, event: LevelCrossUp, ok: true},
+		{prevPrice: 98, price: 98.5},
+		{prevPrice: 102, price: 101, event: LevelCrossDown, ok: true},
+		{prevPrice: 102, price: 97, event: LevelCrossDown, ok: true},
+		{prevPrice: 100, price: 102},
+		{prevPrice: 100, price: 98},
+	} {
+		event, ok := level.Crossing(item.prevPrice, item.price)
+		if event != item.event || ok != item.ok {
+			t.Errorf("%v -> %v: got %q %t, want %q %t", item.prevPrice, item.price, event, ok, item.event, item.ok)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ListingWindow is duration after first seen time of listing during which its price action is tracked.
+const ListingWindow = 24 * time.Hour
+
+// Listing is symbol seen on exchange for the first time. High, Low and LastPrice are price action
+// of the first ListingWindow after FirstSeen, percents are relative to FirstPrice.
+type Listing struct {
+	Exchange   string          `json:"exchange" db:"exchange"`
+	Symbol     string          `json:"symbol" db:"symbol"`
+	FirstSeen  time.Time       `json:"first_seen" db:"first_seen"`
+	FirstPrice decimal.Decimal `json:"first_price" db:"first_price"`
+	High       decimal.Decimal `json:"high" db:"high"`
+	Low        decimal.Decimal `json:"low" db:"low"`
+	LastPrice  decimal.Decimal `json:"last_price" db:"last_price"`
+	LastSeen   time.Time       `json:"last_seen" db:"last_seen"`
+	// Complete is true when ListingWindow passed and price action is not changed anymore.
+	Complete bool `json:"complete" db:"complete"`
+
+	// CrossListed is true when symbol was traded on another exchange before listing. FirstExchange is
+	// exchange of the earliest listing, FirstListedAt is nil when symbol was known before tracking of listings.
+	CrossListed   bool       `json:"cross_listed" db:"cross_listed"`
+	FirstExchange string     `json:"first_exchange" db:"first_exchange"`
+	FirstListedAt *time.Time `json:"first_listed_at" db:"first_listed_at"`
+
+	HighPercent   float64   `json:"high_percent" db:"high_percent"`
+	LowPercent    float64   `json:"low_percent" db:"low_percent"`
+	ChangePercent float64   `json:"change_percent" db:"change_percent"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func NewListing(price SymbolPrice) Listing {
+	return Listing{
+		Exchange:   price.Exchange,
+		Symbol:     price.Symbol,
+		FirstSeen:  price.Date,
+		FirstPrice: price.Price,
+		High:       price.Price,
+		Low:        price.Price,
+		LastPrice:  price.Price,
+		LastSeen:   price.Date,
+	}
+}
+
+// Observe adds price of date to price action and reports whether listing is changed.
+// Prices after ListingWindow complete listing and are ignored.
+func (l *Listing) Observe(price decimal.Decimal, date time.Time) bool {
+	if l.Complete || date.Before(l.LastSeen) {
+		return false
+	}
+	if date.Sub(l.FirstSeen) >= ListingWindow {
+		l.Complete = true
+		return true
+	}
+	l.High = decimal.Max(l.High, price)
+	l.Low = decimal.Min(l.Low, price)
+	l.LastPrice = price
+	l.LastSeen = date
+	return true
+}
+
+// ListingFilter selects listings first seen since Since, CrossListed filters listings when it is set.
+type ListingFilter struct {
+	Exchange    string
+	Symbol      string
+	CrossListed *bool
+	Since       time.Time
+	Limit       int
+	Offset      int
+}
+
+type ListingStorage interface {
+	// SaveListings creates listings and updates price action of existing ones.
+	SaveListings(ctx context.Context, listings []Listing) error
+	// Listings returns listings of filter, the latest first, and number of all listings matched by filter.
+	Listings(ctx context.Context, filter ListingFilter) ([]Listing, int, error)
+	// SymbolListings returns listings of symbols on all exchanges, the latest first.
+	SymbolListings(ctx context.Context, symbols []string) ([]Listing, error)
+	// TrackedListings returns listings which are not complete.
+	TrackedListings(ctx context.Context) ([]Listing, error)
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type Pattern string
+
+const (
+	PinBarPattern             Pattern = "pin_bar"
+	EngulfingPattern          Pattern = "engulfing"
+	DojiPattern               Pattern = "doji"
+	HammerPattern             Pattern = "hammer"
+	ShootingStarPattern       Pattern = "shooting_star"
+	InsideBarPattern          Pattern = "inside_bar"
+	OutsideBarPattern         Pattern = "outside_bar"
+	ThreeWhiteSoldiersPattern Pattern = "three_white_soldiers"
+	ThreeBlackCrowsPattern    Pattern = "three_black_crows"
+)
+
+var ListPatterns = []Pattern{
+	PinBarPattern,
+	EngulfingPattern,
+	DojiPattern,
+	HammerPattern,
+	ShootingStarPattern,
+	InsideBarPattern,
+	OutsideBarPattern,
+	ThreeWhiteSoldiersPattern,
+	ThreeBlackCrowsPattern,
+}
+
+type PatternDirection string
+
+const (
+	BullishDirection PatternDirection = "bullish"
+	BearishDirection PatternDirection = "bearish"
+	NeutralDirection PatternDirection = "neutral"
+)
+
+// CandlestickPattern is pattern detected on candlestick opened at OpenTime, previous candlesticks can be part of it.
+type CandlestickPattern struct {
+	Exchange  string           `json:"exchange" db:"exchange"`
+	Symbol    string           `json:"symbol" db:"symbol"`
+	Interval  Interval         `json:"interval" db:"candle_interval"`
+	OpenTime  time.Time        `json:"open_time" db:"open_time"`
+	Pattern   Pattern          `json:"pattern" db:"pattern"`
+	Direction PatternDirection `json:"direction" db:"direction"`
+	// Strength is in [0, 1], the higher the more pronounced pattern is.
+	Strength  float64   `json:"strength" db:"strength"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// PatternFilter selects patterns of candlesticks opened since Since, empty fields match all patterns.
+type PatternFilter struct {
+	Exchange    string
+	Symbol      string
+	Interval    Interval
+	Pattern     Pattern
+	Direction   PatternDirection
+	MinStrength float64
+	Since       time.Time
+	Limit       int
+}
+
+type PatternStorage interface {
+	// SavePatterns updates strength of patterns which are already saved and returns only inserted ones.
+	SavePatterns(ctx context.Context, patterns []CandlestickPattern) ([]CandlestickPattern, error)
+	Patterns(ctx context.Context, filter PatternFilter) ([]CandlestickPattern, error)
+}
@@ -2,34 +2,34 @@ package domain
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/shopspring/decimal"
 )
 
 type SymbolPrice struct {
-	Exchange string    `json:"exchange" db:"exchange"`
-	Symbol   string    `json:"symbol" db:"symbol"`
-	Price    float64   `json:"price" db:"price"`
-	Date     time.Time `json:"date" db:"datetime"`
+	Exchange string          `json:"exchange" db:"exchange"`
+	Symbol   string          `json:"symbol" db:"symbol"`
+	Price    decimal.Decimal `json:"price" db:"price"`
+	Date     time.Time       `json:"date" db:"datetime"`
 }
 
 type PriceChange struct {
-	Date                time.Time `json:"date" db:"datetime"`
-	Symbol              string    `json:"symbol" db:"symbol"`
-	Exchange            string    `json:"exchange" db:"exchange"`
-	CoefficientOfChange int64     `json:"coefficient_change" db:"coefficient_change"`
-	Price               float64   `json:"price" db:"price"`
-	PrevPrice           float64   `json:"prev_price" db:"prev_price"`
-	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	Date                time.Time       `json:"date" db:"datetime"`
+	Symbol              string          `json:"symbol" db:"symbol"`
+	Exchange            string          `json:"exchange" db:"exchange"`
+	CoefficientOfChange int64           `json:"coefficient_change" db:"coefficient_change"`
+	Price               decimal.Decimal `json:"price" db:"price"`
+	PrevPrice           decimal.Decimal `json:"prev_price" db:"prev_price"`
+	CreatedAt           time.Time       `json:"created_at" db:"created_at"`
 }
 
 func (p PriceChange) PriceString() string {
-	return fmt.Sprintf("%.6f", p.Price)
+	return p.Price.String()
 }
 func (p PriceChange) PrevPriceString() string {
-	return fmt.Sprintf("%.6f", p.PrevPrice)
+	return p.PrevPrice.String()
 }
 
 func ToDatetimeWithoutSec(val time.Time) time.Time {
@@ -111,6 +111,30 @@ type PriceLoader interface {
 	Prices(ctx context.Context, symbol string) ([]SymbolPrice, error)
 }
 
+// SymbolPriceLoader loads prices of symbol on all exchanges for calculation of price changes.
+type SymbolPriceLoader interface {
+	FirstDatetime(ctx context.Context, symbol string) (time.Time, error)
+	SymbolPrices(ctx context.Context, symbol string, from, to time.Time) ([]SymbolPrice, error)
+}
+
+// NewSymbolSaver saves the first prices of symbols new on exchange.
+type NewSymbolSaver interface {
+	AddNewSymbol(ctx context.Context, prices []SymbolPrice) error
+}
+
+type PriceChangeStorage interface {
+	// Save returns only inserted price changes, changes which are already saved are skipped.
+	Save(ctx context.Context, data []PriceChange) ([]PriceChange, error)
+	LastDatetimeSymbolRow(ctx context.Context, symbol string) (time.Time, error)
+}
+
+// MinutePriceLoader loads prices of all exchanges of symbol, so minute of newly saved price change is compared
+// with prices of exchanges saved by previous calculations.
+type MinutePriceLoader interface {
+	// MinutePrices returns price changes of symbol on all exchanges which happened in the minutes.
+	MinutePrices(ctx context.Context, symbol string, dates []time.Time) ([]PriceChange, error)
+}
+
 type PriceChangeLoader interface {
 	Changes(ctx context.Context, exchange, symbol string, from, to time.Time) ([]PriceChange, error)
 }
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PriceHistoryTiers are resolutions of price history from the finest one. Raw prices are rolled up into the first
+// tier and every tier is rolled up into the next one, so rows of tier can be deleted after they are rolled up.
+var PriceHistoryTiers = []Interval{OneMinuteInterval, FiveMinuteInterval, OneHourInterval}
+
+func IsPriceHistoryTier(interval Interval) bool {
+	for _, tier := range PriceHistoryTiers {
+		if tier == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceBar is OHLC of prices of symbol on exchange in bucket of resolution, Samples is number of raw prices.
+type PriceBar struct {
+	Exchange   string          `json:"exchange" db:"exchange"`
+	Symbol     string          `json:"symbol" db:"symbol"`
+	Resolution Interval        `json:"resolution" db:"-"`
+	OpenTime   time.Time       `json:"open_time" db:"bucket"`
+	Open       decimal.Decimal `json:"open" db:"open"`
+	High       decimal.Decimal `json:"high" db:"high"`
+	Low        decimal.Decimal `json:"low" db:"low"`
+	Close      decimal.Decimal `json:"close" db:"close"`
+	Samples    int64           `json:"samples" db:"samples"`
+}
+
+type PriceHistoryStorage interface {
+	// RollupPrices aggregates source of tier (raw prices for the first tier) since from into buckets of tier,
+	// existing buckets are recalculated.
+	RollupPrices(ctx context.Context, tier Interval, from time.Time) error
+	// LastBucket returns open time of the latest bucket of tier of all symbols, zero time when tier is empty.
+	LastBucket(ctx context.Context, tier Interval) (time.Time, error)
+	// FirstBucket returns open time of the earliest bucket of symbol in tier, zero time when there is no bucket.
+	FirstBucket(ctx context.Context, exchange, symbol string, tier Interval) (time.Time, error)
+	PriceBars(ctx context.Context, exchange, symbol string, tier Interval, from, to time.Time) ([]PriceBar, error)
+}
+
+// RetentionGuard keeps rows of table which are still needed, e.g. they are not rolled up yet.
+type RetentionGuard interface {
+	// RetainedSince returns time since which rows must be kept, zero time keeps all rows.
+	RetainedSince(ctx context.Context) (time.Time, error)
+}
+
+// PriceHistoryReader reads bars of range from tier of price history, empty resolution picks tier by range.
+type PriceHistoryReader interface {
+	Bars(ctx context.Context, exchange, symbol string, resolution Interval, from, to time.Time) (Interval, []PriceBar, error)
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// CandlestickDivergence is a field of derived candlestick which differs from the same candlestick of exchange.
+type CandlestickDivergence struct {
+	Exchange string    `json:"exchange"`
+	Symbol   string    `json:"symbol"`
+	Interval Interval  `json:"interval"`
+	OpenTime time.Time `json:"open_time"`
+	Field    string    `json:"field"`
+	Expected float64   `json:"expected"`
+	Derived  float64   `json:"derived"`
+	// Diff is relative difference of derived value from expected one.
+	Diff float64 `json:"diff"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RetentionScope selects rows of Table: rows of Symbol when it is set, otherwise rows of all symbols
+// except ExceptSymbols.
+type RetentionScope struct {
+	Table         string
+	Symbol        string
+	ExceptSymbols []string
+}
+
+type RetentionStorage interface {
+	// RetentionTable reports whether old rows of table can be deleted and whether its rows have symbol.
+	RetentionTable(table string) (known, bySymbol bool)
+	// CountRows returns number of rows of scope older than before.
+	CountRows(ctx context.Context, scope RetentionScope, before time.Time) (int64, error)
+	// DeleteRows deletes at most limit rows of scope older than before and returns number of deleted rows.
+	DeleteRows(ctx context.Context, scope RetentionScope, before time.Time, limit int) (int64, error)
+	// CapTime returns time of the maxRows-th newest row of scope, zero time when scope has less rows.
+	CapTime(ctx context.Context, scope RetentionScope, maxRows int64) (time.Time, error)
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+)
+
+// SourcePrice is a raw price as it is returned by a source, the price is not parsed yet.
+type SourcePrice struct {
+	Exchange string    `json:"exchange"`
+	Symbol   string    `json:"symbol"`
+	Price    string    `json:"price"`
+	Date     time.Time `json:"date"`
+}
+
+type SymbolSnapshot struct {
+	Symbol       string            `json:"symbol"`
+	Exchange     string            `json:"exchange"`
+	Price        string            `json:"price"`
+	CreatedAt    time.Time         `json:"created_at"`
+	Candlesticks []dto.Candlestick `json:"candlesticks"`
+}
+
+// MarketDataSource provides prices and candlesticks of exchanges.
+type MarketDataSource interface {
+	AllSymbolPrices(ctx context.Context) ([]SourcePrice, error)
+	ExchangePrices(ctx context.Context, exchange string) ([]SourcePrice, error)
+	// SymbolSnapshot returns price and the last closed candlestick of every interval, all intervals when empty.
+	SymbolSnapshot(ctx context.Context, exchange, symbol string, intervals ...Interval) (SymbolSnapshot, error)
+	Candlesticks(ctx context.Context, exchange, symbol string, interval Interval) ([]dto.Candlestick, error)
+}
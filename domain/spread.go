@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Spread is the most profitable cross-exchange trade of symbol in minute: buy on BuyExchange at BuyPrice and
+// sell on SellExchange at SellPrice. Prices are the last traded prices of minute, NetPercent is GrossPercent minus
+// taker fees of both exchanges. Bid and ask prices are not loaded from exchanges, so spread is estimation
+// which does not include spread of order book.
+type Spread struct {
+	Symbol       string          `json:"symbol" db:"symbol"`
+	BuyExchange  string          `json:"buy_exchange" db:"buy_exchange"`
+	SellExchange string          `json:"sell_exchange" db:"sell_exchange"`
+	BuyPrice     decimal.Decimal `json:"buy_price" db:"buy_price"`
+	SellPrice    decimal.Decimal `json:"sell_price" db:"sell_price"`
+	GrossPercent float64         `json:"gross_percent" db:"gross_percent"`
+	NetPercent   float64         `json:"net_percent" db:"net_percent"`
+	Date         time.Time       `json:"date" db:"datetime"`
+	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+}
+
+// SpreadFees are taker fees of exchanges in percent, fee of exchange which is not listed is Default.
+type SpreadFees struct {
+	Default   float64
+	Exchanges map[string]float64
+}
+
+func (f SpreadFees) Fee(exchange string) float64 {
+	if fee, has := f.Exchanges[exchange]; has {
+		return fee
+	}
+	return f.Default
+}
+
+// BestSpread returns spread of the most profitable pair of exchange prices, ok is false for less than two prices.
+func (f SpreadFees) BestSpread(symbol string, date time.Time, prices map[string]decimal.Decimal) (Spread, bool) {
+	var (
+		best  Spread
+		found bool
+	)
+	for buyExchange, buyPrice := range prices {
+		for sellExchange, sellPrice := range prices {
+			if buyExchange == sellExchange || !buyPrice.IsPositive() || sellPrice.LessThan(buyPrice) {
+				continue
+			}
+			gross := PercentChange(buyPrice, sellPrice)
+			item := Spread{
+				Symbol:       symbol,
+				BuyExchange:  buyExchange,
+				SellExchange: sellExchange,
+				BuyPrice:     buyPrice,
+				SellPrice:    sellPrice,
+				GrossPercent: gross,
+				NetPercent:   gross - f.Fee(buyExchange) - f.Fee(sellExchange),
+				Date:         date,
+			}
+			// Equal prices give pairs in both directions, order of exchanges keeps result stable.
+			if !found || item.NetPercent > best.NetPercent ||
+				(item.NetPercent == best.NetPercent && item.BuyExchange < best.BuyExchange) {
+				best, found = item, true
+			}
+		}
+	}
+	return best, found
+}
+
+// SpreadFilter selects spreads since Since, empty Symbol matches all symbols.
+type SpreadFilter struct {
+	Symbol        string
+	MinNetPercent *float64
+	Since         time.Time
+	Limit         int
+}
+
+type SpreadStorage interface {
+	// SaveSpreads returns only spreads which are inserted or changed.
+	SaveSpreads(ctx context.Context, spreads []Spread) ([]Spread, error)
+	// Spreads returns history of spreads, the latest first.
+	Spreads(ctx context.Context, filter SpreadFilter) ([]Spread, error)
+	// TopSpreads returns the latest spread of every symbol since time of filter, the most profitable first.
+	TopSpreads(ctx context.Context, filter SpreadFilter) ([]Spread, error)
+}
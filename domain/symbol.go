@@ -1,6 +1,10 @@
 package domain
 
-import "context"
+import (
+	"context"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+)
 
 const (
 	USDT = "USDT"
@@ -16,22 +20,20 @@ const (
 	SOLUSDT = "SOLUSDT"
 )
 
-var SubscribeSymbols = []string{BTCUSDT, ETHUSDT, LTCUSDT, SOLUSDT}
-
 var MainSymbolPairs = map[string]bool{
 	BTCUSDT: true,
 	ETHUSDT: true,
 }
 
-var PopularSymbols = map[string]int{
-	BTCUSDT: 100,
-	ETHUSDT: 99,
-	BTCUSDC: 98,
-	LTCUSDT: 97,
-	ETHUSDC: 96,
-	SOLUSDT: 95,
-}
-
 type SymbolStorage interface {
 	List(ctx context.Context) ([]string, error)
 }
+
+// PopularSymbolLoader returns symbols traded on at least limit exchanges.
+type PopularSymbolLoader interface {
+	PopularSymbols(ctx context.Context, limit int) ([]string, error)
+}
+
+type ExchangeSymbolLoader interface {
+	ExchangeSymbols(ctx context.Context) ([]dto.ExchangeSymbol, error)
+}
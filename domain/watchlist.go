@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrWatchlistItemNotFound = errors.New("watchlist item not found")
+
+// WatchlistItem is a symbol of exchange for which candlesticks and snapshots are loaded.
+// Rank orders symbols on pages, the higher rank the more popular symbol.
+type WatchlistItem struct {
+	Exchange  string     `json:"exchange" db:"exchange"`
+	Symbol    string     `json:"symbol" db:"symbol"`
+	Intervals []Interval `json:"intervals" db:"-"`
+	Rank      int        `json:"rank" db:"rank"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Normalize converts symbol to upper case and removes duplicated intervals.
+func (w WatchlistItem) Normalize() WatchlistItem {
+	w.Exchange = strings.ToLower(strings.TrimSpace(w.Exchange))
+	w.Symbol = strings.ToUpper(strings.TrimSpace(w.Symbol))
+	intervals := make([]Interval, 0, len(w.Intervals))
+	for _, interval := range w.Intervals {
+		interval = Interval(strings.TrimSpace(string(interval)))
+		if interval != "" && !slices.Contains(intervals, interval) {
+			intervals = append(intervals, interval)
+		}
+	}
+	w.Intervals = intervals
+	return w
+}
+
+func (w WatchlistItem) Validate() error {
+	if !slices.Contains(ListExchanges, w.Exchange) {
+		return fmt.Errorf("unknown exchange: %s", w.Exchange)
+	}
+	if w.Symbol == "" {
+		return errors.New("empty symbol")
+	}
+	for _, interval := range w.Intervals {
+		if !interval.Valid() {
+			return fmt.Errorf("unknown interval: %s", interval)
+		}
+	}
+	return nil
+}
+
+type WatchlistStorage interface {
+	WatchlistLoader
+	SaveWatchlistItem(ctx context.Context, item WatchlistItem) error
+	DeleteWatchlistItem(ctx context.Context, exchange, symbol string) error
+}
+
+type WatchlistLoader interface {
+	Watchlist(ctx context.Context) ([]WatchlistItem, error)
+	WatchlistItem(ctx context.Context, exchange, symbol string) (WatchlistItem, error)
+}
+
+// PopularSymbols returns rank of symbols, the best rank among exchanges is used.
+func PopularSymbols(items []WatchlistItem) map[string]int {
+	ranks := make(map[string]int, len(items))
+	for _, item := range items {
+		if rank, has := ranks[item.Symbol]; !has || item.Rank > rank {
+			ranks[item.Symbol] = item.Rank
+		}
+	}
+	return ranks
+}
@@ -1,18 +1,24 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Candlestick struct {
-	Symbol       string    `json:"symbol" db:"symbol"`
-	Exchange     string    `json:"exchange" db:"exchange"`
-	OpenTime     time.Time `json:"open_time" db:"open_time"`
-	CloseTime    time.Time `json:"close_time" db:"close_time"`
-	OpenPrice    float64   `json:"open_price" db:"open_price"`
-	HighPrice    float64   `json:"high_price" db:"high_price"`
-	LowPrice     float64   `json:"low_price" db:"low_price"`
-	ClosePrice   float64   `json:"close_price" db:"close_price"`
-	Volume       float64   `json:"volume" db:"volume"`
-	NumberTrades int       `json:"number_trades" db:"number_trades"`
-	Interval     string    `json:"candle_interval" db:"candle_interval"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	Symbol       string          `json:"symbol" db:"symbol"`
+	Exchange     string          `json:"exchange" db:"exchange"`
+	OpenTime     time.Time       `json:"open_time" db:"open_time"`
+	CloseTime    time.Time       `json:"close_time" db:"close_time"`
+	OpenPrice    decimal.Decimal `json:"open_price" db:"open_price"`
+	HighPrice    decimal.Decimal `json:"high_price" db:"high_price"`
+	LowPrice     decimal.Decimal `json:"low_price" db:"low_price"`
+	ClosePrice   decimal.Decimal `json:"close_price" db:"close_price"`
+	Volume       decimal.Decimal `json:"volume" db:"volume"`
+	NumberTrades int             `json:"number_trades" db:"number_trades"`
+	Interval     string          `json:"candle_interval" db:"candle_interval"`
+	// Derived candlestick is aggregated from candlesticks of lower interval instead of loaded from exchange.
+	Derived   bool      `json:"derived" db:"derived"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
@@ -0,0 +1,277 @@
+package calculation
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var _ domain.PriceChangeHandler = (*AlertEvaluator)(nil)
+
+// AlertEvaluator evaluates alert rules on saved price changes and checks listings of new symbols with Run.
+type AlertEvaluator struct {
+	rules  domain.AlertRuleStorage
+	alerts domain.FiredAlertStorage
+
+	values     domain.IndicatorValueStorage
+	newSymbols domain.NewSymbolLoader
+	notify     bool
+}
+
+func NewAlertEvaluator(rules domain.AlertRuleStorage, alerts domain.FiredAlertStorage) *AlertEvaluator {
+	return &AlertEvaluator{rules: rules, alerts: alerts}
+}
+
+// WithIndicatorValues enables rules of indicator kind.
+func (ae *AlertEvaluator) WithIndicatorValues(values domain.IndicatorValueStorage) {
+	ae.values = values
+}
+
+// WithNewSymbols enables rules of new symbol kind, they are evaluated by Run.
+func (ae *AlertEvaluator) WithNewSymbols(newSymbols domain.NewSymbolLoader) {
+	ae.newSymbols = newSymbols
+}
+
+// WithNotifications enables delivery of saved alerts, they are added to notification outbox with alerts.
+func (ae *AlertEvaluator) WithNotifications(notify bool) {
+	ae.notify = notify
+}
+
+// HandlePriceChanges evaluates coefficient and price rules on every change in order of date
+// and indicator rules on the last indicator values of changed symbols.
+// Values at or before the last alert of rule are skipped, so the same change or indicator value fires rule once.
+func (ae *AlertEvaluator) HandlePriceChanges(ctx context.Context, changes []domain.PriceChange) error {
+	rules, err := ae.enabledRules(ctx, domain.CoefficientAlert, domain.PriceAlert, domain.IndicatorAlert)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	changes = slices.Clone(changes)
+	slices.SortStableFunc(changes, func(a, b domain.PriceChange) int { return a.Date.Compare(b.Date) })
+	var (
+		alerts  []domain.Alert
+		changed = make(map[int]bool)
+	)
+	for _, change := range changes {
+		for i := range rules {
+			rule := &rules[i]
+			if !rule.Matches(change.Exchange, change.Symbol) || rule.Evaluated(change.Date) {
+				continue
+			}
+			var (
+				prev  *float64
+				value float64
+			)
+			switch rule.Kind {
+			case domain.CoefficientAlert:
+				value = float64(change.CoefficientOfChange)
+			case domain.PriceAlert:
+				prevPrice := domain.PriceFloat(change.PrevPrice)
+				prev, value = &prevPrice, domain.PriceFloat(change.Price)
+			default:
+				continue
+			}
+			if alert, ok := ae.evaluate(rule, prev, value, change.Date); ok {
+				alerts = append(alerts, alert)
+			}
+			changed[i] = true
+		}
+	}
+	if ae.values != nil {
+		for i := range rules {
+			rule := &rules[i]
+			if rule.Kind != domain.IndicatorAlert || !slices.ContainsFunc(changes, func(item domain.PriceChange) bool {
+				return rule.Matches(item.Exchange, item.Symbol)
+			}) {
+				continue
+			}
+			value, date, ok, err := ae.indicatorValue(ctx, *rule)
+			if err != nil {
+				zap.L().Error("error load indicator value of alert rule", zap.Int64("rule", rule.ID), zap.Error(err))
+				continue
+			}
+			if !ok || rule.Evaluated(date) {
+				continue
+			}
+			if alert, ok := ae.evaluate(rule, nil, value, date); ok {
+				alerts = append(alerts, alert)
+			}
+			changed[i] = true
+		}
+	}
+	states := make(map[int64]domain.AlertRuleState, len(changed))
+	for i := range changed {
+		states[rules[i].ID] = rules[i].AlertRuleState
+	}
+	return ae.save(ctx, alerts, states)
+}
+
+func (ae *AlertEvaluator) Run(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := ae.checkNewSymbols(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				zap.L().Error("error check new symbol alerts", zap.Error(err))
+			}
+		}
+	}
+}
+
+// checkNewSymbols fires new symbol rules on symbols listed after the last alert of rule or after its creation.
+// Cooldown and hysteresis are not applied, every listing is a separate alert.
+func (ae *AlertEvaluator) checkNewSymbols(ctx context.Context) error {
+	if ae.newSymbols == nil {
+		return nil
+	}
+	rules, err := ae.enabledRules(ctx, domain.NewSymbolAlert)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	since := func(rule domain.AlertRule) time.Time {
+		if rule.LastTriggered != nil {
+			return *rule.LastTriggered
+		}
+		return rule.CreatedAt
+	}
+	from := since(rules[0])
+	for _, rule := range rules {
+		if since(rule).Before(from) {
+			from = since(rule)
+		}
+	}
+	symbols, err := ae.newSymbols.NewSymbols(ctx, from)
+	if err != nil {
+		return errors.Wrap(err, "load new symbols")
+	}
+	var alerts []domain.Alert
+	states := make(map[int64]domain.AlertRuleState)
+	for _, rule := range rules {
+		state, fired := rule.AlertRuleState, false
+		for _, item := range symbols {
+			if !item.Date.After(since(rule)) || !rule.Matches(item.Exchange, item.Symbol) {
+				continue
+			}
+			alerts = append(alerts, domain.Alert{
+				RuleID:    rule.ID,
+				RuleName:  rule.Name,
+				Kind:      rule.Kind,
+				Exchange:  item.Exchange,
+				Symbol:    item.Symbol,
+				Value:     domain.PriceFloat(item.Price),
+				Message:   fmt.Sprintf("%s: new symbol %s/%s listed at price %v", ruleTitle(rule), item.Symbol, item.Exchange, item.Price),
+				Date:      item.Date,
+				CreatedAt: time.Now(),
+			})
+			fired = true
+			if state.LastTriggered == nil || item.Date.After(*state.LastTriggered) {
+				date := item.Date
+				state.LastTriggered = &date
+			}
+		}
+		if fired {
+			states[rule.ID] = state
+		}
+	}
+	return ae.save(ctx, alerts, states)
+}
+
+func (ae *AlertEvaluator) enabledRules(ctx context.Context, kinds ...domain.AlertKind) ([]domain.AlertRule, error) {
+	rules, err := ae.rules.AlertRules(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load alert rules")
+	}
+	return slices.DeleteFunc(rules, func(rule domain.AlertRule) bool {
+		return !rule.Enabled || !slices.Contains(kinds, rule.Kind)
+	}), nil
+}
+
+// evaluate updates state of rule by value and returns alert when rule fires.
+func (ae *AlertEvaluator) evaluate(rule *domain.AlertRule, prev *float64, value float64, date time.Time) (domain.Alert, bool) {
+	fire, state := rule.Evaluate(prev, value, date)
+	rule.AlertRuleState = state
+	if !fire {
+		return domain.Alert{}, false
+	}
+	return domain.Alert{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Kind:      rule.Kind,
+		Exchange:  rule.Exchange,
+		Symbol:    rule.Symbol,
+		Value:     value,
+		Message:   alertMessage(*rule, value),
+		Date:      date,
+		CreatedAt: time.Now(),
+	}, true
+}
+
+// indicatorValue returns output of the last indicator value of rule and open time of its candlestick.
+func (ae *AlertEvaluator) indicatorValue(ctx context.Context, rule domain.AlertRule) (float64, time.Time, bool, error) {
+	value, err := ae.values.LastIndicatorValue(ctx, rule.IndicatorID)
+	if errors.Is(err, domain.ErrIndicatorNotFound) {
+		return 0, time.Time{}, false, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, false, err
+	}
+	output, has := value.Values[rule.Output]
+	if !has {
+		return 0, time.Time{}, false, fmt.Errorf("indicator %s has no output %s", rule.IndicatorID, rule.Output)
+	}
+	return output, value.OpenTime, true, nil
+}
+
+func (ae *AlertEvaluator) save(ctx context.Context, alerts []domain.Alert, states map[int64]domain.AlertRuleState) error {
+	if len(alerts) == 0 && len(states) == 0 {
+		return nil
+	}
+	errSave := backoff.Retry(func() error {
+		return ae.alerts.SaveFiredAlerts(ctx, alerts, states, ae.notify)
+	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+	if errSave != nil {
+		return errors.Wrap(errSave, "save alerts")
+	}
+	for _, item := range alerts {
+		metric.Alerts.WithLabelValues(string(item.Kind)).Inc()
+	}
+	return nil
+}
+
+func alertMessage(rule domain.AlertRule, value float64) string {
+	subject := string(rule.Kind)
+	switch rule.Kind {
+	case domain.CoefficientAlert:
+		subject = "coefficient of change"
+	case domain.IndicatorAlert:
+		subject = rule.IndicatorID + " " + rule.Output
+	}
+	return fmt.Sprintf(
+		"%s: %s/%s %s %s %s, value %s",
+		ruleTitle(rule), rule.Symbol, rule.Exchange, subject, rule.Operator,
+		strconv.FormatFloat(rule.Threshold, 'f', -1, 64), strconv.FormatFloat(value, 'f', -1, 64),
+	)
+}
+
+func ruleTitle(rule domain.AlertRule) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return "alert rule " + strconv.FormatInt(rule.ID, 10)
+}
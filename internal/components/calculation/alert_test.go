@@ -0,0 +1,162 @@
+package calculation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/shopspring/decimal"
+)
+
+// alertMemory keeps alert rules, fired alerts and notifications of outbox in memory.
+type alertMemory struct {
+	domain.AlertRuleStorage
+
+	rules         []domain.AlertRule
+	alerts        []domain.Alert
+	notifications []domain.Alert
+}
+
+func (m *alertMemory) AlertRules(context.Context) ([]domain.AlertRule, error) {
+	return append([]domain.AlertRule(nil), m.rules...), nil
+}
+
+func (m *alertMemory) SaveAlertRuleState(_ context.Context, id int64, state domain.AlertRuleState) error {
+	for i := range m.rules {
+		if m.rules[i].ID == id {
+			m.rules[i].AlertRuleState = state
+		}
+	}
+	return nil
+}
+
+func (m *alertMemory) SaveFiredAlerts(
+	ctx context.Context, alerts []domain.Alert, states map[int64]domain.AlertRuleState, notify bool,
+) error {
+	m.alerts = append(m.alerts, alerts...)
+	if notify {
+		m.notifications = append(m.notifications, alerts...)
+	}
+	for id, state := range states {
+		if err := m.SaveAlertRuleState(ctx, id, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type lastIndicatorValue domain.IndicatorValue
+
+func (v lastIndicatorValue) SaveIndicatorValues(context.Context, []domain.IndicatorValue) error {
+	return nil
+}
+
+func (v lastIndicatorValue) LastIndicatorValue(context.Context, string) (domain.IndicatorValue, error) {
+	return domain.IndicatorValue(v), nil
+}
+
+func TestAlertEvaluatorFiresChangeOnce(t *testing.T) {
+	storage := &alertMemory{rules: []domain.AlertRule{{
+		ID:        1,
+		Kind:      domain.PriceAlert,
+		Exchange:  domain.BinanceExchange,
+		Symbol:    domain.BTCUSDT,
+		Operator:  domain.CrossUpOperator,
+		Threshold: 100,
+		Enabled:   true,
+	}}}
+	evaluator := NewAlertEvaluator(storage, storage)
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	changes := []domain.PriceChange{{
+		Exchange:  domain.BinanceExchange,
+		Symbol:    domain.BTCUSDT,
+		Price:     decimal.NewFromInt(101),
+		PrevPrice: decimal.NewFromInt(99),
+		Date:      date,
+	}}
+	// Window of price changes is recalculated, the same crossing is handled on every cycle.
+	for i := 0; i < 3; i++ {
+		if err := evaluator.HandlePriceChanges(context.Background(), changes); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(storage.alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(storage.alerts))
+	}
+	// Price returns below threshold and crosses it again.
+	changes = []domain.PriceChange{
+		{Exchange: domain.BinanceExchange, Symbol: domain.BTCUSDT, Price: decimal.NewFromInt(99), PrevPrice: decimal.NewFromInt(101), Date: date.Add(time.Minute)},
+		{Exchange: domain.BinanceExchange, Symbol: domain.BTCUSDT, Price: decimal.NewFromInt(101), PrevPrice: decimal.NewFromInt(99), Date: date.Add(2 * time.Minute)},
+	}
+	if err := evaluator.HandlePriceChanges(context.Background(), changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.alerts) != 2 {
+		t.Fatalf("new crossing: got %d alerts, want 2", len(storage.alerts))
+	}
+}
+
+func TestAlertEvaluatorIndicatorEventTime(t *testing.T) {
+	openTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	storage := &alertMemory{rules: []domain.AlertRule{{
+		ID:          1,
+		Kind:        domain.IndicatorAlert,
+		Exchange:    domain.BinanceExchange,
+		Symbol:      domain.BTCUSDT,
+		Operator:    domain.AboveOperator,
+		Threshold:   70,
+		IndicatorID: "rsi",
+		Output:      "value",
+		Enabled:     true,
+	}}}
+	evaluator := NewAlertEvaluator(storage, storage)
+	evaluator.WithIndicatorValues(lastIndicatorValue{
+		InstanceID: "rsi",
+		OpenTime:   openTime,
+		Values:     map[string]float64{"value": 75},
+	})
+	changes := []domain.PriceChange{{Exchange: domain.BinanceExchange, Symbol: domain.BTCUSDT, Date: openTime}}
+	for i := 0; i < 3; i++ {
+		if err := evaluator.HandlePriceChanges(context.Background(), changes); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(storage.alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1", len(storage.alerts))
+	}
+	if !storage.alerts[0].Date.Equal(openTime) {
+		t.Errorf("alert date %s, want open time of indicator value %s", storage.alerts[0].Date, openTime)
+	}
+}
+
+func TestAlertEvaluatorNotifiesWithState(t *testing.T) {
+	storage := &alertMemory{rules: []domain.AlertRule{{
+		ID:        1,
+		Kind:      domain.PriceAlert,
+		Exchange:  domain.BinanceExchange,
+		Symbol:    domain.BTCUSDT,
+		Operator:  domain.AboveOperator,
+		Threshold: 100,
+		Enabled:   true,
+	}}}
+	evaluator := NewAlertEvaluator(storage, storage)
+	evaluator.WithNotifications(true)
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	changes := []domain.PriceChange{{
+		Exchange:  domain.BinanceExchange,
+		Symbol:    domain.BTCUSDT,
+		Price:     decimal.NewFromInt(101),
+		PrevPrice: decimal.NewFromInt(99),
+		Date:      date,
+	}}
+	if err := evaluator.HandlePriceChanges(context.Background(), changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.alerts) != 1 || len(storage.notifications) != 1 {
+		t.Fatalf("got %d alerts and %d notifications, want 1 and 1", len(storage.alerts), len(storage.notifications))
+	}
+	if last := storage.rules[0].LastTriggered; last == nil || !last.Equal(date) {
+		t.Errorf("got last triggered %v, want %s", last, date)
+	}
+}
@@ -20,6 +20,7 @@ type ChangeCoefficient struct {
 	priceChangesRepo *db.PriceChanges
 	symbolsRepo      *db.Symbols
 	repo             *db.Aggregation
+	continuous       bool
 }
 
 func NewChangeCoefficient(
@@ -27,7 +28,17 @@ func NewChangeCoefficient(
 	repo *db.Aggregation,
 	symbolsRepo *db.Symbols,
 ) *ChangeCoefficient {
-	return &ChangeCoefficient{priceChangesRepo: priceChangesRepo, repo: repo, symbolsRepo: symbolsRepo}
+	return &ChangeCoefficient{
+		priceChangesRepo: priceChangesRepo,
+		repo:             repo,
+		symbolsRepo:      symbolsRepo,
+	}
+}
+
+// WithContinuousAggregates reads metrics from TimescaleDB continuous aggregates instead of calculation
+// from price changes.
+func (s *ChangeCoefficient) WithContinuousAggregates(enabled bool) {
+	s.continuous = enabled
 }
 
 func (s *ChangeCoefficient) Run(ctx context.Context, d time.Duration) {
@@ -83,24 +94,6 @@ func (s *ChangeCoefficient) Run(ctx context.Context, d time.Duration) {
 			}
 		}(metric)
 	}
-
-	go func() {
-		ticker := time.NewTicker(24 * time.Hour)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				if err := s.repo.DeleteOldRows(ctx, time.Now().Add(-7*24*time.Hour)); err != nil {
-					zap.L().Error(
-						"error delete old change coefficient",
-						zap.Error(err),
-					)
-				}
-			}
-		}
-	}()
 }
 
 func (s *ChangeCoefficient) executeChangeCoefficient(
@@ -115,6 +108,12 @@ func (s *ChangeCoefficient) executeChangeCoefficient(
 	}
 	for _, symbol := range symbols {
 		from := s.lastTimeUpdateMetric(ctx, m, symbol)
+		if s.continuous {
+			if err := s.saveContinuousAggregation(ctx, m, symbol, from); err != nil {
+				return err
+			}
+			continue
+		}
 		priceChanges, err := s.listPriceChanges(ctx, symbol, from)
 		if err != nil {
 			return errors.Wrap(err, "get price changes")
@@ -145,6 +144,12 @@ func (s *ChangeCoefficient) executeIndicatorChanges(
 	}
 	for _, symbol := range symbols {
 		from := s.lastTimeUpdateMetric(ctx, m, symbol)
+		if s.continuous {
+			if err := s.saveContinuousAggregation(ctx, m, symbol, from); err != nil {
+				return err
+			}
+			continue
+		}
 		priceChanges, err := s.listPriceChanges(ctx, symbol, from)
 		if err != nil {
 			return errors.Wrap(err, "get price changes")
@@ -163,6 +168,26 @@ func (s *ChangeCoefficient) executeIndicatorChanges(
 	return nil
 }
 
+func (s *ChangeCoefficient) saveContinuousAggregation(
+	ctx context.Context, m domain.MetricAggregationPrice, symbol string, from *time.Time,
+) error {
+	var since time.Time
+	if from != nil {
+		since = *from
+	}
+	prices, err := s.repo.ContinuousAggregation(ctx, m, symbol, since)
+	if err != nil {
+		return errors.Wrap(err, "get continuous aggregation")
+	}
+	if len(prices) == 0 {
+		return nil
+	}
+	errSave := backoff.Retry(func() error {
+		return s.repo.Save(ctx, prices...)
+	}, backoff.NewExponentialBackOff())
+	return errors.Wrap(errSave, "save continuous aggregation")
+}
+
 func (s *ChangeCoefficient) listPriceChanges(ctx context.Context, symbol string, from *time.Time) (ExchangePriceChanges, error) {
 	res := make(ExchangePriceChanges)
 	if from == nil {
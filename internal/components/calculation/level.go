@@ -0,0 +1,169 @@
+package calculation
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/level"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// DefaultLevelLookback is number of the last candlesticks which swings form levels.
+const DefaultLevelLookback = 500
+
+// LevelDetector finds support and resistance zones of every watchlist symbol and interval.
+type LevelDetector struct {
+	candlestickLoader domain.CandlestickLoader
+	storage           domain.LevelStorage
+	watchlist         domain.WatchlistLoader
+	lookback          int
+	params            level.Params
+}
+
+func NewLevelDetector(
+	candlestickLoader domain.CandlestickLoader,
+	storage domain.LevelStorage,
+	watchlist domain.WatchlistLoader,
+) *LevelDetector {
+	return &LevelDetector{
+		candlestickLoader: candlestickLoader,
+		storage:           storage,
+		watchlist:         watchlist,
+		lookback:          DefaultLevelLookback,
+		params:            level.DefaultParams(),
+	}
+}
+
+func (ld *LevelDetector) WithLookback(lookback int) {
+	if lookback > 0 {
+		ld.lookback = lookback
+	}
+}
+
+func (ld *LevelDetector) WithParams(params level.Params) {
+	ld.params = params
+}
+
+func (ld *LevelDetector) Run(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := ld.detect(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				zap.L().Error("error detect levels", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (ld *LevelDetector) detect(ctx context.Context) error {
+	items, err := ld.watchlist.Watchlist(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load watchlist")
+	}
+	for _, item := range items {
+		for _, interval := range item.Intervals {
+			if err := ld.detectSeries(ctx, item.Exchange, item.Symbol, interval); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				zap.L().Error(
+					"error detect levels of series",
+					zap.String("exchange", item.Exchange),
+					zap.String("symbol", item.Symbol),
+					zap.Stringer("interval", interval),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+func (ld *LevelDetector) detectSeries(ctx context.Context, exchange, symbol string, interval domain.Interval) error {
+	now := time.Now()
+	candles, err := ld.candlestickLoader.IntervalCandlesticks(
+		ctx, exchange, symbol, interval, interval.Truncate(now).Add(-time.Duration(ld.lookback)*interval.Duration()), now,
+	)
+	if err != nil {
+		return errors.Wrap(err, "load candlesticks")
+	}
+	levels := level.Detect(candles, ld.params)
+	errSave := backoff.Retry(func() error {
+		return ld.storage.SaveLevels(ctx, exchange, symbol, interval, levels)
+	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+	if errSave != nil {
+		return errors.Wrap(errSave, "save levels")
+	}
+	metric.Levels.WithLabelValues(exchange, symbol, interval.String()).Set(float64(len(levels)))
+	return nil
+}
+
+var _ domain.PriceChangeHandler = (*LevelCrossing)(nil)
+
+// LevelCrossing saves event of every price change which crosses level zone of its symbol.
+type LevelCrossing struct {
+	levels domain.LevelStorage
+	events domain.LevelEventStorage
+}
+
+func NewLevelCrossing(levels domain.LevelStorage, events domain.LevelEventStorage) *LevelCrossing {
+	return &LevelCrossing{levels: levels, events: events}
+}
+
+func (lc *LevelCrossing) HandlePriceChanges(ctx context.Context, changes []domain.PriceChange) error {
+	type symbolKey struct {
+		exchange, symbol string
+	}
+	bySymbol := make(map[symbolKey][]domain.Level)
+	var events []domain.LevelEvent
+	for _, change := range changes {
+		key := symbolKey{exchange: change.Exchange, symbol: change.Symbol}
+		levels, has := bySymbol[key]
+		if !has {
+			var err error
+			levels, err = lc.levels.Levels(ctx, change.Exchange, change.Symbol)
+			if err != nil {
+				return errors.Wrap(err, "load levels")
+			}
+			bySymbol[key] = levels
+		}
+		for _, item := range levels {
+			event, ok := item.Crossing(domain.PriceFloat(change.PrevPrice), domain.PriceFloat(change.Price))
+			if !ok {
+				continue
+			}
+			events = append(events, domain.LevelEvent{
+				Exchange:  change.Exchange,
+				Symbol:    change.Symbol,
+				Interval:  item.Interval,
+				Kind:      item.Kind,
+				Low:       item.Low,
+				High:      item.High,
+				Event:     event,
+				Price:     change.Price,
+				PrevPrice: change.PrevPrice,
+				Date:      change.Date,
+				CreatedAt: time.Now(),
+			})
+		}
+	}
+	inserted, err := lc.events.SaveLevelEvents(ctx, events)
+	if err != nil {
+		return errors.Wrap(err, "save level events")
+	}
+	for _, item := range inserted {
+		metric.LevelEvents.WithLabelValues(item.Exchange, string(item.Event)).Inc()
+	}
+	return nil
+}
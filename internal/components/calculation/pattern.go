@@ -0,0 +1,122 @@
+package calculation
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/pattern"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// DefaultPatternDepth is number of the last closed candlesticks which are checked for patterns on every run,
+// so patterns of candlesticks closed between runs are not missed.
+const DefaultPatternDepth = 3
+
+// PatternDetector detects candlestick patterns on the last closed candlesticks of every watchlist symbol and interval.
+type PatternDetector struct {
+	candlestickLoader domain.CandlestickLoader
+	storage           domain.PatternStorage
+	watchlist         domain.WatchlistLoader
+	depth             int
+}
+
+func NewPatternDetector(
+	candlestickLoader domain.CandlestickLoader,
+	storage domain.PatternStorage,
+	watchlist domain.WatchlistLoader,
+) *PatternDetector {
+	return &PatternDetector{
+		candlestickLoader: candlestickLoader,
+		storage:           storage,
+		watchlist:         watchlist,
+		depth:             DefaultPatternDepth,
+	}
+}
+
+func (pd *PatternDetector) WithDepth(depth int) {
+	if depth > 0 {
+		pd.depth = depth
+	}
+}
+
+func (pd *PatternDetector) Run(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := pd.detect(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				zap.L().Error("error detect candlestick patterns", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (pd *PatternDetector) detect(ctx context.Context) error {
+	items, err := pd.watchlist.Watchlist(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load watchlist")
+	}
+	var patterns []domain.CandlestickPattern
+	for _, item := range items {
+		for _, interval := range item.Intervals {
+			candles, err := pd.closedCandlesticks(ctx, item.Exchange, item.Symbol, interval)
+			if err != nil {
+				zap.L().Error(
+					"error load candlesticks of patterns",
+					zap.String("exchange", item.Exchange),
+					zap.String("symbol", item.Symbol),
+					zap.Stringer("interval", interval),
+					zap.Error(err),
+				)
+				continue
+			}
+			patterns = append(patterns, pattern.DetectLast(candles, pd.depth)...)
+		}
+	}
+	var inserted []domain.CandlestickPattern
+	errSave := backoff.Retry(func() error {
+		var err error
+		inserted, err = pd.storage.SavePatterns(ctx, patterns)
+		return err
+	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+	if errSave != nil {
+		return errors.Wrap(errSave, "save candlestick patterns")
+	}
+	// The last candlesticks are detected every run, only patterns found for the first time are counted.
+	for _, item := range inserted {
+		metric.CandlestickPatterns.WithLabelValues(item.Exchange, item.Interval.String(), string(item.Pattern)).Inc()
+	}
+	return nil
+}
+
+// closedCandlesticks returns the last closed candlesticks of series, enough to detect patterns on depth of them.
+func (pd *PatternDetector) closedCandlesticks(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) ([]dto.Candlestick, error) {
+	now := time.Now()
+	count := pd.depth + pattern.TrendLength
+	candles, err := pd.candlestickLoader.IntervalCandlesticks(
+		ctx, exchange, symbol, interval, interval.Truncate(now).Add(-time.Duration(count)*interval.Duration()), now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	closed := candles[:0:0]
+	for _, item := range candles {
+		if !item.CloseTime.After(now) {
+			closed = append(closed, item)
+		}
+	}
+	return closed, nil
+}
@@ -6,31 +6,55 @@ import (
 
 	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
 	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
-	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage/db"
 	"github.com/cenkalti/backoff/v4"
-	"github.com/duke-git/lancet/v2/mathutil"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
-type exchangePrices map[time.Time]map[string]float64
+// coefficientScale converts relative change of price into coefficient, coefficient is in hundredths of percent.
+var coefficientScale = decimal.NewFromInt(10000)
+
+// DefaultMinExchanges is number of exchanges which symbol must be traded on to have price changes calculated.
+const DefaultMinExchanges = 3
+
+type exchangePrices map[time.Time]map[string]decimal.Decimal
 
 type PriceChange struct {
-	symbolRepo       *db.Symbols
-	priceRepo        *db.PriceRepository
-	priceChangesRepo *db.PriceChanges
+	symbolRepo       domain.PopularSymbolLoader
+	priceRepo        domain.SymbolPriceLoader
+	priceChangesRepo domain.PriceChangeStorage
+	minExchanges     int
+
+	handlers []domain.PriceChangeHandler
 }
 
 func NewChangeCalculator(
-	priceRepo *db.PriceRepository,
-	priceChangesRepo *db.PriceChanges,
-	symbolRepo *db.Symbols,
+	priceRepo domain.SymbolPriceLoader,
+	priceChangesRepo domain.PriceChangeStorage,
+	symbolRepo domain.PopularSymbolLoader,
 ) *PriceChange {
 	return &PriceChange{
-		priceRepo: priceRepo, priceChangesRepo: priceChangesRepo, symbolRepo: symbolRepo,
+		priceRepo:        priceRepo,
+		priceChangesRepo: priceChangesRepo,
+		symbolRepo:       symbolRepo,
+		minExchanges:     DefaultMinExchanges,
 	}
 }
 
+// WithMinExchanges sets number of exchanges which symbol must be traded on, sources of listed exchanges
+// have fewer exchanges than crypto_loader.
+func (p *PriceChange) WithMinExchanges(count int) {
+	if count > 0 {
+		p.minExchanges = count
+	}
+}
+
+// WithHandlers adds handlers of newly saved price changes, error of handler is logged and does not stop calculation.
+func (p *PriceChange) WithHandlers(handlers ...domain.PriceChangeHandler) {
+	p.handlers = append(p.handlers, handlers...)
+}
+
 func (p *PriceChange) Run(ctx context.Context, d time.Duration) error {
 	errCh := make(chan error)
 	if err := p.execute(ctx); err != nil {
@@ -51,21 +75,6 @@ func (p *PriceChange) Run(ctx context.Context, d time.Duration) error {
 			}
 		}
 	}()
-
-	go func() {
-		ticker := time.NewTicker(24 * time.Hour)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				errCh <- ctx.Err()
-			case <-ticker.C:
-				if err := p.priceChangesRepo.DeleteOldRows(ctx, time.Now().Add(-7*24*time.Hour)); err != nil {
-					zap.L().Error("error delete old rows price_changes", zap.Error(err))
-				}
-			}
-		}
-	}()
 	return <-errCh
 }
 
@@ -79,7 +88,7 @@ func (p *PriceChange) execute(ctx context.Context) error {
 }
 
 func (p *PriceChange) calculate(ctx context.Context) error {
-	symbols, err := p.symbolRepo.PopularSymbols(ctx, 3)
+	symbols, err := p.symbolRepo.PopularSymbols(ctx, p.minExchanges)
 	if err != nil {
 		return errors.Wrap(err, "get all symbols")
 	}
@@ -87,9 +96,6 @@ func (p *PriceChange) calculate(ctx context.Context) error {
 	for _, symbol := range symbols {
 		p.calculatePriceChanges(ctx, symbol)
 	}
-	if err := p.priceRepo.ClearOldPrices(ctx, time.Now().In(time.UTC).Add(-1*time.Hour)); err != nil {
-		zap.L().Error("error clear old symbolPrice", zap.Error(err))
-	}
 	return nil
 }
 
@@ -125,27 +131,34 @@ func (p *PriceChange) calculatePriceChanges(ctx context.Context, symbol string)
 		coefficients := p.priceChanges(data, keys, symbol)
 
 		if len(coefficients) > 0 {
+			var inserted []domain.PriceChange
 			errSave := backoff.Retry(func() error {
-				return p.priceChangesRepo.Save(ctx, coefficients)
+				var err error
+				inserted, err = p.priceChangesRepo.Save(ctx, coefficients)
+				return err
 			}, backoff.NewExponentialBackOff())
 			if errSave != nil {
 				zap.L().Error("save CoefficientOfChange", zap.Error(errSave))
 				break
 			}
+			// Window is recalculated every cycle, handlers receive only changes saved for the first time.
+			if len(inserted) > 0 {
+				p.handle(ctx, inserted)
+			}
 		}
 
-		if err := p.priceRepo.DeletePrices(ctx, symbol, from, to.Add(-5*time.Minute)); err != nil {
-			zap.L().Error("error delete symbolPrice", zap.Error(err))
-			break
-		}
 		if !to.After(from) {
 			break
 		}
 		from = to
 	}
+}
 
-	if err := p.priceRepo.DeleteOldPrices(ctx, symbol, from.Add(-15*time.Minute)); err != nil {
-		zap.L().Error("error delete old symbolPrice", zap.Error(err))
+func (p *PriceChange) handle(ctx context.Context, changes []domain.PriceChange) {
+	for _, handler := range p.handlers {
+		if err := handler.HandlePriceChanges(ctx, changes); err != nil {
+			zap.L().Error("error handle price changes", zap.Error(err))
+		}
 	}
 }
 
@@ -160,7 +173,7 @@ func (p *PriceChange) loadSymbolData(ctx context.Context, symbol string, from, t
 		key := domain.ToDatetimeWithoutSec(symbolPrice.Date)
 
 		if data[key] == nil {
-			data[key] = make(map[string]float64)
+			data[key] = make(map[string]decimal.Decimal)
 			keys = append(keys, key)
 		}
 
@@ -170,16 +183,14 @@ func (p *PriceChange) loadSymbolData(ctx context.Context, symbol string, from, t
 }
 
 func (p *PriceChange) priceChanges(data exchangePrices, keys []time.Time, symbol string) []domain.PriceChange {
-	prevValues := make(map[string]float64)
+	prevValues := make(map[string]decimal.Decimal)
 	result := make([]domain.PriceChange, 0, len(keys))
 	for _, key := range keys {
 		for exchange, val := range data[key] {
-			if _, ok := prevValues[exchange]; ok {
+			if prevPrice, ok := prevValues[exchange]; ok {
 				var coefficientOfChanges int64
-				currentPrice := mathutil.RoundToFloat(val, 10)
-				prevPrice := mathutil.RoundToFloat(prevValues[exchange], 10)
-				if currentPrice > 0 && prevPrice > 0 {
-					coefficientOfChanges = int64((currentPrice - prevPrice) / currentPrice * 10000)
+				if val.IsPositive() && prevPrice.IsPositive() {
+					coefficientOfChanges = val.Sub(prevPrice).Div(val).Mul(coefficientScale).IntPart()
 				}
 				if coefficientOfChanges > 100_000 || coefficientOfChanges < -100_000 {
 					coefficientOfChanges = 0
@@ -190,7 +201,7 @@ func (p *PriceChange) priceChanges(data exchangePrices, keys []time.Time, symbol
 					Exchange:            exchange,
 					CoefficientOfChange: coefficientOfChanges,
 					Price:               val,
-					PrevPrice:           prevValues[exchange],
+					PrevPrice:           prevPrice,
 					CreatedAt:           time.Now().In(time.UTC),
 				})
 			}
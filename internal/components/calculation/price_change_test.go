@@ -0,0 +1,41 @@
+package calculation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPriceChangesCoefficient(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, item := range []struct {
+		name             string
+		prevPrice, price string
+		want             int64
+	}{
+		{name: "PEPE rise", prevPrice: "0.00001234", price: "0.00001240", want: 48},
+		{name: "SHIB fall", prevPrice: "0.00002501", price: "0.00002500", want: -4},
+		{name: "sub-cent unchanged", prevPrice: "0.000000012345", price: "0.000000012345", want: 0},
+		{name: "BTC rise", prevPrice: "67000", price: "67670", want: 99},
+		{name: "zero previous price", prevPrice: "0", price: "0.00001240", want: 0},
+		{name: "change out of bounds", prevPrice: "0.00001240", price: "0.00000001", want: 0},
+	} {
+		data := exchangePrices{
+			start:                  {"binance": decimal.RequireFromString(item.prevPrice)},
+			start.Add(time.Minute): {"binance": decimal.RequireFromString(item.price)},
+		}
+		changes := (&PriceChange{}).priceChanges(data, []time.Time{start, start.Add(time.Minute)}, "PEPEUSDT")
+		if len(changes) != 1 {
+			t.Fatalf("%s: got %d changes, want 1", item.name, len(changes))
+		}
+		change := changes[0]
+		if change.CoefficientOfChange != item.want {
+			t.Errorf("%s: got coefficient %d, want %d", item.name, change.CoefficientOfChange, item.want)
+		}
+		if change.Price.String() != decimal.RequireFromString(item.price).String() ||
+			change.PrevPrice.String() != decimal.RequireFromString(item.prevPrice).String() {
+			t.Errorf("%s: got prices %s -> %s", item.name, change.PrevPrice, change.Price)
+		}
+	}
+}
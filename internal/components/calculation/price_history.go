@@ -0,0 +1,157 @@
+package calculation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultMaxPriceBars is the most bars returned for range, finer tiers are skipped when range has more bars.
+	DefaultMaxPriceBars = 5000
+	// DefaultPriceHistoryLateness is how long before the latest bucket of tier buckets are recalculated.
+	DefaultPriceHistoryLateness = 10 * time.Minute
+)
+
+var _ domain.PriceHistoryReader = (*PriceHistory)(nil)
+
+// PriceHistory rolls raw prices up into tiers of price history and reads bars of tier suitable for range.
+type PriceHistory struct {
+	storage  domain.PriceHistoryStorage
+	maxBars  int
+	lateness time.Duration
+}
+
+func NewPriceHistory(storage domain.PriceHistoryStorage) *PriceHistory {
+	return &PriceHistory{storage: storage, maxBars: DefaultMaxPriceBars, lateness: DefaultPriceHistoryLateness}
+}
+
+func (ph *PriceHistory) WithMaxBars(maxBars int) {
+	if maxBars > 0 {
+		ph.maxBars = maxBars
+	}
+}
+
+// WithLateness sets how long before the latest bucket of tier buckets are recalculated, the latest bucket
+// is global for all symbols, so prices of symbol saved later than other symbols are rolled up within lateness.
+func (ph *PriceHistory) WithLateness(lateness time.Duration) {
+	if lateness >= 0 {
+		ph.lateness = lateness
+	}
+}
+
+func (ph *PriceHistory) Run(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		if err := ph.Rollup(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			zap.L().Error("error rollup price history", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rollup rolls every tier up from bucket of lateness before its last bucket, the last bucket may be incomplete
+// and symbols may lag behind the last bucket, so buckets of trailing window are recalculated.
+func (ph *PriceHistory) Rollup(ctx context.Context) error {
+	for _, tier := range domain.PriceHistoryTiers {
+		start := time.Now()
+		from, err := rollupFrom(ctx, ph.storage, tier, ph.lateness)
+		if err != nil {
+			return err
+		}
+		if err := ph.storage.RollupPrices(ctx, tier, from); err != nil {
+			return err
+		}
+		metric.PriceHistoryRollups.WithLabelValues(tier.String()).Inc()
+		metric.PriceHistoryRollupDuration.WithLabelValues(tier.String()).Add(float64(time.Since(start).Milliseconds()))
+	}
+	return nil
+}
+
+// Guards return retention guards by table, rows of table are kept until they are rolled up into the next tier.
+func (ph *PriceHistory) Guards() map[string]domain.RetentionGuard {
+	guards := make(map[string]domain.RetentionGuard, len(domain.PriceHistoryTiers))
+	source := "prices"
+	for _, tier := range domain.PriceHistoryTiers {
+		guards[source] = rollupGuard{storage: ph.storage, tier: tier, lateness: ph.lateness}
+		source = "price_history_" + tier.String()
+	}
+	return guards
+}
+
+// Bars returns bars of symbol on exchange between from and to of the finest tier which covers from and has
+// no more than max bars, the coarsest tier is used when there is no such tier. Empty resolution picks tier.
+func (ph *PriceHistory) Bars(
+	ctx context.Context, exchange, symbol string, resolution domain.Interval, from, to time.Time,
+) (domain.Interval, []domain.PriceBar, error) {
+	if resolution == "" {
+		tier, err := ph.pickTier(ctx, exchange, symbol, from, to)
+		if err != nil {
+			return "", nil, err
+		}
+		resolution = tier
+	} else if !domain.IsPriceHistoryTier(resolution) {
+		return "", nil, fmt.Errorf("unknown price history resolution: %s", resolution)
+	}
+	bars, err := ph.storage.PriceBars(ctx, exchange, symbol, resolution, resolution.Truncate(from), to)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "load %s price bars", resolution)
+	}
+	return resolution, bars, nil
+}
+
+func (ph *PriceHistory) pickTier(ctx context.Context, exchange, symbol string, from, to time.Time) (domain.Interval, error) {
+	coarsest := domain.PriceHistoryTiers[len(domain.PriceHistoryTiers)-1]
+	for _, tier := range domain.PriceHistoryTiers[:len(domain.PriceHistoryTiers)-1] {
+		if tier.Count(tier.Truncate(from), to) > ph.maxBars {
+			continue
+		}
+		first, err := ph.storage.FirstBucket(ctx, exchange, symbol, tier)
+		if err != nil {
+			return "", errors.Wrapf(err, "first bucket of %s", tier)
+		}
+		if !first.IsZero() && !first.After(tier.Truncate(from)) {
+			return tier, nil
+		}
+	}
+	return coarsest, nil
+}
+
+// rollupFrom returns open time of the first bucket of tier recalculated by rollup, zero time rolls up all rows.
+func rollupFrom(
+	ctx context.Context, storage domain.PriceHistoryStorage, tier domain.Interval, lateness time.Duration,
+) (time.Time, error) {
+	last, err := storage.LastBucket(ctx, tier)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "last bucket of %s", tier)
+	}
+	if last.IsZero() {
+		return last, nil
+	}
+	return tier.Truncate(last.Add(-lateness)), nil
+}
+
+// rollupGuard keeps source rows of tier which are not rolled up, rows of buckets in trailing window
+// are recalculated by the next rollup, so they are kept too.
+type rollupGuard struct {
+	storage  domain.PriceHistoryStorage
+	tier     domain.Interval
+	lateness time.Duration
+}
+
+func (g rollupGuard) RetainedSince(ctx context.Context) (time.Time, error) {
+	return rollupFrom(ctx, g.storage, g.tier, g.lateness)
+}
@@ -0,0 +1,130 @@
+package calculation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+)
+
+// historyMemory has the last bucket of every tier and the first buckets of symbol, rollups are recorded.
+type historyMemory struct {
+	domain.PriceHistoryStorage
+
+	last    map[domain.Interval]time.Time
+	first   map[domain.Interval]time.Time
+	rollups map[domain.Interval]time.Time
+}
+
+func (m *historyMemory) LastBucket(_ context.Context, tier domain.Interval) (time.Time, error) {
+	return m.last[tier], nil
+}
+
+func (m *historyMemory) FirstBucket(_ context.Context, _, _ string, tier domain.Interval) (time.Time, error) {
+	return m.first[tier], nil
+}
+
+func (m *historyMemory) RollupPrices(_ context.Context, tier domain.Interval, from time.Time) error {
+	m.rollups[tier] = from
+	return nil
+}
+
+func TestPriceHistoryRollupTrailingWindow(t *testing.T) {
+	last := time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)
+	storage := &historyMemory{
+		last: map[domain.Interval]time.Time{
+			domain.OneMinuteInterval:  last,
+			domain.FiveMinuteInterval: last,
+			domain.OneHourInterval:    time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+		rollups: make(map[domain.Interval]time.Time),
+	}
+	history := NewPriceHistory(storage)
+	history.WithLateness(7 * time.Minute)
+	if err := history.Rollup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Buckets are recalculated from the first bucket of tier within lateness.
+	for tier, want := range map[domain.Interval]time.Time{
+		domain.OneMinuteInterval:  time.Date(2024, 1, 1, 9, 58, 0, 0, time.UTC),
+		domain.FiveMinuteInterval: time.Date(2024, 1, 1, 9, 55, 0, 0, time.UTC),
+		domain.OneHourInterval:    time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+	} {
+		if got := storage.rollups[tier]; !got.Equal(want) {
+			t.Errorf("%s: rolled up from %s, want %s", tier, got, want)
+		}
+	}
+
+	// Empty tier is rolled up from all rows.
+	storage.last = nil
+	if err := history.Rollup(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for tier, from := range storage.rollups {
+		if !from.IsZero() {
+			t.Errorf("%s: empty tier rolled up from %s", tier, from)
+		}
+	}
+}
+
+func TestPriceHistoryGuards(t *testing.T) {
+	storage := &historyMemory{last: map[domain.Interval]time.Time{
+		domain.OneMinuteInterval:  time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC),
+		domain.FiveMinuteInterval: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		domain.OneHourInterval:    time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+	}}
+	history := NewPriceHistory(storage)
+	history.WithLateness(10 * time.Minute)
+	guards := history.Guards()
+	// Rows of table are kept since the first bucket recalculated by rollup of the next tier.
+	expected := map[string]time.Time{
+		"prices":           time.Date(2024, 1, 1, 9, 55, 0, 0, time.UTC),
+		"price_history_1m": time.Date(2024, 1, 1, 9, 50, 0, 0, time.UTC),
+		"price_history_5m": time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+	}
+	if len(guards) != len(expected) {
+		t.Fatalf("got guards of %d tables, want %d", len(guards), len(expected))
+	}
+	for table, want := range expected {
+		guard, has := guards[table]
+		if !has {
+			t.Fatalf("no guard of %s", table)
+		}
+		got, err := guard.RetainedSince(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(want) {
+			t.Errorf("%s: retained since %s, want %s", table, got, want)
+		}
+	}
+}
+
+func TestPriceHistoryPickTier(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := &historyMemory{first: map[domain.Interval]time.Time{
+		domain.OneMinuteInterval:  start.AddDate(0, 0, 5),
+		domain.FiveMinuteInterval: start,
+		domain.OneHourInterval:    start,
+	}}
+	history := NewPriceHistory(storage)
+	history.WithMaxBars(1000)
+	for name, item := range map[string]struct {
+		from, to time.Time
+		tier     domain.Interval
+	}{
+		"finest tier covers range":      {from: start.AddDate(0, 0, 6), to: start.AddDate(0, 0, 6).Add(time.Hour), tier: domain.OneMinuteInterval},
+		"finest tier starts after from": {from: start.AddDate(0, 0, 4), to: start.AddDate(0, 0, 4).Add(time.Hour), tier: domain.FiveMinuteInterval},
+		"too many bars of fine tiers":   {from: start, to: start.AddDate(0, 0, 30), tier: domain.OneHourInterval},
+		"range before all tiers":        {from: start.AddDate(0, 0, -1), to: start, tier: domain.OneHourInterval},
+	} {
+		tier, err := history.pickTier(context.Background(), domain.BinanceExchange, domain.BTCUSDT, item.from, item.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tier != item.tier {
+			t.Errorf("%s: got tier %s, want %s", name, tier, item.tier)
+		}
+	}
+}
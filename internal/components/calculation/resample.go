@@ -0,0 +1,346 @@
+package calculation
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage/db"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultResampleLookback  = 14 * 24 * time.Hour
+	DefaultResampleTolerance = 0.001
+)
+
+var (
+	DefaultResampleSources = []domain.Interval{domain.OneHourInterval, domain.OneMinuteInterval}
+	DefaultResampleTargets = []domain.Interval{domain.FourHourInterval, domain.OneDayInterval, domain.OneWeekInterval}
+)
+
+// Resampler builds candlesticks of higher intervals from stored candlesticks of lower intervals
+// and compares them with candlesticks loaded from exchange.
+type Resampler struct {
+	candlesticks *db.Candlestick
+	storage      domain.CandlestickSaver
+	watchlist    domain.WatchlistLoader
+
+	sources       []domain.Interval
+	targets       []domain.Interval
+	sessionOffset time.Duration
+	lookback      time.Duration
+	tolerance     float64
+
+	// reported keeps divergences which are already counted and logged, the same candlesticks are compared
+	// every period while they are within lookback.
+	mu       sync.Mutex
+	reported map[divergenceKey]struct{}
+}
+
+type divergenceKey struct {
+	exchange, symbol string
+	interval         domain.Interval
+	openTime         time.Time
+	field            string
+}
+
+func NewResampler(
+	candlesticks *db.Candlestick,
+	storage domain.CandlestickSaver,
+	watchlist domain.WatchlistLoader,
+) *Resampler {
+	return &Resampler{
+		candlesticks: candlesticks,
+		storage:      storage,
+		watchlist:    watchlist,
+		sources:      DefaultResampleSources,
+		targets:      DefaultResampleTargets,
+		lookback:     DefaultResampleLookback,
+		tolerance:    DefaultResampleTolerance,
+		reported:     make(map[divergenceKey]struct{}),
+	}
+}
+
+// WithIntervals sets intervals which are aggregated, sources are tried in order for every target.
+func (r *Resampler) WithIntervals(sources, targets []domain.Interval) {
+	r.sources = sources
+	r.targets = targets
+}
+
+// WithSessionOffset sets start of sessions relative to UTC midnight, target candlesticks are aligned to it.
+func (r *Resampler) WithSessionOffset(offset time.Duration) {
+	r.sessionOffset = offset
+}
+
+func (r *Resampler) WithLookback(lookback time.Duration) {
+	r.lookback = lookback
+}
+
+// WithTolerance sets relative difference of derived and exchange values which is reported as divergence.
+func (r *Resampler) WithTolerance(tolerance float64) {
+	r.tolerance = tolerance
+}
+
+func (r *Resampler) Run(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := r.resampleWatchlist(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				zap.L().Error("error resample candlesticks", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (r *Resampler) resampleWatchlist(ctx context.Context) error {
+	items, err := r.watchlist.Watchlist(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load watchlist")
+	}
+	to := time.Now().UTC()
+	from := to.Add(-r.lookback)
+	r.forget(from)
+	for _, item := range items {
+		if _, err := r.Resample(ctx, item.Exchange, item.Symbol, from, to); err != nil {
+			return errors.Wrapf(err, "resample %s %s", item.Exchange, item.Symbol)
+		}
+	}
+	return nil
+}
+
+// Resample saves derived candlesticks of target intervals opened in [from, to) and returns their divergences
+// from exchange candlesticks. Candlesticks loaded from exchange are not replaced.
+func (r *Resampler) Resample(ctx context.Context, exchange, symbol string, from, to time.Time) ([]domain.CandlestickDivergence, error) {
+	return r.resample(ctx, exchange, symbol, from, to, true)
+}
+
+// Check returns divergences of derived candlesticks opened in [from, to) from exchange candlesticks without saving.
+func (r *Resampler) Check(ctx context.Context, exchange, symbol string, from, to time.Time) ([]domain.CandlestickDivergence, error) {
+	return r.resample(ctx, exchange, symbol, from, to, false)
+}
+
+func (r *Resampler) resample(
+	ctx context.Context, exchange, symbol string, from, to time.Time, save bool,
+) ([]domain.CandlestickDivergence, error) {
+	var divergences []domain.CandlestickDivergence
+	for _, target := range r.targets {
+		derived, err := r.derive(ctx, exchange, symbol, target, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if len(derived) == 0 {
+			continue
+		}
+		stored, err := r.candlesticks.IntervalCandlesticks(
+			ctx, exchange, symbol, target, derived[0].OpenTime, derived[len(derived)-1].CloseTime,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "load exchange candlesticks")
+		}
+		exchangeCandles := make(map[time.Time]dto.Candlestick, len(stored))
+		for _, item := range stored {
+			if !item.Derived {
+				exchangeCandles[item.OpenTime.UTC()] = item
+			}
+		}
+		candlesticks := make([]dto.Candlestick, 0, len(derived))
+		for _, item := range derived {
+			exchangeCandle, has := exchangeCandles[item.OpenTime]
+			if !has {
+				candlesticks = append(candlesticks, item)
+				continue
+			}
+			divergences = append(divergences, Compare(exchangeCandle, item, target, r.tolerance)...)
+		}
+		if !save || len(candlesticks) == 0 {
+			continue
+		}
+		errSave := backoff.Retry(func() error {
+			return r.storage.Save(ctx, candlesticks)
+		}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+		if errSave != nil {
+			return nil, errors.Wrap(errSave, "save derived candlesticks")
+		}
+		metric.ResampleCandlesticks.WithLabelValues(exchange, target.String()).Add(float64(len(candlesticks)))
+	}
+	for _, item := range r.report(divergences) {
+		metric.ResampleDivergences.WithLabelValues(item.Exchange, item.Interval.String()).Inc()
+		zap.L().Warn("derived candlestick diverges from exchange", zap.Any("divergence", item))
+	}
+	return divergences, nil
+}
+
+// report returns divergences which are not reported yet and marks them as reported.
+func (r *Resampler) report(divergences []domain.CandlestickDivergence) []domain.CandlestickDivergence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []domain.CandlestickDivergence
+	for _, item := range divergences {
+		key := divergenceKey{
+			exchange: item.Exchange,
+			symbol:   item.Symbol,
+			interval: item.Interval,
+			openTime: item.OpenTime.UTC(),
+			field:    item.Field,
+		}
+		if _, has := r.reported[key]; has {
+			continue
+		}
+		r.reported[key] = struct{}{}
+		result = append(result, item)
+	}
+	return result
+}
+
+// forget drops reported divergences of candlesticks which are not derived from from anymore.
+func (r *Resampler) forget(from time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.reported {
+		if key.openTime.Before(key.interval.TruncateInSession(from, r.sessionOffset)) {
+			delete(r.reported, key)
+		}
+	}
+}
+
+// derive aggregates candlesticks of target interval from the first source interval which has candlesticks.
+func (r *Resampler) derive(
+	ctx context.Context, exchange, symbol string, target domain.Interval, from, to time.Time,
+) ([]dto.Candlestick, error) {
+	from = target.TruncateInSession(from, r.sessionOffset)
+	for _, source := range r.sources {
+		if source.Duration() >= target.Duration() || target.Duration()%source.Duration() != 0 {
+			continue
+		}
+		candles, err := r.candlesticks.IntervalCandlesticks(ctx, exchange, symbol, source, from, to)
+		if err != nil {
+			return nil, errors.Wrap(err, "load source candlesticks")
+		}
+		if len(candles) == 0 {
+			continue
+		}
+		return Aggregate(candles, source, target, r.sessionOffset), nil
+	}
+	return nil, nil
+}
+
+// Aggregate builds candlesticks of target interval from candlesticks of source interval. Only closed periods
+// with all source candlesticks are built, derived candlesticks which overlap missed source data would be wrong.
+func Aggregate(candles []dto.Candlestick, source, target domain.Interval, sessionOffset time.Duration) []dto.Candlestick {
+	sorted := make([]dto.Candlestick, 0, len(candles))
+	for _, item := range candles {
+		if item.Interval == source.String() {
+			sorted = append(sorted, item)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].OpenTime.Before(sorted[j].OpenTime) })
+
+	expected := int(target.Duration() / source.Duration())
+	now := time.Now()
+	var (
+		result  []dto.Candlestick
+		current *dto.Candlestick
+		count   int
+		prev    time.Time
+	)
+	flush := func() {
+		if current != nil && count == expected && current.CloseTime.Before(now) {
+			result = append(result, *current)
+		}
+	}
+	for _, item := range sorted {
+		openTime := item.OpenTime.UTC()
+		// Source candlesticks are unique by open time, duplicates come from overlapping pages.
+		if current != nil && openTime.Equal(prev) {
+			continue
+		}
+		prev = openTime
+		bucket := target.TruncateInSession(openTime, sessionOffset)
+		if current == nil || !current.OpenTime.Equal(bucket) {
+			flush()
+			current = &dto.Candlestick{
+				Symbol:    item.Symbol,
+				Exchange:  item.Exchange,
+				OpenTime:  bucket,
+				CloseTime: target.CloseTime(bucket),
+				OpenPrice: item.OpenPrice,
+				HighPrice: item.HighPrice,
+				LowPrice:  item.LowPrice,
+				Interval:  target.String(),
+				CreatedAt: now,
+				Derived:   true,
+			}
+			count = 0
+		}
+		current.HighPrice = decimal.Max(current.HighPrice, item.HighPrice)
+		current.LowPrice = decimal.Min(current.LowPrice, item.LowPrice)
+		current.ClosePrice = item.ClosePrice
+		current.Volume = current.Volume.Add(item.Volume)
+		current.NumberTrades += item.NumberTrades
+		count++
+	}
+	flush()
+	return result
+}
+
+// Compare returns fields of derived candlestick which differ from exchange one more than tolerance.
+func Compare(exchangeCandle, derived dto.Candlestick, interval domain.Interval, tolerance float64) []domain.CandlestickDivergence {
+	fields := []struct {
+		name              string
+		expected, derived decimal.Decimal
+	}{
+		{name: "open_price", expected: exchangeCandle.OpenPrice, derived: derived.OpenPrice},
+		{name: "high_price", expected: exchangeCandle.HighPrice, derived: derived.HighPrice},
+		{name: "low_price", expected: exchangeCandle.LowPrice, derived: derived.LowPrice},
+		{name: "close_price", expected: exchangeCandle.ClosePrice, derived: derived.ClosePrice},
+		{name: "volume", expected: exchangeCandle.Volume, derived: derived.Volume},
+		{
+			name:     "number_trades",
+			expected: decimal.NewFromInt(int64(exchangeCandle.NumberTrades)),
+			derived:  decimal.NewFromInt(int64(derived.NumberTrades)),
+		},
+	}
+	var divergences []domain.CandlestickDivergence
+	for _, field := range fields {
+		diff := relativeDiff(field.expected, field.derived)
+		if diff <= tolerance {
+			continue
+		}
+		divergences = append(divergences, domain.CandlestickDivergence{
+			Exchange: derived.Exchange,
+			Symbol:   derived.Symbol,
+			Interval: interval,
+			OpenTime: derived.OpenTime,
+			Field:    field.name,
+			Expected: domain.PriceFloat(field.expected),
+			Derived:  domain.PriceFloat(field.derived),
+			Diff:     diff,
+		})
+	}
+	return divergences
+}
+
+func relativeDiff(expected, actual decimal.Decimal) float64 {
+	if expected.Equal(actual) {
+		return 0
+	}
+	if expected.IsZero() {
+		return 1
+	}
+	return actual.Sub(expected).Abs().Div(expected.Abs()).InexactFloat64()
+}
@@ -0,0 +1,128 @@
+package calculation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/shopspring/decimal"
+)
+
+// sourceCandles returns count candlesticks of interval from first, prices grow by one every candlestick.
+func sourceCandles(interval domain.Interval, first time.Time, count int) []dto.Candlestick {
+	result := make([]dto.Candlestick, 0, count)
+	for i := 0; i < count; i++ {
+		openTime := first.Add(time.Duration(i) * interval.Duration())
+		price := decimal.NewFromInt(int64(100 + i))
+		result = append(result, dto.Candlestick{
+			Exchange:     domain.BinanceExchange,
+			Symbol:       domain.BTCUSDT,
+			Interval:     interval.String(),
+			OpenTime:     openTime,
+			CloseTime:    interval.CloseTime(openTime),
+			OpenPrice:    price,
+			HighPrice:    price.Add(decimal.NewFromInt(2)),
+			LowPrice:     price.Sub(decimal.NewFromInt(1)),
+			ClosePrice:   price.Add(decimal.NewFromInt(1)),
+			Volume:       decimal.NewFromInt(1),
+			NumberTrades: 1,
+		})
+	}
+	return result
+}
+
+func TestAggregateIncompleteBucket(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := sourceCandles(domain.OneHourInterval, first, 8)
+	// Candlestick of 05:00 is missed, bucket of 04:00 is not built.
+	candles = append(candles[:5], candles[6:]...)
+	result := Aggregate(candles, domain.OneHourInterval, domain.FourHourInterval, 0)
+	if len(result) != 1 {
+		t.Fatalf("got %d candlesticks, want 1", len(result))
+	}
+	item := result[0]
+	if !item.OpenTime.Equal(first) || !item.CloseTime.Equal(domain.FourHourInterval.CloseTime(first)) {
+		t.Errorf("got candlestick of %s - %s", item.OpenTime, item.CloseTime)
+	}
+	if !item.OpenPrice.Equal(decimal.NewFromInt(100)) || !item.HighPrice.Equal(decimal.NewFromInt(105)) ||
+		!item.LowPrice.Equal(decimal.NewFromInt(99)) || !item.ClosePrice.Equal(decimal.NewFromInt(104)) {
+		t.Errorf("got prices %s %s %s %s, want 100 105 99 104", item.OpenPrice, item.HighPrice, item.LowPrice, item.ClosePrice)
+	}
+	if !item.Volume.Equal(decimal.NewFromInt(4)) || item.NumberTrades != 4 || !item.Derived {
+		t.Errorf("got volume %s, trades %d, derived %t", item.Volume, item.NumberTrades, item.Derived)
+	}
+}
+
+func TestAggregateSessionOffset(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Sessions start at 08:00, only session of 2024-01-01 08:00 is complete.
+	result := Aggregate(sourceCandles(domain.OneHourInterval, first, 48), domain.OneHourInterval, domain.OneDayInterval, 8*time.Hour)
+	if len(result) != 1 {
+		t.Fatalf("got %d candlesticks, want 1", len(result))
+	}
+	if want := first.Add(8 * time.Hour); !result[0].OpenTime.Equal(want) {
+		t.Errorf("got open time %s, want %s", result[0].OpenTime, want)
+	}
+	if !result[0].OpenPrice.Equal(decimal.NewFromInt(108)) || !result[0].ClosePrice.Equal(decimal.NewFromInt(132)) {
+		t.Errorf("got open %s and close %s, want 108 and 132", result[0].OpenPrice, result[0].ClosePrice)
+	}
+}
+
+func TestAggregateWeekly(t *testing.T) {
+	// 2023-12-31 is Sunday, week starts on Monday 2024-01-01.
+	first := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+	result := Aggregate(sourceCandles(domain.OneDayInterval, first, 9), domain.OneDayInterval, domain.OneWeekInterval, 0)
+	if len(result) != 1 {
+		t.Fatalf("got %d candlesticks, want 1", len(result))
+	}
+	if want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !result[0].OpenTime.Equal(want) {
+		t.Errorf("got open time %s, want %s", result[0].OpenTime, want)
+	}
+	if !result[0].Volume.Equal(decimal.NewFromInt(7)) {
+		t.Errorf("got volume %s, want 7", result[0].Volume)
+	}
+}
+
+func TestCompareTolerance(t *testing.T) {
+	exchangeCandle := sourceCandles(domain.OneHourInterval, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 1)[0]
+	exchangeCandle.ClosePrice = decimal.NewFromInt(1000)
+	for close, diverges := range map[string]bool{
+		"1000":    false,
+		"1001":    false,
+		"999":     false,
+		"1001.01": true,
+		"998.99":  true,
+	} {
+		derived := exchangeCandle
+		derived.ClosePrice = decimal.RequireFromString(close)
+		divergences := Compare(exchangeCandle, derived, domain.OneHourInterval, 0.001)
+		if diverges != (len(divergences) == 1) {
+			t.Errorf("close %s: got divergences %+v, diverges %t", close, divergences, diverges)
+			continue
+		}
+		if diverges && divergences[0].Field != "close_price" {
+			t.Errorf("close %s: got field %s", close, divergences[0].Field)
+		}
+	}
+}
+
+func TestResamplerReportsDivergenceOnce(t *testing.T) {
+	openTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	divergences := []domain.CandlestickDivergence{
+		{Exchange: domain.BinanceExchange, Symbol: domain.BTCUSDT, Interval: domain.OneDayInterval, OpenTime: openTime, Field: "volume"},
+		{Exchange: domain.BinanceExchange, Symbol: domain.BTCUSDT, Interval: domain.OneDayInterval, OpenTime: openTime, Field: "close_price"},
+	}
+	resampler := NewResampler(nil, nil, nil)
+	if got := resampler.report(divergences); len(got) != 2 {
+		t.Fatalf("got %d new divergences, want 2", len(got))
+	}
+	// The same candlesticks are compared on the next period.
+	if got := resampler.report(divergences[:1]); len(got) != 0 {
+		t.Errorf("got %d new divergences, want 0", len(got))
+	}
+	resampler.forget(openTime.AddDate(0, 0, 1))
+	if got := resampler.report(divergences[:1]); len(got) != 1 {
+		t.Errorf("got %d divergences after forget, want 1", len(got))
+	}
+}
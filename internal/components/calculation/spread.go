@@ -0,0 +1,96 @@
+package calculation
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultSpreadFee is taker fee in percent of exchange without configured fee.
+const DefaultSpreadFee = 0.1
+
+var _ domain.PriceChangeHandler = (*SpreadCalculator)(nil)
+
+// SpreadCalculator saves the best cross-exchange spread of every symbol and minute of price changes.
+// Spreads are calculated from last traded prices, bid and ask prices are not supported.
+type SpreadCalculator struct {
+	storage domain.SpreadStorage
+	prices  domain.MinutePriceLoader
+	fees    domain.SpreadFees
+}
+
+func NewSpreadCalculator(storage domain.SpreadStorage, prices domain.MinutePriceLoader) *SpreadCalculator {
+	return &SpreadCalculator{storage: storage, prices: prices, fees: domain.SpreadFees{Default: DefaultSpreadFee}}
+}
+
+// WithFees sets taker fees in percent, fee of exchange which is not listed is defaultFee.
+func (sc *SpreadCalculator) WithFees(defaultFee float64, exchanges map[string]float64) {
+	sc.fees = domain.SpreadFees{Default: defaultFee, Exchanges: exchanges}
+}
+
+// HandlePriceChanges receives only newly saved price changes, prices of other exchanges in the same minutes
+// may be saved by previous calculations, so prices of all exchanges are loaded from storage.
+func (sc *SpreadCalculator) HandlePriceChanges(ctx context.Context, changes []domain.PriceChange) error {
+	type minuteKey struct {
+		symbol string
+		date   time.Time
+	}
+	dates := make(map[string][]time.Time)
+	seen := make(map[minuteKey]bool)
+	for _, change := range changes {
+		key := minuteKey{symbol: change.Symbol, date: change.Date}
+		if !seen[key] {
+			seen[key] = true
+			dates[change.Symbol] = append(dates[change.Symbol], change.Date)
+		}
+	}
+	prices := make(map[minuteKey]map[string]decimal.Decimal)
+	add := func(change domain.PriceChange) {
+		key := minuteKey{symbol: change.Symbol, date: change.Date}
+		if prices[key] == nil {
+			prices[key] = make(map[string]decimal.Decimal)
+		}
+		prices[key][change.Exchange] = change.Price
+	}
+	for symbol, symbolDates := range dates {
+		saved, err := sc.prices.MinutePrices(ctx, symbol, symbolDates)
+		if err != nil {
+			return errors.Wrap(err, "load prices of exchanges")
+		}
+		for _, change := range saved {
+			if seen[minuteKey{symbol: change.Symbol, date: change.Date}] {
+				add(change)
+			}
+		}
+	}
+	for _, change := range changes {
+		add(change)
+	}
+	spreads := make([]domain.Spread, 0, len(prices))
+	for key, exchangePrices := range prices {
+		spread, ok := sc.fees.BestSpread(key.symbol, key.date, exchangePrices)
+		if !ok {
+			continue
+		}
+		spread.CreatedAt = time.Now()
+		spreads = append(spreads, spread)
+	}
+	sort.Slice(spreads, func(i, j int) bool { return spreads[i].Date.Before(spreads[j].Date) })
+	saved, err := sc.storage.SaveSpreads(ctx, spreads)
+	if err != nil {
+		return errors.Wrap(err, "save spreads")
+	}
+	// Spreads of recalculated minutes are counted only when they are new or changed.
+	for _, item := range saved {
+		metric.Spreads.WithLabelValues(item.BuyExchange, item.SellExchange).Inc()
+		if item.NetPercent > 0 {
+			metric.SpreadOpportunities.WithLabelValues(item.BuyExchange, item.SellExchange).Inc()
+		}
+	}
+	return nil
+}
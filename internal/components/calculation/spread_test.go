@@ -0,0 +1,56 @@
+package calculation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/shopspring/decimal"
+)
+
+// spreadMemory keeps saved spreads, price changes are prices of exchanges saved by previous calculations.
+type spreadMemory struct {
+	domain.SpreadStorage
+
+	changes []domain.PriceChange
+	spreads []domain.Spread
+}
+
+func (m *spreadMemory) SaveSpreads(_ context.Context, spreads []domain.Spread) ([]domain.Spread, error) {
+	m.spreads = append(m.spreads, spreads...)
+	return spreads, nil
+}
+
+func (m *spreadMemory) MinutePrices(_ context.Context, symbol string, dates []time.Time) ([]domain.PriceChange, error) {
+	var result []domain.PriceChange
+	for _, change := range m.changes {
+		for _, date := range dates {
+			if change.Symbol == symbol && change.Date.Equal(date) {
+				result = append(result, change)
+			}
+		}
+	}
+	return result, nil
+}
+
+func TestSpreadCalculatorLoadsPricesOfExchanges(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	binance := domain.PriceChange{Exchange: domain.BinanceExchange, Symbol: domain.BTCUSDT, Price: decimal.NewFromInt(100), Date: date}
+	bybit := domain.PriceChange{Exchange: domain.BybitExchange, Symbol: domain.BTCUSDT, Price: decimal.NewFromInt(101), Date: date}
+	// Price of binance is saved by previous calculation, another minute of binance is not loaded.
+	storage := &spreadMemory{changes: []domain.PriceChange{binance, bybit, {
+		Exchange: domain.BinanceExchange, Symbol: domain.BTCUSDT, Price: decimal.NewFromInt(90), Date: date.Add(time.Minute),
+	}}}
+	calculator := NewSpreadCalculator(storage, storage)
+	if err := calculator.HandlePriceChanges(context.Background(), []domain.PriceChange{bybit}); err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.spreads) != 1 {
+		t.Fatalf("got %d spreads, want 1", len(storage.spreads))
+	}
+	spread := storage.spreads[0]
+	if spread.BuyExchange != domain.BinanceExchange || spread.SellExchange != domain.BybitExchange || !spread.Date.Equal(date) {
+		t.Errorf("got spread %+v", spread)
+	}
+}
@@ -2,62 +2,284 @@ package calculation
 
 import (
 	"context"
-	"fmt"
+	"slices"
 	"time"
 
 	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/indicator"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/level"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/pattern"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage/cache"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
 	"github.com/sdcoffey/big"
 	"github.com/sdcoffey/techan"
+	"go.uber.org/zap"
 )
 
+const (
+	ShortMAWindow = 8
+	LongMAWindow  = 40
+	// DefaultIndicatorWindow is window of EMA, WMA, SMA, RingLow and RingHigh.
+	DefaultIndicatorWindow = 20
+)
+
+// TechAnalysis calculates indicator snapshot on the last closed candlestick of every watchlist symbol and interval
+// and values of indicator instances. Indicators of snapshot are zero when there are not enough candlesticks for their window.
 type TechAnalysis struct {
 	candlestickLoader domain.CandlestickLoader
+	storage           domain.IndicatorStorage
+	watchlist         domain.WatchlistLoader
+	registry          *indicator.Registry
+
+	instances domain.IndicatorInstanceStorage
+	values    domain.IndicatorValueStorage
+	levels    domain.LevelStorage
+}
+
+func NewTechAnalysis(
+	candlestickLoader domain.CandlestickLoader,
+	storage domain.IndicatorStorage,
+	watchlist domain.WatchlistLoader,
+) *TechAnalysis {
+	return &TechAnalysis{
+		candlestickLoader: candlestickLoader,
+		storage:           storage,
+		watchlist:         watchlist,
+		registry:          indicator.Default,
+	}
+}
+
+// WithInstances enables calculation of indicator instances, their values are saved in values storage.
+func (ta *TechAnalysis) WithInstances(instances domain.IndicatorInstanceStorage, values domain.IndicatorValueStorage) {
+	ta.instances = instances
+	ta.values = values
 }
 
-func NewTechAnalysis(candlestickLoader domain.CandlestickLoader) *TechAnalysis {
-	return &TechAnalysis{candlestickLoader: candlestickLoader}
+// WithLevels enables RingLow and RingHigh of snapshot from the nearest levels of its series.
+func (ta *TechAnalysis) WithLevels(levels domain.LevelStorage) {
+	ta.levels = levels
 }
 
-func (ta *TechAnalysis) Run(ctx context.Context) error {
-	ticker := time.NewTicker(time.Minute)
+func (ta *TechAnalysis) Run(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			if err := ta.loadCandlesticks(ctx); err != nil {
-				return err
+			if err := ta.calculate(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				zap.L().Error("error calculate indicators", zap.Error(err))
 			}
 		}
 	}
 }
 
-func (ta *TechAnalysis) loadCandlesticks(ctx context.Context) error {
-	series := techan.NewTimeSeries()
-	candles, err := ta.candlestickLoader.Candlesticks(ctx, domain.BinanceExchange, "BTCUSDT", time.Now().Add(-24*time.Hour), time.Now())
+func (ta *TechAnalysis) calculate(ctx context.Context) error {
+	start := time.Now()
+	items, err := ta.watchlist.Watchlist(ctx)
 	if err != nil {
-		return err
+		return errors.Wrap(err, "load watchlist")
 	}
-	if len(candles) == 0 {
-		return nil
+	var snapshots []domain.IndicatorSnapshot
+	for _, item := range items {
+		for _, interval := range item.Intervals {
+			snapshot, ok, err := ta.snapshot(ctx, item.Exchange, item.Symbol, interval)
+			if err != nil {
+				zap.L().Error(
+					"error calculate indicator snapshot",
+					zap.String("exchange", item.Exchange),
+					zap.String("symbol", item.Symbol),
+					zap.Stringer("interval", interval),
+					zap.Error(err),
+				)
+				continue
+			}
+			if ok {
+				snapshots = append(snapshots, snapshot)
+			}
+		}
 	}
-	for _, item := range candles {
-		if item.Interval != domain.OneHourInterval {
-			continue
+	errSave := backoff.Retry(func() error {
+		return ta.storage.SaveIndicators(ctx, snapshots)
+	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+	if errSave != nil {
+		return errors.Wrap(errSave, "save indicators")
+	}
+	metric.IndicatorSnapshots.Add(float64(len(snapshots)))
+	if ta.instances != nil {
+		values, err := ta.calculateInstances(ctx)
+		if err != nil {
+			return err
 		}
-		candle := techan.NewCandle(techan.NewTimePeriod(item.OpenTime, time.Hour))
-		candle.OpenPrice = big.NewDecimal(item.OpenPrice)
-		candle.ClosePrice = big.NewDecimal(item.ClosePrice)
-		candle.MaxPrice = big.NewDecimal(item.HighPrice)
-		candle.MinPrice = big.NewDecimal(item.LowPrice)
-		series.AddCandle(candle)
+		errSave := backoff.Retry(func() error {
+			return ta.values.SaveIndicatorValues(ctx, values)
+		}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+		if errSave != nil {
+			return errors.Wrap(errSave, "save indicator values")
+		}
+		metric.IndicatorValues.Add(float64(len(values)))
 	}
-	closePrices := techan.NewClosePriceIndicator(series)
-	movingAverage := techan.NewEMAIndicator(closePrices, 10) // Create an exponential moving average with a window of 10
-	trendlineIndicator := techan.NewTrendlineIndicator(closePrices, 10)
-	fmt.Println("movingAverage", movingAverage.Calculate(0).FormattedString(2))
-	fmt.Println("trendlineIndicator", trendlineIndicator.Calculate(10))
+	metric.IndicatorDuration.Add(float64(time.Since(start).Milliseconds()))
+	return nil
+}
 
+// snapshot calculates indicators of series, ok is false when series has no closed candlesticks.
+func (ta *TechAnalysis) snapshot(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) (domain.IndicatorSnapshot, bool, error) {
+	series, last, err := ta.series(ctx, exchange, symbol, interval, LongMAWindow)
+	if err != nil {
+		return domain.IndicatorSnapshot{}, false, err
+	}
+	if len(series.Candles) == 0 {
+		return domain.IndicatorSnapshot{}, false, nil
+	}
+	window := float64(DefaultIndicatorWindow)
+	ringLow := techan.NewMinimumValueIndicator(techan.NewLowPriceIndicator(series), DefaultIndicatorWindow)
+	ringHigh := techan.NewMaximumValueIndicator(techan.NewHighPriceIndicator(series), DefaultIndicatorWindow)
+	snapshot := domain.IndicatorSnapshot{
+		Exchange:  exchange,
+		Symbol:    symbol,
+		Interval:  interval,
+		OpenTime:  last.OpenTime,
+		MA8:       ta.value(series, indicator.SMA, ShortMAWindow),
+		MA40:      ta.value(series, indicator.SMA, LongMAWindow),
+		EMA:       ta.value(series, indicator.EMA, window),
+		WMA:       ta.value(series, indicator.WMA, window),
+		SMA:       ta.value(series, indicator.SMA, window),
+		PinBar:    pattern.PinBar(last),
+		CreatedAt: time.Now(),
+	}
+	if len(series.Candles) >= DefaultIndicatorWindow {
+		snapshot.RingLow = ringLow.Calculate(series.LastIndex()).Float()
+		snapshot.RingHigh = ringHigh.Calculate(series.LastIndex()).Float()
+	}
+	if ta.levels != nil {
+		if err := ta.ringLevels(ctx, &snapshot, domain.PriceFloat(last.ClosePrice)); err != nil {
+			return domain.IndicatorSnapshot{}, false, err
+		}
+	}
+	return snapshot, true, nil
+}
+
+// ringLevels replaces RingLow and RingHigh of snapshot by edges of the nearest levels around price.
+func (ta *TechAnalysis) ringLevels(ctx context.Context, snapshot *domain.IndicatorSnapshot, price float64) error {
+	levels, err := ta.levels.Levels(ctx, snapshot.Exchange, snapshot.Symbol)
+	if err != nil {
+		return errors.Wrap(err, "load levels")
+	}
+	levels = slices.DeleteFunc(levels, func(item domain.Level) bool { return item.Interval != snapshot.Interval })
+	below, okBelow, above, okAbove := level.Nearest(levels, price)
+	if okBelow {
+		snapshot.RingLow = below.High
+	}
+	if okAbove {
+		snapshot.RingHigh = above.Low
+	}
 	return nil
 }
+
+// value returns moving average of registry, zero is returned when series is shorter than window.
+func (ta *TechAnalysis) value(series *techan.TimeSeries, name string, window float64) float64 {
+	values, err := ta.registry.Calculate(series, name, map[string]float64{"window": window})
+	if err != nil {
+		return 0
+	}
+	return values[indicator.OutputValue]
+}
+
+// calculateInstances calculates values of indicator instances on the last closed candlestick of their series.
+func (ta *TechAnalysis) calculateInstances(ctx context.Context) ([]domain.IndicatorValue, error) {
+	instances, err := ta.instances.IndicatorInstances(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "load indicator instances")
+	}
+	type seriesKey struct {
+		exchange, symbol string
+		interval         domain.Interval
+	}
+	bySeries := make(map[seriesKey][]domain.IndicatorInstance)
+	for _, item := range instances {
+		key := seriesKey{exchange: item.Exchange, symbol: item.Symbol, interval: item.Interval}
+		bySeries[key] = append(bySeries[key], item)
+	}
+	var values []domain.IndicatorValue
+	for key, items := range bySeries {
+		lookback := 0
+		for _, item := range items {
+			itemLookback, err := ta.registry.Lookback(item.Name, item.Params)
+			if err != nil {
+				return nil, errors.Wrapf(err, "indicator instance %s", item.ID)
+			}
+			lookback = max(lookback, itemLookback)
+		}
+		series, last, err := ta.series(ctx, key.exchange, key.symbol, key.interval, lookback)
+		if err != nil {
+			zap.L().Error(
+				"error load series of indicator instances",
+				zap.String("exchange", key.exchange),
+				zap.String("symbol", key.symbol),
+				zap.Stringer("interval", key.interval),
+				zap.Error(err),
+			)
+			continue
+		}
+		for _, item := range items {
+			outputs, err := ta.registry.Calculate(series, item.Name, item.Params)
+			if errors.Is(err, indicator.ErrNotEnoughData) {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "indicator instance %s", item.ID)
+			}
+			values = append(values, domain.IndicatorValue{
+				InstanceID: item.ID,
+				OpenTime:   last.OpenTime,
+				Values:     outputs,
+				CreatedAt:  time.Now(),
+			})
+		}
+	}
+	return values, nil
+}
+
+// series returns closed candlesticks of series, at least lookback of them when they are stored,
+// and the last closed candlestick.
+func (ta *TechAnalysis) series(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, lookback int,
+) (*techan.TimeSeries, dto.Candlestick, error) {
+	now := time.Now()
+	// Window which fits cached series is loaded from memory, the last cached candlestick can be not closed yet.
+	count := max(lookback, cache.LengthCandlestickData-1)
+	candles, err := ta.candlestickLoader.IntervalCandlesticks(
+		ctx, exchange, symbol, interval, interval.Truncate(now).Add(-time.Duration(count)*interval.Duration()), now,
+	)
+	if err != nil {
+		return nil, dto.Candlestick{}, errors.Wrap(err, "load candlesticks")
+	}
+	series := techan.NewTimeSeries()
+	var last dto.Candlestick
+	for _, item := range candles {
+		if item.CloseTime.After(now) {
+			continue
+		}
+		candle := techan.NewCandle(techan.NewTimePeriod(item.OpenTime, interval.Duration()))
+		candle.OpenPrice = big.NewFromString(item.OpenPrice.String())
+		candle.ClosePrice = big.NewFromString(item.ClosePrice.String())
+		candle.MaxPrice = big.NewFromString(item.HighPrice.String())
+		candle.MinPrice = big.NewFromString(item.LowPrice.String())
+		candle.Volume = big.NewFromString(item.Volume.String())
+		candle.TradeCount = uint(item.NumberTrades)
+		if series.AddCandle(candle) {
+			last = item
+		}
+	}
+	return series, last, nil
+}
@@ -1,13 +1,259 @@
 package cleaner
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
-type Cleaner struct{}
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"go.uber.org/zap"
+)
 
-func NewCleaner() *Cleaner {
-	return &Cleaner{}
+const (
+	DefaultBatchSize = 5000
+	DefaultPause     = 100 * time.Millisecond
+)
+
+// Config of retention, Tables are policies by name of table.
+type Config struct {
+	// Period of deletion of old rows.
+	Period time.Duration `mapstructure:"period"`
+	// BatchSize is number of rows deleted by one statement, Pause is delay between statements.
+	BatchSize int           `mapstructure:"batch_size"`
+	Pause     time.Duration `mapstructure:"pause"`
+	// DryRun counts and logs rows which would be deleted without deletion.
+	DryRun bool              `mapstructure:"dry_run"`
+	Tables map[string]Policy `mapstructure:"tables"`
+}
+
+// Policy deletes rows older than Age and rows beyond MaxRows newest ones, zero value disables limit.
+// Symbols override policy for rows of symbol, other rows are limited by policy of table. MaxRows is not per symbol:
+// MaxRows of table limits rows of all symbols without override together, MaxRows of override limits rows of its symbol.
+type Policy struct {
+	Age     time.Duration     `mapstructure:"age"`
+	MaxRows int64             `mapstructure:"max_rows"`
+	Symbols map[string]Policy `mapstructure:"symbols"`
+}
+
+func (p Policy) disabled() bool {
+	return p.Age <= 0 && p.MaxRows <= 0
+}
+
+func (c Config) Validate() error {
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("retention.batch_size must be positive, got %d", c.BatchSize)
+	}
+	if c.Pause < 0 {
+		return fmt.Errorf("retention.pause must not be negative, got %s", c.Pause)
+	}
+	for table, policy := range c.Tables {
+		if err := policy.validate("retention.tables." + table); err != nil {
+			return err
+		}
+		for symbol, override := range policy.Symbols {
+			if len(override.Symbols) > 0 {
+				return fmt.Errorf("retention.tables.%s.symbols.%s: nested symbols are not supported", table, symbol)
+			}
+			if err := override.validate("retention.tables." + table + ".symbols." + symbol); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
-func (c *Cleaner) Run(ctx context.Context) error {
+func (p Policy) validate(key string) error {
+	if p.Age < 0 {
+		return fmt.Errorf("%s.age must not be negative, got %s", key, p.Age)
+	}
+	if p.MaxRows < 0 {
+		return fmt.Errorf("%s.max_rows must not be negative, got %d", key, p.MaxRows)
+	}
 	return nil
 }
+
+// rule is policy applied to scope of table.
+type rule struct {
+	scope  domain.RetentionScope
+	policy Policy
+}
+
+// Cleaner deletes rows of tables by retention policies in batches.
+type Cleaner struct {
+	storage   domain.RetentionStorage
+	rules     []rule
+	guards    map[string]domain.RetentionGuard
+	batchSize int
+	pause     time.Duration
+	dryRun    bool
+}
+
+// NewCleaner creates cleaner of tables, tables must be known by storage and symbol overrides
+// are allowed only for tables with symbol.
+func NewCleaner(storage domain.RetentionStorage, tables map[string]Policy) (*Cleaner, error) {
+	names := make([]string, 0, len(tables))
+	for table := range tables {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+	c := &Cleaner{storage: storage, batchSize: DefaultBatchSize, pause: DefaultPause}
+	for _, table := range names {
+		policy := tables[table]
+		known, bySymbol := storage.RetentionTable(table)
+		if !known {
+			return nil, fmt.Errorf("retention: unknown table %s", table)
+		}
+		if len(policy.Symbols) > 0 && !bySymbol {
+			return nil, fmt.Errorf("retention: table %s has no symbol, symbols are not supported", table)
+		}
+		symbols := make([]string, 0, len(policy.Symbols))
+		for symbol, override := range policy.Symbols {
+			// Keys of config are case insensitive, symbols are upper case.
+			symbol = strings.ToUpper(symbol)
+			symbols = append(symbols, symbol)
+			c.rules = append(c.rules, rule{
+				scope:  domain.RetentionScope{Table: table, Symbol: symbol},
+				policy: override,
+			})
+		}
+		sort.Strings(symbols)
+		c.rules = append(c.rules, rule{
+			scope:  domain.RetentionScope{Table: table, ExceptSymbols: symbols},
+			policy: policy,
+		})
+	}
+	return c, nil
+}
+
+func (c *Cleaner) WithBatch(size int, pause time.Duration) {
+	if size > 0 {
+		c.batchSize = size
+	}
+	if pause >= 0 {
+		c.pause = pause
+	}
+}
+
+// WithGuard keeps rows of table which are still needed by guard whatever policy of table is.
+func (c *Cleaner) WithGuard(table string, guard domain.RetentionGuard) {
+	if c.guards == nil {
+		c.guards = make(map[string]domain.RetentionGuard)
+	}
+	c.guards[table] = guard
+}
+
+func (c *Cleaner) WithDryRun(dryRun bool) {
+	c.dryRun = dryRun
+}
+
+func (c *Cleaner) Run(ctx context.Context, d time.Duration) error {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		c.Clean(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Clean applies every policy once, error of policy is logged and does not stop others.
+func (c *Cleaner) Clean(ctx context.Context) {
+	for _, item := range c.rules {
+		if item.policy.disabled() {
+			continue
+		}
+		start := time.Now()
+		rows, err := c.apply(ctx, item)
+		metric.RetentionDuration.WithLabelValues(item.scope.Table).Add(float64(time.Since(start).Milliseconds()))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			zap.L().Error(
+				"error apply retention policy",
+				zap.String("table", item.scope.Table),
+				zap.String("symbol", item.scope.Symbol),
+				zap.Error(err),
+			)
+			continue
+		}
+		if rows > 0 {
+			zap.L().Info(
+				"retention policy applied",
+				zap.String("table", item.scope.Table),
+				zap.String("symbol", item.scope.Symbol),
+				zap.Int64("rows", rows),
+				zap.Bool("dry_run", c.dryRun),
+			)
+		}
+	}
+}
+
+// apply deletes rows of rule and returns their number, rows are only counted in dry run.
+func (c *Cleaner) apply(ctx context.Context, item rule) (int64, error) {
+	before, err := c.before(ctx, item)
+	if err != nil || before.IsZero() {
+		return 0, err
+	}
+	if c.dryRun {
+		rows, err := c.storage.CountRows(ctx, item.scope, before)
+		if err != nil {
+			return 0, err
+		}
+		metric.RetentionRows.WithLabelValues(item.scope.Table, "dry_run").Add(float64(rows))
+		return rows, nil
+	}
+	var total int64
+	for {
+		rows, err := c.storage.DeleteRows(ctx, item.scope, before, c.batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += rows
+		metric.RetentionRows.WithLabelValues(item.scope.Table, "deleted").Add(float64(rows))
+		if rows < int64(c.batchSize) {
+			return total, nil
+		}
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(c.pause):
+		}
+	}
+}
+
+// before returns time which older rows are deleted, it is the latest of age limit and rows limit
+// but not later than time retained by guard of table. Zero time means there is nothing to delete.
+func (c *Cleaner) before(ctx context.Context, item rule) (time.Time, error) {
+	var before time.Time
+	if item.policy.Age > 0 {
+		before = time.Now().Add(-item.policy.Age)
+	}
+	if item.policy.MaxRows > 0 {
+		capTime, err := c.storage.CapTime(ctx, item.scope, item.policy.MaxRows)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if capTime.After(before) {
+			before = capTime
+		}
+	}
+	guard, has := c.guards[item.scope.Table]
+	if !has || before.IsZero() {
+		return before, nil
+	}
+	retained, err := guard.RetainedSince(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if retained.Before(before) {
+		before = retained
+	}
+	return before, nil
+}
@@ -0,0 +1,208 @@
+package cleaner
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+)
+
+type retentionRow struct {
+	symbol string
+	time   time.Time
+}
+
+// retentionMemory is table prices with symbol, calls of DeleteRows are counted.
+type retentionMemory struct {
+	rows    []retentionRow
+	deletes int
+}
+
+func (m *retentionMemory) RetentionTable(table string) (bool, bool) {
+	return table == "prices", true
+}
+
+func (m *retentionMemory) matches(scope domain.RetentionScope, row retentionRow) bool {
+	if scope.Symbol != "" {
+		return row.symbol == scope.Symbol
+	}
+	return !slices.Contains(scope.ExceptSymbols, row.symbol)
+}
+
+func (m *retentionMemory) CountRows(_ context.Context, scope domain.RetentionScope, before time.Time) (int64, error) {
+	var count int64
+	for _, row := range m.rows {
+		if m.matches(scope, row) && row.time.Before(before) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *retentionMemory) DeleteRows(
+	_ context.Context, scope domain.RetentionScope, before time.Time, limit int,
+) (int64, error) {
+	m.deletes++
+	var (
+		kept    []retentionRow
+		deleted int64
+	)
+	for _, row := range m.rows {
+		if deleted < int64(limit) && m.matches(scope, row) && row.time.Before(before) {
+			deleted++
+			continue
+		}
+		kept = append(kept, row)
+	}
+	m.rows = kept
+	return deleted, nil
+}
+
+func (m *retentionMemory) CapTime(_ context.Context, scope domain.RetentionScope, maxRows int64) (time.Time, error) {
+	var times []time.Time
+	for _, row := range m.rows {
+		if m.matches(scope, row) {
+			times = append(times, row.time)
+		}
+	}
+	if int64(len(times)) < maxRows {
+		return time.Time{}, nil
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].After(times[j]) })
+	return times[maxRows-1], nil
+}
+
+type retainedSince time.Time
+
+func (g retainedSince) RetainedSince(context.Context) (time.Time, error) {
+	return time.Time(g), nil
+}
+
+// hourlyRows returns rows of symbol opened every hour till now, the newest first.
+func hourlyRows(symbol string, count int) []retentionRow {
+	now := time.Now().Truncate(time.Hour)
+	rows := make([]retentionRow, 0, count)
+	for i := 0; i < count; i++ {
+		rows = append(rows, retentionRow{symbol: symbol, time: now.Add(-time.Duration(i) * time.Hour)})
+	}
+	return rows
+}
+
+func TestCleanerBefore(t *testing.T) {
+	storage := &retentionMemory{rows: hourlyRows("BTCUSDT", 10)}
+	capTime := storage.rows[4].time
+	scope := domain.RetentionScope{Table: "prices"}
+	for name, item := range map[string]struct {
+		policy Policy
+		guard  *time.Time
+		want   time.Time
+		// ageLimit is set when age limit is expected, it depends on current time.
+		ageLimit bool
+	}{
+		"no limits": {},
+		"cap is later than age": {
+			policy: Policy{Age: 24 * time.Hour, MaxRows: 5},
+			want:   capTime,
+		},
+		"age is later than cap": {
+			policy:   Policy{Age: 2 * time.Hour, MaxRows: 5},
+			ageLimit: true,
+		},
+		"fewer rows than cap": {
+			policy: Policy{MaxRows: 20},
+		},
+		"guard keeps rows": {
+			policy: Policy{MaxRows: 5},
+			guard:  &storage.rows[7].time,
+			want:   storage.rows[7].time,
+		},
+		"guard is later than limits": {
+			policy: Policy{MaxRows: 5},
+			guard:  &storage.rows[0].time,
+			want:   capTime,
+		},
+	} {
+		c, err := NewCleaner(storage, map[string]Policy{"prices": item.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.guard != nil {
+			c.WithGuard("prices", retainedSince(*item.guard))
+		}
+		start := time.Now()
+		got, err := c.before(context.Background(), rule{scope: scope, policy: item.policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.ageLimit {
+			if got.Before(start.Add(-item.policy.Age)) || got.After(time.Now().Add(-item.policy.Age)) {
+				t.Errorf("%s: got %s, want age limit %s", name, got, start.Add(-item.policy.Age))
+			}
+			continue
+		}
+		if !got.Equal(item.want) {
+			t.Errorf("%s: got %s, want %s", name, got, item.want)
+		}
+	}
+}
+
+func TestCleanerDryRun(t *testing.T) {
+	storage := &retentionMemory{rows: hourlyRows("BTCUSDT", 10)}
+	c, err := NewCleaner(storage, map[string]Policy{"prices": {MaxRows: 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.WithDryRun(true)
+	rows, err := c.apply(context.Background(), c.rules[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 6 || len(storage.rows) != 10 || storage.deletes != 0 {
+		t.Errorf("got %d rows counted, %d rows kept, %d deletes, want 6, 10 and 0", rows, len(storage.rows), storage.deletes)
+	}
+}
+
+func TestCleanerDeletesInBatches(t *testing.T) {
+	for total, deletes := range map[int]int{12: 3, 10: 3, 3: 1} {
+		storage := &retentionMemory{rows: hourlyRows("BTCUSDT", total+2)}
+		c, err := NewCleaner(storage, map[string]Policy{"prices": {MaxRows: 2}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.WithBatch(5, 0)
+		rows, err := c.apply(context.Background(), c.rules[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rows != int64(total) || len(storage.rows) != 2 {
+			t.Errorf("%d rows: got %d deleted and %d kept, want %d and 2", total, rows, len(storage.rows), total)
+		}
+		// Batch shorter than size finishes deletion.
+		if storage.deletes != deletes {
+			t.Errorf("%d rows: got %d deletes, want %d", total, storage.deletes, deletes)
+		}
+	}
+}
+
+func TestCleanerSymbolOverride(t *testing.T) {
+	storage := &retentionMemory{rows: append(hourlyRows("BTCUSDT", 10), hourlyRows("ETHUSDT", 10)...)}
+	c, err := NewCleaner(storage, map[string]Policy{"prices": {
+		MaxRows: 3,
+		Symbols: map[string]Policy{"btcusdt": {MaxRows: 8}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.WithBatch(100, 0)
+	c.Clean(context.Background())
+	count := make(map[string]int)
+	for _, row := range storage.rows {
+		count[row.symbol]++
+	}
+	if count["BTCUSDT"] != 8 || count["ETHUSDT"] != 3 {
+		t.Errorf("got rows %v, want 8 of BTCUSDT and 3 of ETHUSDT", count)
+	}
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// DefaultAlertAge is age of alerts returned when request has no since param.
+const DefaultAlertAge = 24 * time.Hour
+
+type Alert struct {
+	rules  domain.AlertRuleStorage
+	alerts domain.AlertStorage
+}
+
+func NewAlert(rules domain.AlertRuleStorage, alerts domain.AlertStorage) *Alert {
+	return &Alert{rules: rules, alerts: alerts}
+}
+
+func (app *Alert) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/alerts", app.ruleList)
+	e.POST("/alerts", app.createRule)
+	e.GET("/alerts/history", app.history)
+	e.GET("/alerts/:id", app.rule)
+	e.PUT("/alerts/:id", app.updateRule)
+	e.DELETE("/alerts/:id", app.deleteRule)
+}
+
+type alertRuleRequest struct {
+	Name        string               `json:"name"`
+	Kind        domain.AlertKind     `json:"kind"`
+	Exchange    string               `json:"exchange"`
+	Symbol      string               `json:"symbol"`
+	Operator    domain.AlertOperator `json:"operator"`
+	Threshold   float64              `json:"threshold"`
+	IndicatorID string               `json:"indicator_id"`
+	Output      string               `json:"output"`
+	CooldownSec int64                `json:"cooldown_sec"`
+	Hysteresis  float64              `json:"hysteresis"`
+	Enabled     *bool                `json:"enabled"`
+}
+
+func (req alertRuleRequest) rule() domain.AlertRule {
+	rule := domain.AlertRule{
+		Name:        req.Name,
+		Kind:        req.Kind,
+		Exchange:    req.Exchange,
+		Symbol:      req.Symbol,
+		Operator:    req.Operator,
+		Threshold:   req.Threshold,
+		IndicatorID: req.IndicatorID,
+		Output:      req.Output,
+		CooldownSec: req.CooldownSec,
+		Hysteresis:  req.Hysteresis,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	return rule.Normalize()
+}
+
+func (app *Alert) ruleList(c echo.Context) error {
+	rules, err := app.rules.AlertRules(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+func (app *Alert) rule(c echo.Context) error {
+	id, err := alertRuleID(c)
+	if err != nil {
+		return err
+	}
+	rule, err := app.rules.AlertRule(c.Request().Context(), id)
+	if err != nil {
+		return alertRuleError(err)
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+func (app *Alert) createRule(c echo.Context) error {
+	var req alertRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	rule := req.rule()
+	if err := rule.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	rule, err := app.rules.SaveAlertRule(c.Request().Context(), rule)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, rule)
+}
+
+func (app *Alert) updateRule(c echo.Context) error {
+	id, err := alertRuleID(c)
+	if err != nil {
+		return err
+	}
+	var req alertRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	rule := req.rule()
+	rule.ID = id
+	if err := rule.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	rule, err = app.rules.SaveAlertRule(c.Request().Context(), rule)
+	if err != nil {
+		return alertRuleError(err)
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+func (app *Alert) deleteRule(c echo.Context) error {
+	id, err := alertRuleID(c)
+	if err != nil {
+		return err
+	}
+	if err := app.rules.DeleteAlertRule(c.Request().Context(), id); err != nil {
+		return alertRuleError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (app *Alert) history(c echo.Context) error {
+	since, err := parseSince(c.QueryParam("since"), DefaultAlertAge)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	filter := domain.AlertFilter{Since: since}
+	if param := c.QueryParam("rule_id"); param != "" {
+		if filter.RuleID, err = strconv.ParseInt(param, 10, 64); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "rule_id").Error())
+		}
+	}
+	if param := c.QueryParam("limit"); param != "" {
+		if filter.Limit, err = strconv.Atoi(param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "limit").Error())
+		}
+	}
+	alerts, err := app.alerts.Alerts(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, alerts)
+}
+
+func alertRuleID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid alert rule id")
+	}
+	return id, nil
+}
+
+func alertRuleError(err error) error {
+	if errors.Is(err, domain.ErrAlertRuleNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return err
+}
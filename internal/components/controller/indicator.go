@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/indicator"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+type Indicator struct {
+	storage   domain.IndicatorStorage
+	registry  *indicator.Registry
+	instances domain.IndicatorInstanceStorage
+	values    domain.IndicatorValueStorage
+}
+
+func NewIndicator(
+	storage domain.IndicatorStorage,
+	registry *indicator.Registry,
+	instances domain.IndicatorInstanceStorage,
+	values domain.IndicatorValueStorage,
+) *Indicator {
+	return &Indicator{storage: storage, registry: registry, instances: instances, values: values}
+}
+
+func (app *Indicator) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/indicators/registry", app.registryList)
+	e.GET("/indicators/instances", app.instanceList)
+	e.POST("/indicators/instances", app.createInstance)
+	e.GET("/indicators/instances/:id", app.instance)
+	e.DELETE("/indicators/instances/:id", app.deleteInstance)
+	e.GET("/indicators/:exchange/:symbol/:interval", app.last)
+}
+
+type indicatorInstanceRequest struct {
+	Name     string             `json:"name"`
+	Exchange string             `json:"exchange"`
+	Symbol   string             `json:"symbol"`
+	Interval domain.Interval    `json:"interval"`
+	Params   map[string]float64 `json:"params"`
+}
+
+type indicatorInstanceResponse struct {
+	Instance domain.IndicatorInstance `json:"instance"`
+	Value    *domain.IndicatorValue   `json:"value"`
+}
+
+func (app *Indicator) last(c echo.Context) error {
+	interval, err := domain.ParseInterval(c.Param("interval"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	snapshot, err := app.storage.LastIndicator(
+		c.Request().Context(), strings.ToLower(c.Param("exchange")), strings.ToUpper(c.Param("symbol")), interval,
+	)
+	if errors.Is(err, domain.ErrIndicatorNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, snapshot)
+}
+
+func (app *Indicator) registryList(c echo.Context) error {
+	return c.JSON(http.StatusOK, app.registry.Definitions())
+}
+
+func (app *Indicator) instanceList(c echo.Context) error {
+	instances, err := app.instances.IndicatorInstances(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, instances)
+}
+
+func (app *Indicator) instance(c echo.Context) error {
+	instance, found, err := app.findInstance(c, c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return echo.NewHTTPError(http.StatusNotFound, domain.ErrIndicatorNotFound.Error())
+	}
+	resp := indicatorInstanceResponse{Instance: instance}
+	value, err := app.values.LastIndicatorValue(c.Request().Context(), instance.ID)
+	switch {
+	case err == nil:
+		resp.Value = &value
+	case !errors.Is(err, domain.ErrIndicatorNotFound):
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (app *Indicator) createInstance(c echo.Context) error {
+	var req indicatorInstanceRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	instance, err := app.registry.Instance(domain.IndicatorInstance{
+		Name:      req.Name,
+		Exchange:  req.Exchange,
+		Symbol:    req.Symbol,
+		Interval:  req.Interval,
+		Params:    req.Params,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	_, found, err := app.findInstance(c, instance.ID)
+	if err != nil {
+		return err
+	}
+	if found {
+		return echo.NewHTTPError(http.StatusConflict, "indicator instance already exists")
+	}
+	if err := app.instances.SaveIndicatorInstance(c.Request().Context(), instance); err != nil {
+		return indicatorInstanceError(err)
+	}
+	return c.JSON(http.StatusCreated, instance)
+}
+
+func (app *Indicator) deleteInstance(c echo.Context) error {
+	if err := app.instances.DeleteIndicatorInstance(c.Request().Context(), c.Param("id")); err != nil {
+		return indicatorInstanceError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (app *Indicator) findInstance(c echo.Context, id string) (domain.IndicatorInstance, bool, error) {
+	instances, err := app.instances.IndicatorInstances(c.Request().Context())
+	if err != nil {
+		return domain.IndicatorInstance{}, false, err
+	}
+	for _, item := range instances {
+		if item.ID == id {
+			return item, true, nil
+		}
+	}
+	return domain.IndicatorInstance{}, false, nil
+}
+
+func indicatorInstanceError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrIndicatorNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrIndicatorInstanceReadOnly):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	default:
+		return err
+	}
+}
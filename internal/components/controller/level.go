@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultLevelEventAge is age of level events returned when request has no since param.
+const DefaultLevelEventAge = 24 * time.Hour
+
+type Level struct {
+	levels domain.LevelStorage
+	events domain.LevelEventStorage
+}
+
+func NewLevel(levels domain.LevelStorage, events domain.LevelEventStorage) *Level {
+	return &Level{levels: levels, events: events}
+}
+
+func (app *Level) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/levels/:exchange/:symbol", app.list)
+	e.GET("/levels/:exchange/:symbol/events", app.eventList)
+}
+
+func (app *Level) list(c echo.Context) error {
+	levels, err := app.levels.Levels(
+		c.Request().Context(), strings.ToLower(c.Param("exchange")), strings.ToUpper(c.Param("symbol")),
+	)
+	if err != nil {
+		return err
+	}
+	if param := c.QueryParam("interval"); param != "" {
+		interval, err := domain.ParseInterval(param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		levels = slices.DeleteFunc(levels, func(item domain.Level) bool { return item.Interval != interval })
+	}
+	if kind := domain.LevelKind(strings.ToLower(c.QueryParam("kind"))); kind != "" {
+		levels = slices.DeleteFunc(levels, func(item domain.Level) bool { return item.Kind != kind })
+	}
+	return c.JSON(http.StatusOK, levels)
+}
+
+func (app *Level) eventList(c echo.Context) error {
+	since, err := parseSince(c.QueryParam("since"), DefaultLevelEventAge)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	events, err := app.events.LevelEvents(
+		c.Request().Context(), strings.ToLower(c.Param("exchange")), strings.ToUpper(c.Param("symbol")), since,
+	)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultListingLimit is page size of listings feed when request has no limit param.
+	DefaultListingLimit = 100
+	// MaxListingLimit is the largest page of listings feed.
+	MaxListingLimit = 1000
+)
+
+type Listing struct {
+	listings domain.ListingStorage
+}
+
+func NewListing(listings domain.ListingStorage) *Listing {
+	return &Listing{listings: listings}
+}
+
+func (app *Listing) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/listings", app.list)
+}
+
+type listingPage struct {
+	Listings []domain.Listing `json:"listings"`
+	Total    int              `json:"total"`
+	Limit    int              `json:"limit"`
+	Offset   int              `json:"offset"`
+}
+
+// list returns page of listings, the latest first. Filters: exchange, symbol, cross (true or false)
+// and since (duration or RFC3339, all listings by default); pagination: limit and offset.
+func (app *Listing) list(c echo.Context) error {
+	filter := domain.ListingFilter{
+		Exchange: strings.ToLower(c.QueryParam("exchange")),
+		Symbol:   strings.ToUpper(c.QueryParam("symbol")),
+		Limit:    DefaultListingLimit,
+	}
+	var err error
+	if param := c.QueryParam("since"); param != "" {
+		if filter.Since, err = parseSince(param, 0); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+	if param := c.QueryParam("cross"); param != "" {
+		cross, err := strconv.ParseBool(param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "cross").Error())
+		}
+		filter.CrossListed = &cross
+	}
+	if param := c.QueryParam("limit"); param != "" {
+		if filter.Limit, err = strconv.Atoi(param); err != nil || filter.Limit <= 0 || filter.Limit > MaxListingLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be in [1, "+strconv.Itoa(MaxListingLimit)+"]")
+		}
+	}
+	if param := c.QueryParam("offset"); param != "" {
+		if filter.Offset, err = strconv.Atoi(param); err != nil || filter.Offset < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "offset must be non-negative number")
+		}
+	}
+	listings, total, err := app.listings.Listings(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	if listings == nil {
+		listings = []domain.Listing{}
+	}
+	return c.JSON(http.StatusOK, listingPage{Listings: listings, Total: total, Limit: filter.Limit, Offset: filter.Offset})
+}
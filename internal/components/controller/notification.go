@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultDeadLetterAge is age of dead letters returned when request has no since param.
+const DefaultDeadLetterAge = 7 * 24 * time.Hour
+
+type Notification struct {
+	deadLetters domain.DeadLetterStorage
+}
+
+func NewNotification(deadLetters domain.DeadLetterStorage) *Notification {
+	return &Notification{deadLetters: deadLetters}
+}
+
+func (app *Notification) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/notifications/dead_letters", app.deadLetterList)
+}
+
+func (app *Notification) deadLetterList(c echo.Context) error {
+	since, err := parseSince(c.QueryParam("since"), DefaultDeadLetterAge)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	letters, err := app.deadLetters.DeadLetters(c.Request().Context(), since)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, letters)
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/controller/templates"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// DefaultPatternAge is age of candlesticks which patterns are fresh when filter has no since param.
+const DefaultPatternAge = 24 * time.Hour
+
+type Pattern struct {
+	storage domain.PatternStorage
+}
+
+func NewPattern(storage domain.PatternStorage) *Pattern {
+	return &Pattern{storage: storage}
+}
+
+func (app *Pattern) RegistrationPageRoute(e *echo.Group) {
+	e.GET("/patterns", app.page)
+}
+
+func (app *Pattern) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/patterns", app.list)
+	e.GET("/patterns/:exchange/:symbol", app.symbol)
+}
+
+type patternPageData struct {
+	Filter    patternQuery
+	Patterns  []domain.CandlestickPattern
+	Kinds     []domain.Pattern
+	Intervals []domain.Interval
+}
+
+// patternQuery is filter of request as it is shown in page form.
+type patternQuery struct {
+	Exchange    string
+	Symbol      string
+	Interval    string
+	Pattern     string
+	Direction   string
+	MinStrength string
+	Since       string
+}
+
+func (app *Pattern) list(c echo.Context) error {
+	filter, err := parsePatternFilter(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	patterns, err := app.storage.Patterns(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, patterns)
+}
+
+func (app *Pattern) symbol(c echo.Context) error {
+	filter, err := parsePatternFilter(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	filter.Exchange = strings.ToLower(c.Param("exchange"))
+	filter.Symbol = strings.ToUpper(c.Param("symbol"))
+	patterns, err := app.storage.Patterns(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, patterns)
+}
+
+func (app *Pattern) page(c echo.Context) error {
+	filter, err := parsePatternFilter(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	patterns, err := app.storage.Patterns(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	data := patternPageData{
+		Filter: patternQuery{
+			Exchange:    c.QueryParam("exchange"),
+			Symbol:      c.QueryParam("symbol"),
+			Interval:    c.QueryParam("interval"),
+			Pattern:     c.QueryParam("pattern"),
+			Direction:   c.QueryParam("direction"),
+			MinStrength: c.QueryParam("min_strength"),
+			Since:       c.QueryParam("since"),
+		},
+		Patterns:  patterns,
+		Kinds:     domain.ListPatterns,
+		Intervals: domain.ListIntervals,
+	}
+	return executeTemplate("patterns", templates.PatternsHtmlPage, c.Response(), templates.PageData{Title: "Patterns", Data: data})
+}
+
+// parsePatternFilter reads filter from query params.
+func parsePatternFilter(c echo.Context) (domain.PatternFilter, error) {
+	filter := domain.PatternFilter{
+		Exchange:  strings.ToLower(c.QueryParam("exchange")),
+		Symbol:    strings.ToUpper(c.QueryParam("symbol")),
+		Pattern:   domain.Pattern(strings.ToLower(c.QueryParam("pattern"))),
+		Direction: domain.PatternDirection(strings.ToLower(c.QueryParam("direction"))),
+	}
+	if param := c.QueryParam("interval"); param != "" {
+		interval, err := domain.ParseInterval(param)
+		if err != nil {
+			return domain.PatternFilter{}, err
+		}
+		filter.Interval = interval
+	}
+	if param := c.QueryParam("min_strength"); param != "" {
+		val, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return domain.PatternFilter{}, errors.Wrap(err, "min_strength")
+		}
+		filter.MinStrength = val
+	}
+	if param := c.QueryParam("limit"); param != "" {
+		val, err := strconv.Atoi(param)
+		if err != nil {
+			return domain.PatternFilter{}, errors.Wrap(err, "limit")
+		}
+		filter.Limit = val
+	}
+	since, err := parseSince(c.QueryParam("since"), DefaultPatternAge)
+	if err != nil {
+		return domain.PatternFilter{}, err
+	}
+	filter.Since = since
+	return filter, nil
+}
+
+// parseSince parses param which is either duration before now (e.g. 4h) or RFC3339 time, empty param is age before now.
+func parseSince(param string, age time.Duration) (time.Time, error) {
+	if param == "" {
+		return time.Now().Add(-age), nil
+	}
+	if d, err := time.ParseDuration(param); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if since, err := time.Parse(time.RFC3339, param); err == nil {
+		return since, nil
+	}
+	return time.Time{}, errors.Errorf("since must be duration or RFC3339 time, got %s", param)
+}
@@ -22,6 +22,8 @@ type Price struct {
 	snapshotStorage   domain.CandlestickStorage
 	symbolStorage     domain.SymbolStorage
 	priceChangeLoader domain.PriceChangeLoader
+	watchlist         domain.WatchlistLoader
+	patterns          domain.PatternStorage
 }
 
 func NewPrice(
@@ -29,15 +31,22 @@ func NewPrice(
 	snapshotStorage domain.CandlestickStorage,
 	symbolStorage domain.SymbolStorage,
 	priceChangeLoader domain.PriceChangeLoader,
+	watchlist domain.WatchlistLoader,
 ) *Price {
 	return &Price{
 		priceStorage:      priceStorage,
 		snapshotStorage:   snapshotStorage,
 		symbolStorage:     symbolStorage,
 		priceChangeLoader: priceChangeLoader,
+		watchlist:         watchlist,
 	}
 }
 
+// WithPatterns adds fresh candlestick patterns of symbol to snapshot.
+func (app *Price) WithPatterns(patterns domain.PatternStorage) {
+	app.patterns = patterns
+}
+
 func (app *Price) RegistrationPageRoute(e *echo.Group) {
 	e.GET("/price", app.index)
 	e.GET("/price/:symbol", app.symbolPrice)
@@ -54,8 +63,13 @@ func (app *Price) index(c echo.Context) error {
 	if err != nil {
 		return err
 	}
-	sort.Slice(symbols, func(i, j int) bool {
-		return domain.PopularSymbols[symbols[i]] > domain.PopularSymbols[symbols[j]]
+	items, err := app.watchlist.Watchlist(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	ranks := domain.PopularSymbols(items)
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return ranks[symbols[i]] > ranks[symbols[j]]
 	})
 
 	return executeTemplate("index", templates.IndexHtmlPage, c.Response(), templates.PageData{Title: "Price", Data: symbols})
@@ -82,19 +96,39 @@ func (app *Price) snapshot(c echo.Context) error {
 	if exchange == "" {
 		return errors.New("empty exchange")
 	}
-	snapshotOneHour, err := app.snapshotStorage.LastCandlestick(c.Request().Context(), exchange, symbol, domain.OneHourInterval)
-	if err != nil {
-		return err
+	intervals := domain.ListIntervals
+	if param := c.QueryParam("interval"); param != "" {
+		interval, err := domain.ParseInterval(param)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		intervals = []domain.Interval{interval}
 	}
-	snapshotFourHour, err := app.snapshotStorage.LastCandlestick(c.Request().Context(), exchange, symbol, domain.FourHourInterval)
-	snapshots := []*dto.Candlestick{snapshotFourHour, snapshotOneHour}
-	if err != nil {
-		return err
+	snapshots := make([]*dto.Candlestick, 0, len(intervals))
+	for i := len(intervals) - 1; i >= 0; i-- {
+		snapshot, err := app.snapshotStorage.LastCandlestick(c.Request().Context(), exchange, symbol, intervals[i])
+		if err != nil {
+			return err
+		}
+		if snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
 	}
 	data := map[string]interface{}{
 		"time":      time.Now().In(time.UTC),
 		"snapshots": snapshots,
 	}
+	if app.patterns != nil {
+		filter := domain.PatternFilter{Exchange: exchange, Symbol: symbol, Since: time.Now().Add(-DefaultPatternAge)}
+		if len(intervals) == 1 {
+			filter.Interval = intervals[0]
+		}
+		patterns, err := app.patterns.Patterns(c.Request().Context(), filter)
+		if err != nil {
+			return err
+		}
+		data["patterns"] = patterns
+	}
 	return c.JSON(http.StatusOK, data)
 }
 
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// DefaultPriceHistoryAge is range of price history when request has no from param.
+const DefaultPriceHistoryAge = 24 * time.Hour
+
+type PriceHistory struct {
+	history domain.PriceHistoryReader
+}
+
+func NewPriceHistory(history domain.PriceHistoryReader) *PriceHistory {
+	return &PriceHistory{history: history}
+}
+
+func (app *PriceHistory) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/price/history/:exchange/:symbol", app.bars)
+}
+
+type priceHistoryResponse struct {
+	Resolution domain.Interval   `json:"resolution"`
+	From       time.Time         `json:"from"`
+	To         time.Time         `json:"to"`
+	Bars       []domain.PriceBar `json:"bars"`
+}
+
+// bars returns OHLC bars of symbol between from (duration or RFC3339, 24h ago by default) and to (RFC3339,
+// now by default). Resolution (1m, 5m or 1h) is picked by range unless it is set.
+func (app *PriceHistory) bars(c echo.Context) error {
+	from, err := parseSince(c.QueryParam("from"), DefaultPriceHistoryAge)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "from").Error())
+	}
+	to := time.Now()
+	if param := c.QueryParam("to"); param != "" {
+		if to, err = time.Parse(time.RFC3339, param); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "to").Error())
+		}
+	}
+	if !from.Before(to) {
+		return echo.NewHTTPError(http.StatusBadRequest, "from must be before to")
+	}
+	resolution := domain.Interval(c.QueryParam("resolution"))
+	if resolution != "" && !domain.IsPriceHistoryTier(resolution) {
+		return echo.NewHTTPError(http.StatusBadRequest, "resolution must be one of 1m, 5m, 1h")
+	}
+	resolution, bars, err := app.history.Bars(
+		c.Request().Context(),
+		strings.ToLower(c.Param("exchange")),
+		strings.ToUpper(c.Param("symbol")),
+		resolution,
+		from,
+		to,
+	)
+	if err != nil {
+		return err
+	}
+	if bars == nil {
+		bars = []domain.PriceBar{}
+	}
+	return c.JSON(http.StatusOK, priceHistoryResponse{
+		Resolution: resolution,
+		From:       from.In(time.UTC),
+		To:         to.In(time.UTC),
+		Bars:       bars,
+	})
+}
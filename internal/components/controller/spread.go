@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/controller/templates"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultSpreadWindow is age of the latest spreads which are current opportunities.
+	DefaultSpreadWindow = 5 * time.Minute
+	// DefaultSpreadTop is number of current opportunities when request has no limit param.
+	DefaultSpreadTop = 50
+	// DefaultSpreadAge is age of spread history when request has no since param.
+	DefaultSpreadAge = 24 * time.Hour
+)
+
+type Spread struct {
+	storage domain.SpreadStorage
+	window  time.Duration
+	top     int
+	minNet  float64
+}
+
+func NewSpread(storage domain.SpreadStorage) *Spread {
+	return &Spread{storage: storage, window: DefaultSpreadWindow, top: DefaultSpreadTop}
+}
+
+// WithOpportunities sets defaults of current opportunities: age of the latest spreads, their number
+// and minimal net spread in percent.
+func (app *Spread) WithOpportunities(window time.Duration, top int, minNet float64) {
+	if window > 0 {
+		app.window = window
+	}
+	if top > 0 {
+		app.top = top
+	}
+	app.minNet = minNet
+}
+
+func (app *Spread) RegistrationPageRoute(e *echo.Group) {
+	e.GET("/spreads", app.page)
+}
+
+func (app *Spread) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/spreads", app.opportunities)
+	e.GET("/spreads/history", app.history)
+}
+
+type spreadPageData struct {
+	Filter        spreadQuery
+	Opportunities []domain.Spread
+	History       []domain.Spread
+}
+
+// spreadQuery is filter of request as it is shown in page form.
+type spreadQuery struct {
+	Symbol string
+	MinNet string
+	Window string
+}
+
+// opportunities returns the latest spread of every symbol within window, the most profitable first.
+func (app *Spread) opportunities(c echo.Context) error {
+	filter, err := app.opportunityFilter(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	spreads, err := app.storage.TopSpreads(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, spreads)
+}
+
+func (app *Spread) history(c echo.Context) error {
+	filter, err := parseSpreadFilter(c, nil)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if filter.Since, err = parseSince(c.QueryParam("since"), DefaultSpreadAge); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	spreads, err := app.storage.Spreads(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, spreads)
+}
+
+func (app *Spread) page(c echo.Context) error {
+	filter, err := app.opportunityFilter(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	data := spreadPageData{
+		Filter: spreadQuery{
+			Symbol: c.QueryParam("symbol"),
+			MinNet: strconv.FormatFloat(*filter.MinNetPercent, 'f', -1, 64),
+			Window: c.QueryParam("window"),
+		},
+	}
+	if data.Opportunities, err = app.storage.TopSpreads(ctx, filter); err != nil {
+		return err
+	}
+	if filter.Symbol != "" {
+		data.History, err = app.storage.Spreads(ctx, domain.SpreadFilter{
+			Symbol: filter.Symbol,
+			Since:  time.Now().Add(-DefaultSpreadAge),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return executeTemplate("spreads", templates.SpreadsHtmlPage, c.Response(), templates.PageData{Title: "Spreads", Data: data})
+}
+
+func (app *Spread) opportunityFilter(c echo.Context) (domain.SpreadFilter, error) {
+	minNet := app.minNet
+	filter, err := parseSpreadFilter(c, &minNet)
+	if err != nil {
+		return domain.SpreadFilter{}, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = app.top
+	}
+	window := app.window
+	if param := c.QueryParam("window"); param != "" {
+		if window, err = time.ParseDuration(param); err != nil {
+			return domain.SpreadFilter{}, errors.Wrap(err, "window")
+		}
+	}
+	filter.Since = time.Now().Add(-window)
+	return filter, nil
+}
+
+// parseSpreadFilter reads symbol, min_net and limit params, minNet is used when request has no min_net param.
+func parseSpreadFilter(c echo.Context, minNet *float64) (domain.SpreadFilter, error) {
+	filter := domain.SpreadFilter{
+		Symbol:        strings.ToUpper(c.QueryParam("symbol")),
+		MinNetPercent: minNet,
+	}
+	if param := c.QueryParam("min_net"); param != "" {
+		val, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return domain.SpreadFilter{}, errors.Wrap(err, "min_net")
+		}
+		filter.MinNetPercent = &val
+	}
+	if param := c.QueryParam("limit"); param != "" {
+		val, err := strconv.Atoi(param)
+		if err != nil {
+			return domain.SpreadFilter{}, errors.Wrap(err, "limit")
+		}
+		filter.Limit = val
+	}
+	return filter, nil
+}
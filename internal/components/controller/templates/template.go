@@ -20,6 +20,12 @@ var SymbolHtmlPage []byte
 //go:embed changes.html
 var PriceChangesHtmlPage []byte
 
+//go:embed patterns.html
+var PatternsHtmlPage []byte
+
+//go:embed spreads.html
+var SpreadsHtmlPage []byte
+
 type PageData struct {
 	Title       string
 	Symbol      string
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+type Watchlist struct {
+	storage domain.WatchlistStorage
+}
+
+func NewWatchlist(storage domain.WatchlistStorage) *Watchlist {
+	return &Watchlist{storage: storage}
+}
+
+func (app *Watchlist) RegistrationApiRoute(e *echo.Group) {
+	e.GET("/watchlist", app.list)
+	e.POST("/watchlist", app.create)
+	e.GET("/watchlist/:exchange/:symbol", app.item)
+	e.PUT("/watchlist/:exchange/:symbol", app.update)
+	e.DELETE("/watchlist/:exchange/:symbol", app.delete)
+}
+
+type watchlistRequest struct {
+	Exchange  string            `json:"exchange"`
+	Symbol    string            `json:"symbol"`
+	Intervals []domain.Interval `json:"intervals"`
+	Rank      int               `json:"rank"`
+}
+
+func (app *Watchlist) list(c echo.Context) error {
+	items, err := app.storage.Watchlist(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+func (app *Watchlist) item(c echo.Context) error {
+	key := domain.WatchlistItem{Exchange: c.Param("exchange"), Symbol: c.Param("symbol")}.Normalize()
+	item, err := app.storage.WatchlistItem(c.Request().Context(), key.Exchange, key.Symbol)
+	if err != nil {
+		return watchlistError(err)
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+func (app *Watchlist) create(c echo.Context) error {
+	var req watchlistRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	key := domain.WatchlistItem{Exchange: req.Exchange, Symbol: req.Symbol}.Normalize()
+	_, err := app.storage.WatchlistItem(c.Request().Context(), key.Exchange, key.Symbol)
+	if err == nil {
+		return echo.NewHTTPError(http.StatusConflict, "watchlist item already exists")
+	}
+	if !errors.Is(err, domain.ErrWatchlistItemNotFound) {
+		return err
+	}
+	return app.save(c, http.StatusCreated, req)
+}
+
+// update replaces intervals and rank, item is created when it does not exist.
+func (app *Watchlist) update(c echo.Context) error {
+	var req watchlistRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	req.Exchange, req.Symbol = c.Param("exchange"), c.Param("symbol")
+	return app.save(c, http.StatusOK, req)
+}
+
+func (app *Watchlist) delete(c echo.Context) error {
+	key := domain.WatchlistItem{Exchange: c.Param("exchange"), Symbol: c.Param("symbol")}.Normalize()
+	if err := app.storage.DeleteWatchlistItem(c.Request().Context(), key.Exchange, key.Symbol); err != nil {
+		return watchlistError(err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (app *Watchlist) save(c echo.Context, status int, req watchlistRequest) error {
+	item := domain.WatchlistItem{
+		Exchange:  req.Exchange,
+		Symbol:    req.Symbol,
+		Intervals: req.Intervals,
+		Rank:      req.Rank,
+	}.Normalize()
+	if err := item.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	if err := app.storage.SaveWatchlistItem(ctx, item); err != nil {
+		return err
+	}
+	saved, err := app.storage.WatchlistItem(ctx, item.Exchange, item.Symbol)
+	if err != nil {
+		return err
+	}
+	return c.JSON(status, saved)
+}
+
+func watchlistError(err error) error {
+	if errors.Is(err, domain.ErrWatchlistItemNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,238 @@
+package loader
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const DefaultBackfillLookback = 7 * 24 * time.Hour
+
+// Backfill loads missed candlesticks from source history. Every gap is a job, the job pages backwards
+// from the end of gap and saves its cursor after every page, so interrupted job is resumed from the cursor.
+type Backfill struct {
+	source       domain.CandlestickHistory
+	candlesticks domain.CandlestickStorage
+	gaps         domain.CandlestickGapLoader
+	jobs         domain.BackfillStorage
+	watchlist    domain.WatchlistLoader
+	lookback     time.Duration
+}
+
+func NewBackfill(
+	source domain.CandlestickHistory,
+	candlesticks domain.CandlestickStorage,
+	gaps domain.CandlestickGapLoader,
+	jobs domain.BackfillStorage,
+	watchlist domain.WatchlistLoader,
+) *Backfill {
+	return &Backfill{
+		source:       source,
+		candlesticks: candlesticks,
+		gaps:         gaps,
+		jobs:         jobs,
+		watchlist:    watchlist,
+		lookback:     DefaultBackfillLookback,
+	}
+}
+
+// WithLookback sets how far in the past the gap detector searches gaps.
+func (b *Backfill) WithLookback(lookback time.Duration) {
+	b.lookback = lookback
+}
+
+// Fill loads candlesticks opened in [from, to) which are missed in storage. Range in future is cut
+// at open time of the current candlestick, which is not closed yet and is not loaded from history.
+func (b *Backfill) Fill(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, from, to time.Time,
+) error {
+	if now := time.Now().UTC(); to.After(now) {
+		to = interval.Truncate(now)
+	}
+	if !from.Before(to) {
+		return errors.New("from must be before to")
+	}
+	err := b.resume(ctx, func(job domain.BackfillJob) bool {
+		return job.Exchange == exchange && job.Symbol == symbol && job.Interval == interval &&
+			job.From.Before(to) && job.To.After(from)
+	})
+	if err != nil {
+		return err
+	}
+	gaps, err := b.gaps.Gaps(ctx, exchange, symbol, interval, from, to)
+	if err != nil {
+		return errors.Wrap(err, "find gaps")
+	}
+	first, last, ok, err := b.gaps.Bounds(ctx, exchange, symbol, interval, from, to)
+	if err != nil {
+		return errors.Wrap(err, "find bounds")
+	}
+	edge := func(gapFrom, gapTo time.Time) domain.CandlestickGap {
+		return domain.CandlestickGap{Exchange: exchange, Symbol: symbol, Interval: interval, From: gapFrom, To: gapTo}
+	}
+	switch {
+	case !ok:
+		gaps = append(gaps, edge(from, to))
+	default:
+		if first.After(from) {
+			gaps = append(gaps, edge(from, first))
+		}
+		// Close time of the last closed candlestick is a millisecond before to, shorter edge is not a gap.
+		if to.Sub(last) >= interval.Duration() {
+			gaps = append(gaps, edge(last, to))
+		}
+	}
+	return b.fillGaps(ctx, gaps)
+}
+
+// Run resumes interrupted jobs, then searches gaps of watchlist candlesticks every period and fills them.
+// Only gaps between stored candlesticks are filled, history before the first candlestick is loaded by Fill.
+func (b *Backfill) Run(ctx context.Context, period time.Duration) error {
+	if err := b.resume(ctx, func(domain.BackfillJob) bool { return true }); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := b.detect(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				zap.L().Error("error detect candlestick gaps", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (b *Backfill) detect(ctx context.Context) error {
+	items, err := b.watchlist.Watchlist(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load watchlist")
+	}
+	to := time.Now().UTC()
+	from := to.Add(-b.lookback)
+	for _, item := range items {
+		for _, interval := range item.Intervals {
+			gaps, err := b.gaps.Gaps(ctx, item.Exchange, item.Symbol, interval, from, to)
+			if err != nil {
+				return errors.Wrap(err, "find gaps")
+			}
+			if err := b.fillGaps(ctx, gaps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Backfill) resume(ctx context.Context, filter func(job domain.BackfillJob) bool) error {
+	jobs, err := b.jobs.PendingBackfillJobs(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load pending jobs")
+	}
+	for _, job := range jobs {
+		if !filter(job) {
+			continue
+		}
+		if err := b.runJob(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backfill) fillGaps(ctx context.Context, gaps []domain.CandlestickGap) error {
+	for _, gap := range gaps {
+		job, err := b.jobs.CreateBackfillJob(ctx, gap)
+		if err != nil {
+			return errors.Wrap(err, "create backfill job")
+		}
+		// Done job of the same gap means that source has no candlesticks for it.
+		if job.Status == domain.BackfillStatusDone {
+			continue
+		}
+		if err := b.runJob(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Backfill) runJob(ctx context.Context, job domain.BackfillJob) error {
+	logger := zap.L().With(
+		zap.Int64("job", job.ID),
+		zap.String("exchange", job.Exchange),
+		zap.String("symbol", job.Symbol),
+		zap.Stringer("interval", job.Interval),
+	)
+	logger.Info("start backfill", zap.Time("from", job.From), zap.Time("to", job.To), zap.Time("cursor", job.Cursor))
+	metric.BackfillJobs.WithLabelValues("started").Inc()
+	remaining := metric.BackfillRemaining.WithLabelValues(job.Exchange, job.Symbol, job.Interval.String())
+	defer metric.BackfillRemaining.DeleteLabelValues(job.Exchange, job.Symbol, job.Interval.String())
+	for job.Cursor.After(job.From) {
+		remaining.Set(job.Cursor.Sub(job.From).Seconds())
+		var page []dto.Candlestick
+		err := backoff.Retry(func() error {
+			var err error
+			page, err = b.source.CandlesticksBefore(ctx, job.Exchange, job.Symbol, job.Interval, job.Cursor)
+			return err
+		}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+		if err != nil {
+			metric.BackfillJobs.WithLabelValues("failed").Inc()
+			return errors.Wrap(err, "load candlesticks history")
+		}
+		candlesticks := pageInRange(page, job)
+		if len(candlesticks) == 0 {
+			break
+		}
+		errSave := backoff.Retry(func() error {
+			return b.candlesticks.Save(ctx, candlesticks)
+		}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+		if errSave != nil {
+			metric.BackfillJobs.WithLabelValues("failed").Inc()
+			return errors.Wrap(errSave, "save candlesticks")
+		}
+		for _, item := range candlesticks {
+			if item.OpenTime.Before(job.Cursor) {
+				job.Cursor = item.OpenTime
+			}
+		}
+		job.Loaded += len(candlesticks)
+		if err := b.jobs.UpdateBackfillJob(ctx, job); err != nil {
+			return errors.Wrap(err, "save backfill cursor")
+		}
+		metric.BackfillCandlesticks.WithLabelValues(job.Exchange, job.Interval.String()).Add(float64(len(candlesticks)))
+		logger.Debug("backfill page", zap.Int("count", len(candlesticks)), zap.Time("cursor", job.Cursor))
+	}
+	job.Status = domain.BackfillStatusDone
+	if err := b.jobs.UpdateBackfillJob(ctx, job); err != nil {
+		return errors.Wrap(err, "finish backfill job")
+	}
+	metric.BackfillJobs.WithLabelValues(domain.BackfillStatusDone).Inc()
+	logger.Info("finish backfill", zap.Int("loaded", job.Loaded))
+	return nil
+}
+
+// pageInRange returns closed candlesticks of page opened between job start and cursor.
+func pageInRange(page []dto.Candlestick, job domain.BackfillJob) []dto.Candlestick {
+	now := time.Now()
+	result := make([]dto.Candlestick, 0, len(page))
+	for _, item := range page {
+		if item.OpenTime.Before(job.From) || !item.OpenTime.Before(job.Cursor) || item.CloseTime.After(now) {
+			continue
+		}
+		item.Interval = job.Interval.String()
+		result = append(result, item)
+	}
+	return toCandlestick(job.Symbol, job.Exchange, result...)
+}
@@ -0,0 +1,94 @@
+package loader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+)
+
+// backfillMemory is storage of series with the last candlestick closed at last and of created jobs,
+// history of source is empty.
+type backfillMemory struct {
+	domain.CandlestickStorage
+	domain.WatchlistLoader
+
+	first, last time.Time
+	jobs        []domain.BackfillJob
+}
+
+func (m *backfillMemory) Save(context.Context, []dto.Candlestick) error {
+	return nil
+}
+
+func (m *backfillMemory) Gaps(
+	context.Context, string, string, domain.Interval, time.Time, time.Time,
+) ([]domain.CandlestickGap, error) {
+	return nil, nil
+}
+
+func (m *backfillMemory) Bounds(
+	context.Context, string, string, domain.Interval, time.Time, time.Time,
+) (time.Time, time.Time, bool, error) {
+	return m.first, m.last, true, nil
+}
+
+func (m *backfillMemory) CreateBackfillJob(_ context.Context, gap domain.CandlestickGap) (domain.BackfillJob, error) {
+	job := domain.BackfillJob{
+		ID:       int64(len(m.jobs) + 1),
+		Exchange: gap.Exchange,
+		Symbol:   gap.Symbol,
+		Interval: gap.Interval,
+		From:     gap.From,
+		To:       gap.To,
+		Cursor:   gap.To,
+		Status:   domain.BackfillStatusPending,
+	}
+	m.jobs = append(m.jobs, job)
+	return job, nil
+}
+
+func (m *backfillMemory) PendingBackfillJobs(context.Context) ([]domain.BackfillJob, error) {
+	return nil, nil
+}
+
+func (m *backfillMemory) UpdateBackfillJob(context.Context, domain.BackfillJob) error {
+	return nil
+}
+
+func (m *backfillMemory) CandlesticksBefore(
+	context.Context, string, string, domain.Interval, time.Time,
+) ([]dto.Candlestick, error) {
+	return nil, nil
+}
+
+func TestBackfillTrailingEdge(t *testing.T) {
+	interval := domain.OneDayInterval
+	current := interval.Truncate(time.Now())
+	from := current.AddDate(0, 0, -5)
+	for name, item := range map[string]struct {
+		lastOpen time.Time
+		jobs     int
+	}{
+		"the last closed candlestick is stored": {lastOpen: current.AddDate(0, 0, -1)},
+		"candlestick is missed":                 {lastOpen: current.AddDate(0, 0, -2), jobs: 1},
+	} {
+		storage := &backfillMemory{first: from, last: interval.CloseTime(item.lastOpen)}
+		backfill := NewBackfill(storage, storage, storage, storage, storage)
+		// Range till future is cut at open time of the current candlestick.
+		err := backfill.Fill(
+			context.Background(), domain.BinanceExchange, domain.BTCUSDT, interval, from, current.AddDate(0, 0, 1),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(storage.jobs) != item.jobs {
+			t.Fatalf("%s: got jobs %+v, want %d", name, storage.jobs, item.jobs)
+		}
+		if item.jobs > 0 && !storage.jobs[0].To.Equal(current) {
+			t.Errorf("%s: job ends at %s, want open time of current candlestick %s", name, storage.jobs[0].To, current)
+		}
+	}
+}
@@ -4,13 +4,11 @@ import (
 	"context"
 	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/shutdown"
 	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/trade"
-	"strconv"
 	"time"
 
 	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
 	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
 	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
-	"github.com/AlekseyPorandaykin/crypto_loader/api/http/client"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/pkg/errors"
 	_ "github.com/shopspring/decimal"
@@ -19,20 +17,47 @@ import (
 
 const DefaultLoadPriceDuration = 1 * time.Minute
 
+type Config struct {
+	LoadPricesDuration         time.Duration
+	LoadExchangePricesDuration time.Duration
+	LoadSymbolsDuration        time.Duration
+	LoadSnapshotDuration       time.Duration
+	LoadCandlesticksDuration   time.Duration
+}
+
+var DefaultConfig = Config{
+	LoadPricesDuration:         DefaultLoadPriceDuration,
+	LoadExchangePricesDuration: time.Minute / 3,
+	LoadSymbolsDuration:        time.Minute,
+	LoadSnapshotDuration:       time.Minute,
+	LoadCandlesticksDuration:   time.Minute,
+}
+
 type Loader struct {
-	client             *client.Client
+	source             domain.MarketDataSource
 	priceStorage       domain.PriceSaver
 	candlestickStorage domain.CandlestickStorage
+	watchlist          domain.WatchlistLoader
 	price              *Price
+	conf               Config
 }
 
 func NewLoader(
-	client *client.Client,
+	source domain.MarketDataSource,
 	priceStorage domain.PriceSaver,
 	candlestickStorage domain.CandlestickStorage,
+	watchlist domain.WatchlistLoader,
 	price *Price,
+	conf Config,
 ) *Loader {
-	return &Loader{client: client, priceStorage: priceStorage, candlestickStorage: candlestickStorage, price: price}
+	return &Loader{
+		source:             source,
+		priceStorage:       priceStorage,
+		candlestickStorage: candlestickStorage,
+		watchlist:          watchlist,
+		price:              price,
+		conf:               conf,
+	}
 }
 
 func (l *Loader) Run(ctx context.Context) error {
@@ -70,17 +95,17 @@ func (l *Loader) Run(ctx context.Context) error {
 }
 
 func (l *Loader) loadPrices(ctx context.Context) error {
-	ticker := time.NewTicker(DefaultLoadPriceDuration)
+	ticker := time.NewTicker(l.conf.LoadPricesDuration)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			var sourcePrices []client.PriceResponse
+			var sourcePrices []domain.SourcePrice
 			err := backoff.Retry(func() error {
 				var err error
-				sourcePrices, err = l.client.AllSymbolPrices(ctx)
+				sourcePrices, err = l.source.AllSymbolPrices(ctx)
 				if err != nil {
 					return err
 				}
@@ -94,7 +119,7 @@ func (l *Loader) loadPrices(ctx context.Context) error {
 				if trade.IsEmptyPrice(item.Price) {
 					continue
 				}
-				price, err := strconv.ParseFloat(item.Price, 64)
+				price, err := domain.ParsePrice(item.Price)
 				if err != nil {
 					trade.IsEmptyPrice(item.Price)
 					zap.L().Error(
@@ -105,7 +130,7 @@ func (l *Loader) loadPrices(ctx context.Context) error {
 					)
 					continue
 				}
-				if price == 0 {
+				if price.IsZero() {
 					continue
 				}
 				prices = append(prices, &domain.SymbolPrice{
@@ -129,27 +154,30 @@ func (l *Loader) loadPrices(ctx context.Context) error {
 }
 
 func (l *Loader) loadSymbolSnapshot(ctx context.Context) error {
-	ticker := time.NewTicker(1 * time.Minute)
+	ticker := time.NewTicker(l.conf.LoadSnapshotDuration)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			for _, symbol := range domain.SubscribeSymbols {
+			items, err := l.watchlist.Watchlist(ctx)
+			if err != nil {
+				zap.L().Error("error load watchlist", zap.Error(err))
+				continue
+			}
+			for _, item := range items {
+				if len(item.Intervals) == 0 {
+					continue
+				}
 				var candlesticks []dto.Candlestick
 				err := backoff.Retry(func() error {
 					var err error
-					resp, err := l.client.SymbolSnapshot(ctx, domain.BinanceExchange, symbol)
+					resp, err := l.source.SymbolSnapshot(ctx, item.Exchange, item.Symbol, item.Intervals...)
 					if err != nil {
 						return errors.Wrap(err, "error get symbolSnapshot")
 					}
-					candlesticks = toCandlestick(
-						resp.Symbol,
-						resp.Exchange,
-						resp.Candlestick4H,
-						resp.Candlestick1H,
-					)
+					candlesticks = toCandlestick(resp.Symbol, resp.Exchange, resp.Candlesticks...)
 
 					return nil
 				}, backoff.NewExponentialBackOff())
@@ -171,16 +199,21 @@ func (l *Loader) loadSymbolSnapshot(ctx context.Context) error {
 }
 
 func (l *Loader) loadCandlesticks(ctx context.Context) error {
-	ticker := time.NewTicker(time.Minute)
+	ticker := time.NewTicker(l.conf.LoadCandlesticksDuration)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			for _, symbol := range domain.SubscribeSymbols {
-				for _, interval := range domain.ListIntervals {
-					if err := l.fetchCandlesticks(ctx, domain.BinanceExchange, symbol, interval); err != nil {
+			items, err := l.watchlist.Watchlist(ctx)
+			if err != nil {
+				zap.L().Error("error load watchlist", zap.Error(err))
+				continue
+			}
+			for _, item := range items {
+				for _, interval := range item.Intervals {
+					if err := l.fetchCandlesticks(ctx, item.Exchange, item.Symbol, interval); err != nil {
 						zap.L().Error("error fetch candlesticks", zap.Error(err))
 					}
 				}
@@ -189,14 +222,14 @@ func (l *Loader) loadCandlesticks(ctx context.Context) error {
 	}
 }
 
-func (l *Loader) fetchCandlesticks(ctx context.Context, exchange, symbol, interval string) error {
+func (l *Loader) fetchCandlesticks(ctx context.Context, exchange, symbol string, interval domain.Interval) error {
 	candlesticks := make([]dto.Candlestick, 0, 1000)
 	err := backoff.Retry(func() error {
-		resp, err := l.client.Candlesticks(ctx, exchange, symbol, interval)
+		resp, err := l.source.Candlesticks(ctx, exchange, symbol, interval)
 		if err != nil {
 			return err
 		}
-		data := make([]client.SymbolSnapshotCandlestick, 0, len(resp))
+		data := make([]dto.Candlestick, 0, len(resp))
 		now := time.Now().In(time.UTC)
 		for _, item := range resp {
 			if item.CloseTime.After(now) {
@@ -219,28 +252,15 @@ func (l *Loader) fetchCandlesticks(ctx context.Context, exchange, symbol, interv
 	return nil
 }
 
-func toCandlestick(
-	symbol, exchange string, data ...client.SymbolSnapshotCandlestick,
-) []dto.Candlestick {
+func toCandlestick(symbol, exchange string, data ...dto.Candlestick) []dto.Candlestick {
 	candlesticks := make([]dto.Candlestick, 0, len(data))
 	for _, item := range data {
-		if item.OpenTime.IsZero() || item.CloseTime.IsZero() || item.OpenPrice == 0 || item.ClosePrice == 0 {
+		if item.OpenTime.IsZero() || item.CloseTime.IsZero() || item.OpenPrice.IsZero() || item.ClosePrice.IsZero() {
 			continue
 		}
-		candlesticks = append(candlesticks, dto.Candlestick{
-			Symbol:       symbol,
-			Exchange:     exchange,
-			OpenTime:     item.OpenTime,
-			CloseTime:    item.CloseTime,
-			OpenPrice:    item.OpenPrice,
-			HighPrice:    item.HighPrice,
-			LowPrice:     item.LowPrice,
-			ClosePrice:   item.ClosePrice,
-			Volume:       item.Volume,
-			NumberTrades: item.NumberTrades,
-			Interval:     item.Interval,
-			CreatedAt:    item.CreatedAt,
-		})
+		item.Symbol = symbol
+		item.Exchange = exchange
+		candlesticks = append(candlesticks, item)
 	}
 	return candlesticks
 }
@@ -4,45 +4,70 @@ import (
 	"context"
 	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/shutdown"
 	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/trade"
-	"strconv"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
 	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
-	"github.com/AlekseyPorandaykin/crypto_analyst/internal/storage/db"
-	"github.com/AlekseyPorandaykin/crypto_loader/api/http/client"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
 type Price struct {
-	client       *client.Client
-	symbolRepo   *db.Symbols
-	priceRepo    *db.PriceRepository
+	source       domain.MarketDataSource
+	symbolRepo   domain.ExchangeSymbolLoader
+	priceRepo    domain.NewSymbolSaver
 	priceStorage domain.PriceSaver
 
 	exchangeSymbols map[string]map[string]bool
 	muSymbols       sync.Mutex
+
+	listings   domain.ListingStorage
+	tracked    map[string]map[string]*domain.Listing
+	muListings sync.Mutex
+
+	conf Config
 }
 
 func NewPrice(
-	client *client.Client,
-	symbolRepo *db.Symbols,
-	priceRepo *db.PriceRepository,
+	source domain.MarketDataSource,
+	symbolRepo domain.ExchangeSymbolLoader,
+	priceRepo domain.NewSymbolSaver,
 	priceStorage domain.PriceSaver,
+	conf Config,
 ) *Price {
 	return &Price{
-		client:          client,
+		source:          source,
 		symbolRepo:      symbolRepo,
 		priceRepo:       priceRepo,
 		exchangeSymbols: make(map[string]map[string]bool),
+		tracked:         make(map[string]map[string]*domain.Listing),
 		priceStorage:    priceStorage,
+		conf:            conf,
 	}
 }
 
+// WithListings enables tracking of listings: first seen time and price action of the first
+// domain.ListingWindow of symbols new on exchange.
+func (p *Price) WithListings(listings domain.ListingStorage) {
+	p.listings = listings
+}
+
 func (p *Price) Run(ctx context.Context) error {
+	return p.run(ctx, true)
+}
+
+// RunNewSymbols detects new symbols only, prices are loaded by another component, e.g. Stream.
+func (p *Price) RunNewSymbols(ctx context.Context) error {
+	return p.run(ctx, false)
+}
+
+func (p *Price) run(ctx context.Context, withPrices bool) error {
+	if err := p.loadTrackedListings(ctx); err != nil {
+		return errors.Wrap(err, "load tracked listings")
+	}
 	errCh := make(chan error)
 	for _, ex := range domain.ListExchanges {
 		ex := ex
@@ -53,19 +78,21 @@ func (p *Price) Run(ctx context.Context) error {
 			}
 		}(ex)
 	}
-	go func() {
-		defer shutdown.HandlePanic()
-		if err := p.loadPrices(ctx); err != nil {
-			errCh <- err
-		}
-	}()
+	if withPrices {
+		go func() {
+			defer shutdown.HandlePanic()
+			if err := p.loadPrices(ctx); err != nil {
+				errCh <- err
+			}
+		}()
+	}
 	go func() {
 		defer shutdown.HandlePanic()
 		if err := p.loadSymbols(ctx); err != nil {
 			errCh <- err
 			return
 		}
-		ticker := time.NewTicker(time.Minute)
+		ticker := time.NewTicker(p.conf.LoadSymbolsDuration)
 		defer ticker.Stop()
 		for {
 			select {
@@ -90,7 +117,7 @@ func (p *Price) Run(ctx context.Context) error {
 }
 
 func (p *Price) loadExchangePrices(ctx context.Context, exchange string) error {
-	ticker := time.NewTicker(time.Minute / 3)
+	ticker := time.NewTicker(p.conf.LoadExchangePricesDuration)
 	defer ticker.Stop()
 	for {
 		select {
@@ -100,10 +127,10 @@ func (p *Price) loadExchangePrices(ctx context.Context, exchange string) error {
 			if len(p.exchangeSymbols) == 0 {
 				continue
 			}
-			var sourcePrices []client.PriceResponse
+			var sourcePrices []domain.SourcePrice
 			err := backoff.Retry(func() error {
 				var err error
-				sourcePrices, err = p.client.ExchangePrices(ctx, exchange)
+				sourcePrices, err = p.source.ExchangePrices(ctx, exchange)
 				if err != nil {
 					return err
 				}
@@ -112,18 +139,18 @@ func (p *Price) loadExchangePrices(ctx context.Context, exchange string) error {
 			if err != nil {
 				return errors.Wrap(err, "error load exchange price")
 			}
-			prices := make([]domain.SymbolPrice, 0, len(sourcePrices))
+			var (
+				prices = make([]domain.SymbolPrice, 0, len(sourcePrices))
+				known  = make([]domain.SymbolPrice, 0, len(sourcePrices))
+			)
 			for _, item := range sourcePrices {
 				if p.isEmptyExchangeSymbol(item.Exchange) {
 					continue
 				}
-				if p.hasExchangeSymbol(item.Exchange, item.Symbol) {
-					continue
-				}
 				if trade.IsEmptyPrice(item.Price) {
 					continue
 				}
-				price, err := strconv.ParseFloat(item.Price, 64)
+				price, err := domain.ParsePrice(item.Price)
 				if err != nil {
 					zap.L().Error(
 						"error parse price",
@@ -133,15 +160,23 @@ func (p *Price) loadExchangePrices(ctx context.Context, exchange string) error {
 					)
 					continue
 				}
-				if price == 0 {
+				if price.IsZero() {
 					continue
 				}
-				prices = append(prices, domain.SymbolPrice{
+				symbolPrice := domain.SymbolPrice{
 					Exchange: item.Exchange,
 					Symbol:   item.Symbol,
 					Price:    price,
 					Date:     item.Date,
-				})
+				}
+				if p.hasExchangeSymbol(item.Exchange, item.Symbol) {
+					known = append(known, symbolPrice)
+					continue
+				}
+				prices = append(prices, symbolPrice)
+			}
+			if err := p.trackListings(ctx, known); err != nil {
+				zap.L().Error("error track listings", zap.String("exchange", exchange), zap.Error(err))
 			}
 			if len(prices) == 0 {
 				continue
@@ -155,7 +190,153 @@ func (p *Price) loadExchangePrices(ctx context.Context, exchange string) error {
 			}
 			metric.SaveNewSymbolDuration.Add(float64(time.Since(start).Milliseconds()))
 			metric.SaveNewSymbol.Add(float64(len(prices)))
+			if err := p.addListings(ctx, prices); err != nil {
+				zap.L().Error("error save listings", zap.String("exchange", exchange), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (p *Price) loadTrackedListings(ctx context.Context) error {
+	if p.listings == nil {
+		return nil
+	}
+	listings, err := p.listings.TrackedListings(ctx)
+	if err != nil {
+		return err
+	}
+	p.muListings.Lock()
+	defer p.muListings.Unlock()
+	for i := range listings {
+		p.trackListing(&listings[i])
+	}
+	return nil
+}
+
+// addListings saves listings of new symbols and tracks their price action. Symbol is cross listed
+// when it is traded on another exchange or was listed there before.
+func (p *Price) addListings(ctx context.Context, prices []domain.SymbolPrice) error {
+	p.addExchangeSymbols(prices)
+	if p.listings == nil {
+		return nil
+	}
+	symbols := make([]string, 0, len(prices))
+	for _, price := range prices {
+		if !slices.Contains(symbols, price.Symbol) {
+			symbols = append(symbols, price.Symbol)
+		}
+	}
+	existing, err := p.listings.SymbolListings(ctx, symbols)
+	if err != nil {
+		return errors.Wrap(err, "load listings of new symbols")
+	}
+	bySymbol := make(map[string][]domain.Listing, len(symbols))
+	for _, item := range existing {
+		bySymbol[item.Symbol] = append(bySymbol[item.Symbol], item)
+	}
+	listings := make([]domain.Listing, 0, len(prices))
+	for _, price := range prices {
+		listing := domain.NewListing(price)
+		others := bySymbol[price.Symbol]
+		// Symbol is detected again after restart until it is loaded to symbols of exchange.
+		if slices.ContainsFunc(others, func(other domain.Listing) bool { return other.Exchange == price.Exchange }) {
+			continue
+		}
+		for _, other := range others {
+			if other.Exchange == price.Exchange || !other.FirstSeen.Before(price.Date) {
+				continue
+			}
+			// Listings are ordered by first seen time, the last one is the earliest.
+			firstSeen := other.FirstSeen
+			listing.CrossListed, listing.FirstExchange, listing.FirstListedAt = true, other.Exchange, &firstSeen
+		}
+		if !listing.CrossListed {
+			for _, exchange := range domain.ListExchanges {
+				if exchange != price.Exchange && p.hasExchangeSymbol(exchange, price.Symbol) {
+					listing.CrossListed, listing.FirstExchange = true, exchange
+					break
+				}
+			}
+		}
+		listings = append(listings, listing)
+	}
+	errSave := backoff.Retry(func() error {
+		return p.listings.SaveListings(ctx, listings)
+	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+	if errSave != nil {
+		return errSave
+	}
+	p.muListings.Lock()
+	defer p.muListings.Unlock()
+	for i := range listings {
+		kind := "new"
+		if listings[i].CrossListed {
+			kind = "cross"
+		}
+		metric.Listings.WithLabelValues(listings[i].Exchange, kind).Inc()
+		p.trackListing(&listings[i])
+	}
+	return nil
+}
+
+// trackListings adds prices of known symbols to price action of tracked listings.
+func (p *Price) trackListings(ctx context.Context, prices []domain.SymbolPrice) error {
+	if p.listings == nil {
+		return nil
+	}
+	var (
+		changed   []domain.Listing
+		completed []*domain.Listing
+	)
+	p.muListings.Lock()
+	for _, price := range prices {
+		listing, has := p.tracked[price.Exchange][price.Symbol]
+		if !has || slices.Contains(completed, listing) {
+			continue
+		}
+		// Completed listing is still tracked when it is not saved, it is saved again.
+		if !listing.Complete && !listing.Observe(price.Price, price.Date) {
+			continue
+		}
+		changed = append(changed, *listing)
+		if listing.Complete {
+			completed = append(completed, listing)
+		}
+	}
+	p.muListings.Unlock()
+	err := backoff.Retry(func() error {
+		return p.listings.SaveListings(ctx, changed)
+	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+	if err != nil {
+		return err
+	}
+	p.muListings.Lock()
+	defer p.muListings.Unlock()
+	for _, listing := range completed {
+		if p.tracked[listing.Exchange][listing.Symbol] == listing {
+			delete(p.tracked[listing.Exchange], listing.Symbol)
+			metric.ListingsCompleted.Inc()
+		}
+	}
+	return nil
+}
+
+// trackListing must be called with locked muListings.
+func (p *Price) trackListing(listing *domain.Listing) {
+	if _, has := p.tracked[listing.Exchange]; !has {
+		p.tracked[listing.Exchange] = make(map[string]*domain.Listing)
+	}
+	p.tracked[listing.Exchange][listing.Symbol] = listing
+}
+
+func (p *Price) addExchangeSymbols(prices []domain.SymbolPrice) {
+	p.muSymbols.Lock()
+	defer p.muSymbols.Unlock()
+	for _, price := range prices {
+		if _, has := p.exchangeSymbols[price.Exchange]; !has {
+			p.exchangeSymbols[price.Exchange] = make(map[string]bool)
 		}
+		p.exchangeSymbols[price.Exchange][price.Symbol] = true
 	}
 }
 
@@ -177,17 +358,17 @@ func (p *Price) loadSymbols(ctx context.Context) error {
 }
 
 func (p *Price) loadPrices(ctx context.Context) error {
-	ticker := time.NewTicker(DefaultLoadPriceDuration)
+	ticker := time.NewTicker(p.conf.LoadPricesDuration)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			var sourcePrices []client.PriceResponse
+			var sourcePrices []domain.SourcePrice
 			err := backoff.Retry(func() error {
 				var err error
-				sourcePrices, err = p.client.AllSymbolPrices(ctx)
+				sourcePrices, err = p.source.AllSymbolPrices(ctx)
 				if err != nil {
 					return err
 				}
@@ -201,7 +382,7 @@ func (p *Price) loadPrices(ctx context.Context) error {
 				if trade.IsEmptyPrice(item.Price) {
 					continue
 				}
-				price, err := strconv.ParseFloat(item.Price, 64)
+				price, err := domain.ParsePrice(item.Price)
 				if err != nil {
 					trade.IsEmptyPrice(item.Price)
 					zap.L().Error(
@@ -212,7 +393,7 @@ func (p *Price) loadPrices(ctx context.Context) error {
 					)
 					continue
 				}
-				if price == 0 {
+				if price.IsZero() {
 					continue
 				}
 				prices = append(prices, &domain.SymbolPrice{
@@ -0,0 +1,69 @@
+package loader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// listingMemory records saved listings, save fails while fail is set.
+type listingMemory struct {
+	domain.ListingStorage
+
+	fail  bool
+	saved []domain.Listing
+}
+
+func (m *listingMemory) SaveListings(_ context.Context, listings []domain.Listing) error {
+	if m.fail {
+		return backoff.Permanent(errors.New("database is down"))
+	}
+	m.saved = append(m.saved, listings...)
+	return nil
+}
+
+func TestTrackListingsKeepsNotSavedCompleted(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := &listingMemory{fail: true}
+	p := &Price{listings: storage, tracked: make(map[string]map[string]*domain.Listing)}
+	listing := &domain.Listing{
+		Exchange:   "binance",
+		Symbol:     "PEPEUSDT",
+		FirstSeen:  start,
+		FirstPrice: decimal.RequireFromString("0.00001234"),
+		High:       decimal.RequireFromString("0.00001234"),
+		Low:        decimal.RequireFromString("0.00001234"),
+		LastPrice:  decimal.RequireFromString("0.00001234"),
+		LastSeen:   start,
+	}
+	p.trackListing(listing)
+	price := domain.SymbolPrice{
+		Exchange: "binance",
+		Symbol:   "PEPEUSDT",
+		Price:    decimal.RequireFromString("0.00001240"),
+		Date:     start.Add(domain.ListingWindow),
+	}
+	if err := p.trackListings(context.Background(), []domain.SymbolPrice{price}); err == nil {
+		t.Fatal("expected error of save")
+	}
+	if _, has := p.tracked["binance"]["PEPEUSDT"]; !has {
+		t.Fatal("completed listing is not tracked before it is saved")
+	}
+
+	storage.fail = false
+	price.Date = price.Date.Add(time.Minute)
+	if err := p.trackListings(context.Background(), []domain.SymbolPrice{price}); err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.saved) != 1 || !storage.saved[0].Complete {
+		t.Fatalf("got saved listings %+v, want completed listing", storage.saved)
+	}
+	if _, has := p.tracked["binance"]["PEPEUSDT"]; has {
+		t.Error("saved completed listing is still tracked")
+	}
+}
@@ -0,0 +1,248 @@
+package loader
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/stream"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/shutdown"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultFlushDuration = 5 * time.Second
+	// saveMaxElapsed bounds retries of a saved batch, missed candlesticks are restored by backfill.
+	saveMaxElapsed = time.Minute
+	// shutdownFlushTimeout bounds saving of buffered prices and candlesticks on shutdown.
+	shutdownFlushTimeout = 10 * time.Second
+)
+
+// streamBatch is buffered prices and closed candlesticks handed to writer of stream.
+type streamBatch struct {
+	prices       map[string]*domain.SymbolPrice
+	candlesticks []dto.Candlestick
+}
+
+func (b streamBatch) empty() bool {
+	return len(b.prices) == 0 && len(b.candlesticks) == 0
+}
+
+// Stream loads prices and candlesticks from exchange websockets instead of polling.
+// Prices are buffered and saved once per flush duration, closed candlesticks are saved as soon as writer is idle,
+// buffered data is flushed on shutdown, gaps in candlesticks are filled from REST API of source.
+type Stream struct {
+	clients            []*stream.Client
+	source             domain.MarketDataSource
+	priceStorage       domain.PriceSaver
+	candlestickStorage domain.CandlestickSaver
+	price              *Price
+	flushDuration      time.Duration
+}
+
+func NewStream(
+	clients []*stream.Client,
+	source domain.MarketDataSource,
+	priceStorage domain.PriceSaver,
+	candlestickStorage domain.CandlestickSaver,
+	price *Price,
+	flushDuration time.Duration,
+) *Stream {
+	if flushDuration <= 0 {
+		flushDuration = DefaultFlushDuration
+	}
+	return &Stream{
+		clients:            clients,
+		source:             source,
+		priceStorage:       priceStorage,
+		candlestickStorage: candlestickStorage,
+		price:              price,
+		flushDuration:      flushDuration,
+	}
+}
+
+func (s *Stream) Run(ctx context.Context) error {
+	childCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errCh := make(chan error, len(s.clients)+1)
+	events := make(chan []stream.Event, 100)
+	for _, client := range s.clients {
+		go func(client *stream.Client) {
+			defer shutdown.HandlePanic()
+			if err := client.Run(childCtx, events); err != nil && childCtx.Err() == nil {
+				errCh <- err
+			}
+		}(client)
+	}
+	go func() {
+		defer shutdown.HandlePanic()
+		if err := s.price.RunNewSymbols(childCtx); err != nil && childCtx.Err() == nil {
+			errCh <- err
+		}
+	}()
+
+	// Storage is written outside of the events loop, so slow storage does not stall reading of streams.
+	writes := make(chan streamBatch)
+	writerDone := make(chan struct{})
+	writeCtx, cancelWrite := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWrite()
+	go func() {
+		defer shutdown.HandlePanic()
+		defer close(writerDone)
+		for batch := range writes {
+			s.save(writeCtx, batch)
+		}
+	}()
+
+	pending := streamBatch{prices: make(map[string]*domain.SymbolPrice)}
+	flushPrices := false
+	defer func() {
+		// Buffered prices and candlesticks are saved on shutdown within bounded time.
+		cancel()
+		timer := time.AfterFunc(shutdownFlushTimeout, cancelWrite)
+		defer timer.Stop()
+		if !pending.empty() {
+			writes <- pending
+		}
+		close(writes)
+		<-writerDone
+	}()
+
+	ticker := time.NewTicker(s.flushDuration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errCh:
+			return err
+		case batch := <-events:
+			for _, event := range batch {
+				switch event.Type {
+				case stream.PriceEvent:
+					if event.Price.Price.IsZero() {
+						continue
+					}
+					price := event.Price
+					pending.prices[price.Exchange+price.Symbol] = &price
+				case stream.CandlestickEvent:
+					if event.Closed {
+						pending.candlesticks = append(pending.candlesticks, event.Candlestick)
+					}
+				case stream.GapEvent:
+					go func(gap stream.Gap) {
+						defer shutdown.HandlePanic()
+						if err := s.fillGap(childCtx, gap); err != nil {
+							zap.L().Error("error fill candlesticks gap", zap.Any("gap", gap), zap.Error(err))
+						}
+					}(event.Gap)
+				}
+			}
+		case <-ticker.C:
+			flushPrices = true
+		}
+		// Pending batch is handed to writer only when it is idle, otherwise it is accumulated further.
+		out := streamBatch{candlesticks: pending.candlesticks}
+		if flushPrices {
+			out.prices = pending.prices
+		}
+		if out.empty() {
+			flushPrices = false
+			continue
+		}
+		select {
+		case writes <- out:
+			pending.candlesticks = nil
+			if flushPrices {
+				pending.prices = make(map[string]*domain.SymbolPrice, len(out.prices))
+				flushPrices = false
+			}
+		default:
+		}
+	}
+}
+
+func (s *Stream) save(ctx context.Context, batch streamBatch) {
+	if err := s.saveCandlesticks(ctx, batch.candlesticks); err != nil {
+		zap.L().Error("error save stream candlesticks", zap.Int("count", len(batch.candlesticks)), zap.Error(err))
+	}
+	if len(batch.prices) > 0 {
+		s.savePrices(ctx, batch.prices)
+	}
+}
+
+// saveBackOff retries saving with bounded elapsed time.
+func saveBackOff(ctx context.Context) backoff.BackOffContext {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = saveMaxElapsed
+	return backoff.WithContext(b, ctx)
+}
+
+func (s *Stream) savePrices(ctx context.Context, buffer map[string]*domain.SymbolPrice) {
+	prices := make([]*domain.SymbolPrice, 0, len(buffer))
+	for _, price := range buffer {
+		prices = append(prices, price)
+	}
+	start := time.Now()
+	errSave := backoff.Retry(func() error {
+		return s.priceStorage.SavePrices(ctx, prices)
+	}, saveBackOff(ctx))
+	if errSave != nil {
+		zap.L().Error("error save symbolPrice", zap.Error(errSave))
+	}
+	metric.SavePriceDuration.Add(float64(time.Since(start).Milliseconds()))
+	metric.SavePrices.Add(float64(len(prices)))
+}
+
+func (s *Stream) saveCandlesticks(ctx context.Context, data []dto.Candlestick) error {
+	if len(data) == 0 {
+		return nil
+	}
+	candlesticks := make([]dto.Candlestick, 0, len(data))
+	for _, item := range data {
+		candlesticks = append(candlesticks, toCandlestick(item.Symbol, item.Exchange, item)...)
+	}
+	return backoff.Retry(func() error {
+		return s.candlestickStorage.Save(ctx, candlesticks)
+	}, saveBackOff(ctx))
+}
+
+// fillGap loads missed candlesticks from REST API, only candlesticks inside the gap are saved.
+func (s *Stream) fillGap(ctx context.Context, gap stream.Gap) error {
+	zap.L().Info(
+		"fill candlesticks gap",
+		zap.String("exchange", gap.Exchange),
+		zap.String("symbol", gap.Symbol),
+		zap.Stringer("interval", gap.Interval),
+		zap.Time("from", gap.From),
+		zap.Time("to", gap.To),
+	)
+	var resp []dto.Candlestick
+	err := backoff.Retry(func() error {
+		var err error
+		resp, err = s.source.Candlesticks(ctx, gap.Exchange, gap.Symbol, gap.Interval)
+		return err
+	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+	if err != nil {
+		return errors.Wrap(err, "error get candlesticks")
+	}
+	candlesticks := make([]dto.Candlestick, 0, len(resp))
+	for _, item := range resp {
+		if item.OpenTime.Before(gap.From) || !item.OpenTime.Before(gap.To) {
+			continue
+		}
+		item.Symbol = gap.Symbol
+		item.Exchange = gap.Exchange
+		item.Interval = gap.Interval.String()
+		candlesticks = append(candlesticks, item)
+	}
+	if len(candlesticks) == 0 {
+		zap.L().Warn("candlesticks gap is out of source range", zap.Any("gap", gap))
+		return nil
+	}
+	return s.saveCandlesticks(ctx, candlesticks)
+}
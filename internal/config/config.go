@@ -0,0 +1,602 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/cleaner"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/indicator"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/notifier"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/database"
+	"github.com/AlekseyPorandaykin/crypto_analyst/pkg/logger"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const EnvPrefix = "CRYPTO_ANALYST"
+
+const hiddenValue = "******"
+
+const (
+	SourceCryptoLoader = "crypto_loader"
+	SourceReplay       = "replay"
+	SourceExchanges    = "exchanges"
+)
+
+const (
+	ModePoll   = "poll"
+	ModeStream = "stream"
+)
+
+// MetricsDisabled is port of metrics server which disables it.
+const MetricsDisabled = "0"
+
+const (
+	TimescaleOff      = "off"
+	TimescaleAuto     = "auto"
+	TimescaleRequired = "required"
+)
+
+type AppConfig struct {
+	Database  database.Config `mapstructure:"database"`
+	Logger    logger.Config   `mapstructure:"logger"`
+	Loader    LoaderConfig    `mapstructure:"loader"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Intervals IntervalsConfig `mapstructure:"intervals"`
+	Exchanges ExchangesConfig `mapstructure:"exchanges"`
+	Stream    StreamConfig    `mapstructure:"stream"`
+	Backfill  BackfillConfig  `mapstructure:"backfill"`
+	Resample  ResampleConfig  `mapstructure:"resample"`
+	Levels    LevelsConfig    `mapstructure:"levels"`
+	Spread    SpreadConfig    `mapstructure:"spread"`
+	History   HistoryConfig   `mapstructure:"history"`
+	Timescale TimescaleConfig `mapstructure:"timescale"`
+	Retention cleaner.Config  `mapstructure:"retention"`
+	Notifier  notifier.Config `mapstructure:"notifier"`
+	// Indicators are read only indicator instances, instances can be also created by API.
+	Indicators []IndicatorConfig `mapstructure:"indicators"`
+}
+
+type LoaderConfig struct {
+	// Source of market data: crypto_loader, exchanges (direct REST API of exchanges) or replay.
+	Source string `mapstructure:"source"`
+	URL    string `mapstructure:"url"`
+	// ReplayFile is json with recorded frames for replay source, generated frames are used when it is empty.
+	ReplayFile string `mapstructure:"replay_file"`
+	// Mode is poll (periodic requests to source) or stream (websockets of exchanges, source fills gaps).
+	Mode string `mapstructure:"mode"`
+}
+
+type StreamConfig struct {
+	BinanceURL string `mapstructure:"binance_url"`
+	BybitURL   string `mapstructure:"bybit_url"`
+	// Heartbeat is ping period, connection is reconnected when nothing is received during two periods.
+	Heartbeat time.Duration `mapstructure:"heartbeat"`
+	// Flush is period of saving buffered prices.
+	Flush time.Duration `mapstructure:"flush"`
+}
+
+type ExchangesConfig struct {
+	Binance ExchangeConfig `mapstructure:"binance"`
+	Bybit   ExchangeConfig `mapstructure:"bybit"`
+}
+
+type ExchangeConfig struct {
+	URL string `mapstructure:"url"`
+	// RateLimit is request weight per second, Burst is max weight of requests sent at once.
+	RateLimit float64 `mapstructure:"rate_limit"`
+	Burst     int     `mapstructure:"burst"`
+}
+
+type BackfillConfig struct {
+	// Detect is period of searching gaps in candlesticks of watchlist, Lookback is how far in the past gaps are searched.
+	Detect   time.Duration `mapstructure:"detect"`
+	Lookback time.Duration `mapstructure:"lookback"`
+}
+
+type ResampleConfig struct {
+	// Period of building derived candlesticks of watchlist symbols opened during Lookback.
+	Period   time.Duration `mapstructure:"period"`
+	Lookback time.Duration `mapstructure:"lookback"`
+	// Sources are intervals which are aggregated in order of preference, Targets are built intervals.
+	Sources []string `mapstructure:"sources"`
+	Targets []string `mapstructure:"targets"`
+	// SessionOffset is start of daily and weekly candlesticks relative to UTC midnight.
+	SessionOffset time.Duration `mapstructure:"session_offset"`
+	// Tolerance is relative difference of derived and exchange candlesticks which is reported as divergence.
+	Tolerance float64 `mapstructure:"tolerance"`
+}
+
+// IndicatorConfig is instance of registered indicator, e.g. name = "EMA" and params = { window = 21 },
+// missed params have default values.
+type IndicatorConfig struct {
+	Name     string             `mapstructure:"name"`
+	Exchange string             `mapstructure:"exchange"`
+	Symbol   string             `mapstructure:"symbol"`
+	Interval string             `mapstructure:"interval"`
+	Params   map[string]float64 `mapstructure:"params"`
+}
+
+// IndicatorInstances returns instances of indicators defined in config.
+func (c AppConfig) IndicatorInstances() []domain.IndicatorInstance {
+	instances := make([]domain.IndicatorInstance, 0, len(c.Indicators))
+	for _, item := range c.Indicators {
+		instances = append(instances, domain.IndicatorInstance{
+			Name:     item.Name,
+			Exchange: item.Exchange,
+			Symbol:   item.Symbol,
+			Interval: domain.Interval(item.Interval),
+			Params:   item.Params,
+		})
+	}
+	return instances
+}
+
+type ServerConfig struct {
+	Host string `mapstructure:"host"`
+	Port string `mapstructure:"port"`
+}
+
+// MetricsConfig is metrics server of long-running commands. Port of command in Commands overrides Port,
+// so commands started as separate processes on one host do not conflict, MetricsDisabled port disables server.
+type MetricsConfig struct {
+	Host     string            `mapstructure:"host"`
+	Port     string            `mapstructure:"port"`
+	Commands map[string]string `mapstructure:"commands"`
+}
+
+// CommandPort returns port of metrics server of command.
+func (c MetricsConfig) CommandPort(command string) string {
+	if port, has := c.Commands[command]; has {
+		return port
+	}
+	return c.Port
+}
+
+func (c MetricsConfig) validate() error {
+	if err := validateMetricsPort("metrics.port", c.Port); err != nil {
+		return err
+	}
+	for command, port := range c.Commands {
+		if err := validateMetricsPort("metrics.commands."+command, port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateMetricsPort(key, port string) error {
+	if port == MetricsDisabled {
+		return nil
+	}
+	return validatePort(key, port)
+}
+
+type IntervalsConfig struct {
+	Recalculate        time.Duration `mapstructure:"recalculate"`
+	PriceAggregation   time.Duration `mapstructure:"price_aggregation"`
+	LoadPrices         time.Duration `mapstructure:"load_prices"`
+	LoadExchangePrices time.Duration `mapstructure:"load_exchange_prices"`
+	LoadSymbols        time.Duration `mapstructure:"load_symbols"`
+	LoadSnapshot       time.Duration `mapstructure:"load_snapshot"`
+	LoadCandlesticks   time.Duration `mapstructure:"load_candlesticks"`
+	TechAnalysis       time.Duration `mapstructure:"tech_analysis"`
+	Patterns           time.Duration `mapstructure:"patterns"`
+	Alerts             time.Duration `mapstructure:"alerts"`
+}
+
+var defaults = map[string]any{
+	"database.driver":               "postgres",
+	"database.username":             "crypto_app",
+	"database.password":             "developer",
+	"database.host":                 "localhost",
+	"database.port":                 "5433",
+	"database.database":             "crypto_app",
+	"database.max_open_connections": 0,
+	"database.max_idle_connections": 0,
+
+	"logger.level":        logger.DefaultConf.Level,
+	"logger.output_paths": logger.DefaultConf.OutputPaths,
+	"logger.stacktrace":   logger.DefaultConf.Stacktrace,
+
+	"loader.source":      SourceCryptoLoader,
+	"loader.url":         "http://localhost:8081",
+	"loader.replay_file": "",
+	"loader.mode":        ModePoll,
+
+	"stream.binance_url": "wss://stream.binance.com:9443/ws",
+	"stream.bybit_url":   "wss://stream.bybit.com/v5/public/spot",
+	"stream.heartbeat":   20 * time.Second,
+	"stream.flush":       5 * time.Second,
+
+	"backfill.detect":   1 * time.Hour,
+	"backfill.lookback": 7 * 24 * time.Hour,
+
+	"resample.period":         15 * time.Minute,
+	"resample.lookback":       14 * 24 * time.Hour,
+	"resample.sources":        []string{"1h", "1m"},
+	"resample.targets":        []string{"4h", "1d", "1w"},
+	"resample.session_offset": time.Duration(0),
+	"resample.tolerance":      0.001,
+
+	"levels.period":       5 * time.Minute,
+	"levels.lookback":     500,
+	"levels.swing_window": 3,
+	"levels.tolerance":    0.005,
+	"levels.min_touches":  2,
+
+	"spread.default_fee":  0.1,
+	"spread.fees.binance": 0.1,
+	"spread.fees.bybit":   0.1,
+	"spread.window":       5 * time.Minute,
+	"spread.top":          50,
+	"spread.min_net":      0.0,
+
+	"retention.period":                       10 * time.Minute,
+	"retention.batch_size":                   5000,
+	"retention.pause":                        100 * time.Millisecond,
+	"retention.dry_run":                      false,
+	"retention.tables.prices.age":            time.Hour,
+	"retention.tables.price_changes.age":     7 * 24 * time.Hour,
+	"retention.tables.price_aggregation.age": 7 * 24 * time.Hour,
+	"retention.tables.price_history_1m.age":  7 * 24 * time.Hour,
+	"retention.tables.price_history_5m.age":  90 * 24 * time.Hour,
+
+	"history.period":   time.Minute,
+	"history.max_bars": 5000,
+	"history.lateness": 10 * time.Minute,
+
+	"timescale.mode":           TimescaleOff,
+	"timescale.chunk_interval": 24 * time.Hour,
+	"timescale.compress_after": 7 * 24 * time.Hour,
+
+	"notifier.poll":        5 * time.Second,
+	"notifier.max_elapsed": 1 * time.Minute,
+
+	"exchanges.binance.url":        "https://api.binance.com",
+	"exchanges.binance.rate_limit": 50,
+	"exchanges.binance.burst":      100,
+	"exchanges.bybit.url":          "https://api.bybit.com",
+	"exchanges.bybit.rate_limit":   20,
+	"exchanges.bybit.burst":        20,
+
+	"server.host":  "localhost",
+	"server.port":  "8082",
+	"metrics.host": "localhost",
+	"metrics.port": "9082",
+
+	"intervals.recalculate":          5 * time.Second,
+	"intervals.price_aggregation":    1 * time.Hour,
+	"intervals.load_prices":          1 * time.Minute,
+	"intervals.load_exchange_prices": 20 * time.Second,
+	"intervals.load_symbols":         1 * time.Minute,
+	"intervals.load_snapshot":        1 * time.Minute,
+	"intervals.load_candlesticks":    1 * time.Minute,
+	"intervals.tech_analysis":        1 * time.Minute,
+	"intervals.patterns":             1 * time.Minute,
+	"intervals.alerts":               1 * time.Minute,
+}
+
+// New creates viper instance with defaults and environment overrides (CRYPTO_ANALYST_DATABASE_HOST etc.).
+func New() *viper.Viper {
+	v := viper.New()
+	for key, val := range defaults {
+		v.SetDefault(key, val)
+	}
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	return v
+}
+
+// Load reads file (if path not empty) on top of defaults and returns validated config.
+// Priority: flags > env > file > defaults.
+func Load(v *viper.Viper, path string) (AppConfig, error) {
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return AppConfig{}, errors.Wrap(err, "read config file")
+		}
+	}
+	var conf AppConfig
+	if err := v.Unmarshal(&conf); err != nil {
+		return AppConfig{}, errors.Wrap(err, "unmarshal config")
+	}
+	if err := conf.Validate(); err != nil {
+		return AppConfig{}, errors.Wrap(err, "validate config")
+	}
+	return conf, nil
+}
+
+func (c AppConfig) Validate() error {
+	if c.Database.Driver == "" {
+		return errors.New("empty database.driver")
+	}
+	if c.Database.Host == "" {
+		return errors.New("empty database.host")
+	}
+	if c.Database.Database == "" {
+		return errors.New("empty database.database")
+	}
+	if err := validatePort("database.port", c.Database.Port); err != nil {
+		return err
+	}
+	if _, err := zap.ParseAtomicLevel(c.Logger.Level); err != nil {
+		return errors.Wrap(err, "logger.level")
+	}
+	if len(c.Logger.OutputPaths) == 0 {
+		return errors.New("empty logger.output_paths")
+	}
+	switch c.Loader.Source {
+	case SourceCryptoLoader:
+		if err := validateURL("loader.url", c.Loader.URL); err != nil {
+			return err
+		}
+	case SourceExchanges:
+		exchanges := map[string]ExchangeConfig{
+			"exchanges.binance": c.Exchanges.Binance,
+			"exchanges.bybit":   c.Exchanges.Bybit,
+		}
+		for key, exchange := range exchanges {
+			if err := validateURL(key+".url", exchange.URL); err != nil {
+				return err
+			}
+			if exchange.RateLimit <= 0 {
+				return fmt.Errorf("%s.rate_limit must be positive", key)
+			}
+		}
+	case SourceReplay:
+	default:
+		return fmt.Errorf("unknown loader.source: %s", c.Loader.Source)
+	}
+	switch c.Loader.Mode {
+	case ModePoll:
+	case ModeStream:
+		if err := validateURL("stream.binance_url", c.Stream.BinanceURL); err != nil {
+			return err
+		}
+		if err := validateURL("stream.bybit_url", c.Stream.BybitURL); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown loader.mode: %s", c.Loader.Mode)
+	}
+	switch c.Timescale.Mode {
+	case TimescaleOff, TimescaleAuto, TimescaleRequired:
+	default:
+		return fmt.Errorf("unknown timescale.mode: %s", c.Timescale.Mode)
+	}
+	if err := c.Resample.validate(); err != nil {
+		return err
+	}
+	if err := c.Levels.validate(); err != nil {
+		return err
+	}
+	if err := c.Spread.validate(); err != nil {
+		return err
+	}
+	if c.History.MaxBars <= 0 {
+		return fmt.Errorf("history.max_bars must be positive, got %d", c.History.MaxBars)
+	}
+	if err := c.Retention.Validate(); err != nil {
+		return err
+	}
+	if c.Notifier.Poll <= 0 {
+		return fmt.Errorf("notifier.poll must be positive, got %s", c.Notifier.Poll)
+	}
+	if _, err := notifier.NewSinks(c.Notifier, nil); err != nil {
+		return errors.Wrap(err, "notifier")
+	}
+	for i, item := range c.IndicatorInstances() {
+		if _, err := indicator.Default.Instance(item); err != nil {
+			return errors.Wrapf(err, "indicators[%d]", i)
+		}
+	}
+	if err := validatePort("server.port", c.Server.Port); err != nil {
+		return err
+	}
+	if err := c.Metrics.validate(); err != nil {
+		return err
+	}
+	durations := map[string]time.Duration{
+		"intervals.recalculate":          c.Intervals.Recalculate,
+		"intervals.price_aggregation":    c.Intervals.PriceAggregation,
+		"intervals.load_prices":          c.Intervals.LoadPrices,
+		"intervals.load_exchange_prices": c.Intervals.LoadExchangePrices,
+		"intervals.load_symbols":         c.Intervals.LoadSymbols,
+		"intervals.load_snapshot":        c.Intervals.LoadSnapshot,
+		"intervals.load_candlesticks":    c.Intervals.LoadCandlesticks,
+		"intervals.tech_analysis":        c.Intervals.TechAnalysis,
+		"intervals.patterns":             c.Intervals.Patterns,
+		"intervals.alerts":               c.Intervals.Alerts,
+		"stream.heartbeat":               c.Stream.Heartbeat,
+		"stream.flush":                   c.Stream.Flush,
+		"backfill.detect":                c.Backfill.Detect,
+		"backfill.lookback":              c.Backfill.Lookback,
+		"resample.period":                c.Resample.Period,
+		"resample.lookback":              c.Resample.Lookback,
+		"levels.period":                  c.Levels.Period,
+		"spread.window":                  c.Spread.Window,
+		"history.period":                 c.History.Period,
+		"history.lateness":               c.History.Lateness,
+		"timescale.chunk_interval":       c.Timescale.ChunkInterval,
+		"timescale.compress_after":       c.Timescale.CompressAfter,
+		"retention.period":               c.Retention.Period,
+		"notifier.max_elapsed":           c.Notifier.MaxElapsed,
+	}
+	for key, d := range durations {
+		if d <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", key, d)
+		}
+	}
+	return nil
+}
+
+type LevelsConfig struct {
+	// Period of detection of support and resistance zones from the last Lookback candlesticks of watchlist symbols.
+	Period   time.Duration `mapstructure:"period"`
+	Lookback int           `mapstructure:"lookback"`
+	// SwingWindow is number of candlesticks on each side of swing high or low.
+	SwingWindow int `mapstructure:"swing_window"`
+	// Tolerance is relative distance of swings clustered into one zone.
+	Tolerance  float64 `mapstructure:"tolerance"`
+	MinTouches int     `mapstructure:"min_touches"`
+}
+
+type SpreadConfig struct {
+	// DefaultFee is taker fee in percent of exchange which is not listed in Fees.
+	DefaultFee float64            `mapstructure:"default_fee"`
+	Fees       map[string]float64 `mapstructure:"fees"`
+	// Window is age of the latest spreads which are current opportunities, Top is number of them on page.
+	Window time.Duration `mapstructure:"window"`
+	Top    int           `mapstructure:"top"`
+	// MinNet is net spread in percent of opportunities shown on page.
+	MinNet float64 `mapstructure:"min_net"`
+}
+
+type HistoryConfig struct {
+	// Period of rollup of raw prices into tiers of price history.
+	Period time.Duration `mapstructure:"period"`
+	// MaxBars is the most bars of range read from one tier, coarser tier is used for longer range.
+	MaxBars int `mapstructure:"max_bars"`
+	// Lateness is how long before the latest bucket of tier buckets are recalculated by rollup,
+	// prices saved later than that after their bucket are not rolled up.
+	Lateness time.Duration `mapstructure:"lateness"`
+}
+
+type TimescaleConfig struct {
+	// Mode is off (plain Postgres), auto (TimescaleDB is used when extension is available)
+	// or required (start fails without TimescaleDB).
+	Mode string `mapstructure:"mode"`
+	// ChunkInterval is time range of chunk of hypertables, chunks older than CompressAfter are compressed.
+	ChunkInterval time.Duration `mapstructure:"chunk_interval"`
+	CompressAfter time.Duration `mapstructure:"compress_after"`
+}
+
+func (c SpreadConfig) validate() error {
+	if c.DefaultFee < 0 {
+		return fmt.Errorf("spread.default_fee must not be negative, got %v", c.DefaultFee)
+	}
+	for exchange, fee := range c.Fees {
+		if !slices.Contains(domain.ListExchanges, exchange) {
+			return fmt.Errorf("spread.fees: unknown exchange %s", exchange)
+		}
+		if fee < 0 {
+			return fmt.Errorf("spread.fees.%s must not be negative, got %v", exchange, fee)
+		}
+	}
+	if c.Top <= 0 {
+		return fmt.Errorf("spread.top must be positive, got %d", c.Top)
+	}
+	return nil
+}
+
+func (c LevelsConfig) validate() error {
+	if c.SwingWindow <= 0 {
+		return fmt.Errorf("levels.swing_window must be positive, got %d", c.SwingWindow)
+	}
+	if c.Lookback <= 2*c.SwingWindow {
+		return fmt.Errorf("levels.lookback must be greater than doubled levels.swing_window, got %d", c.Lookback)
+	}
+	if c.Tolerance < 0 || c.Tolerance >= 1 {
+		return fmt.Errorf("levels.tolerance must be in [0, 1), got %v", c.Tolerance)
+	}
+	if c.MinTouches <= 0 {
+		return fmt.Errorf("levels.min_touches must be positive, got %d", c.MinTouches)
+	}
+	return nil
+}
+
+func (c ResampleConfig) validate() error {
+	sources, err := domain.ParseIntervals(c.Sources)
+	if err != nil {
+		return errors.Wrap(err, "resample.sources")
+	}
+	targets, err := domain.ParseIntervals(c.Targets)
+	if err != nil {
+		return errors.Wrap(err, "resample.targets")
+	}
+	for _, target := range targets {
+		if !slices.ContainsFunc(sources, func(source domain.Interval) bool {
+			return source.Duration() < target.Duration() && target.Duration()%source.Duration() == 0
+		}) {
+			return fmt.Errorf("resample.targets: %s can not be built from resample.sources", target)
+		}
+	}
+	if c.SessionOffset < 0 || c.SessionOffset >= 24*time.Hour {
+		return fmt.Errorf("resample.session_offset must be in [0, 24h), got %s", c.SessionOffset)
+	}
+	if c.Tolerance < 0 {
+		return fmt.Errorf("resample.tolerance must not be negative, got %v", c.Tolerance)
+	}
+	return nil
+}
+
+// Settings returns effective "key = value" lines sorted by key, secrets are hidden.
+func Settings(v *viper.Viper) []string {
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s = %v", key, hideSecrets(key, v.Get(key))))
+	}
+	return lines
+}
+
+// hideSecrets hides value of secret key, tables of arrays like notifier.sinks are checked key by key.
+func hideSecrets(key string, val any) any {
+	if strings.HasSuffix(key, "password") || strings.HasSuffix(key, "token") || strings.HasSuffix(key, "secret") {
+		return hiddenValue
+	}
+	switch items := val.(type) {
+	case []any:
+		hidden := make([]any, 0, len(items))
+		for _, item := range items {
+			hidden = append(hidden, hideSecrets("", item))
+		}
+		return hidden
+	case []map[string]any:
+		hidden := make([]any, 0, len(items))
+		for _, item := range items {
+			hidden = append(hidden, hideSecrets("", item))
+		}
+		return hidden
+	case map[string]any:
+		hidden := make(map[string]any, len(items))
+		for name, item := range items {
+			hidden[name] = hideSecrets(name, item)
+		}
+		return hidden
+	}
+	return val
+}
+
+func validateURL(key, val string) error {
+	u, err := url.Parse(val)
+	if err != nil {
+		return errors.Wrap(err, key)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%s must be absolute: %s", key, val)
+	}
+	return nil
+}
+
+func validatePort(key, port string) error {
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return errors.Wrap(err, key)
+	}
+	if p <= 0 || p > 65535 {
+		return fmt.Errorf("%s out of range: %d", key, p)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package indicator
+
+import (
+	"github.com/sdcoffey/big"
+	"github.com/sdcoffey/techan"
+)
+
+const (
+	SMA        = "SMA"
+	EMA        = "EMA"
+	WMA        = "WMA"
+	RSI        = "RSI"
+	MACD       = "MACD"
+	Bollinger  = "BOLLINGER"
+	ATR        = "ATR"
+	Stochastic = "STOCHASTIC"
+	OBV        = "OBV"
+	VWAP       = "VWAP"
+)
+
+// OutputValue is name of output of indicators with single series.
+const OutputValue = "value"
+
+const maxWindow = 500
+
+// Default contains all indicators supported by application.
+var Default = NewRegistry()
+
+func init() {
+	windowParam := func(def float64) Param {
+		return Param{Name: "window", Description: "number of candlesticks", Default: def, Min: 1, Max: maxWindow, Integer: true}
+	}
+	window := func(params Params) int { return params.Int("window") }
+	closePrices := func(series *techan.TimeSeries) techan.Indicator { return techan.NewClosePriceIndicator(series) }
+
+	Default.Register(Definition{
+		Name:        SMA,
+		Description: "Simple moving average of close price",
+		Params:      []Param{windowParam(20)},
+		Outputs:     []string{OutputValue},
+		Lookback:    window,
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			return map[string]techan.Indicator{
+				OutputValue: techan.NewSimpleMovingAverage(closePrices(series), params.Int("window")),
+			}
+		},
+	})
+	Default.Register(Definition{
+		Name:        EMA,
+		Description: "Exponential moving average of close price",
+		Params:      []Param{windowParam(20)},
+		Outputs:     []string{OutputValue},
+		Lookback:    window,
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			return map[string]techan.Indicator{
+				OutputValue: techan.NewEMAIndicator(closePrices(series), params.Int("window")),
+			}
+		},
+	})
+	Default.Register(Definition{
+		Name:        WMA,
+		Description: "Linearly weighted moving average of close price",
+		Params:      []Param{windowParam(20)},
+		Outputs:     []string{OutputValue},
+		Lookback:    window,
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			return map[string]techan.Indicator{
+				OutputValue: NewWMAIndicator(closePrices(series), params.Int("window")),
+			}
+		},
+	})
+	Default.Register(Definition{
+		Name:        RSI,
+		Description: "Relative strength index of close price",
+		Params:      []Param{windowParam(14)},
+		Outputs:     []string{OutputValue},
+		Lookback:    func(params Params) int { return params.Int("window") + 1 },
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			return map[string]techan.Indicator{
+				OutputValue: techan.NewRelativeStrengthIndexIndicator(closePrices(series), params.Int("window")),
+			}
+		},
+	})
+	Default.Register(Definition{
+		Name:        MACD,
+		Description: "Moving average convergence divergence of close price",
+		Params: []Param{
+			{Name: "fast", Description: "window of fast EMA", Default: 12, Min: 1, Max: maxWindow, Integer: true},
+			{Name: "slow", Description: "window of slow EMA", Default: 26, Min: 1, Max: maxWindow, Integer: true},
+			{Name: "signal", Description: "window of signal EMA", Default: 9, Min: 1, Max: maxWindow, Integer: true},
+		},
+		Outputs: []string{"macd", "signal", "histogram"},
+		Lookback: func(params Params) int {
+			return max(params.Int("fast"), params.Int("slow")) + params.Int("signal") - 1
+		},
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			macd := techan.NewMACDIndicator(closePrices(series), params.Int("fast"), params.Int("slow"))
+			return map[string]techan.Indicator{
+				"macd":      macd,
+				"signal":    techan.NewEMAIndicator(macd, params.Int("signal")),
+				"histogram": techan.NewMACDHistogramIndicator(macd, params.Int("signal")),
+			}
+		},
+	})
+	Default.Register(Definition{
+		Name:        Bollinger,
+		Description: "Bollinger bands of close price",
+		Params: []Param{
+			windowParam(20),
+			{Name: "sigma", Description: "width of bands in standard deviations", Default: 2, Min: 0.1, Max: 10},
+		},
+		Outputs:  []string{"upper", "middle", "lower"},
+		Lookback: window,
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			prices := closePrices(series)
+			return map[string]techan.Indicator{
+				"upper":  techan.NewBollingerUpperBandIndicator(prices, params.Int("window"), params["sigma"]),
+				"middle": techan.NewSimpleMovingAverage(prices, params.Int("window")),
+				"lower":  techan.NewBollingerLowerBandIndicator(prices, params.Int("window"), params["sigma"]),
+			}
+		},
+	})
+	Default.Register(Definition{
+		Name:        ATR,
+		Description: "Average true range",
+		Params:      []Param{windowParam(14)},
+		Outputs:     []string{OutputValue},
+		Lookback:    func(params Params) int { return params.Int("window") + 1 },
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			return map[string]techan.Indicator{
+				OutputValue: techan.NewAverageTrueRangeIndicator(series, params.Int("window")),
+			}
+		},
+	})
+	Default.Register(Definition{
+		Name:        Stochastic,
+		Description: "Stochastic oscillator, k is fast line and d is its moving average",
+		Params: []Param{
+			windowParam(14),
+			{Name: "smooth", Description: "window of d line", Default: 3, Min: 1, Max: maxWindow, Integer: true},
+		},
+		Outputs: []string{"k", "d"},
+		Lookback: func(params Params) int {
+			return params.Int("window") + params.Int("smooth") - 1
+		},
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			k := techan.NewFastStochasticIndicator(series, params.Int("window"))
+			return map[string]techan.Indicator{
+				"k": k,
+				"d": techan.NewSlowStochasticIndicator(k, params.Int("smooth")),
+			}
+		},
+	})
+	Default.Register(Definition{
+		Name:        OBV,
+		Description: "On balance volume accumulated from the first candlestick of series",
+		Outputs:     []string{OutputValue},
+		Lookback:    func(Params) int { return 2 },
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			return map[string]techan.Indicator{OutputValue: NewOBVIndicator(series)}
+		},
+	})
+	Default.Register(Definition{
+		Name:        VWAP,
+		Description: "Volume weighted average of typical price during window",
+		Params:      []Param{windowParam(20)},
+		Outputs:     []string{OutputValue},
+		Lookback:    window,
+		Build: func(series *techan.TimeSeries, params Params) map[string]techan.Indicator {
+			return map[string]techan.Indicator{OutputValue: NewVWAPIndicator(series, params.Int("window"))}
+		},
+	})
+}
+
+// wmaIndicator is weighted moving average, the latest value has weight of window, the oldest one has weight 1.
+type wmaIndicator struct {
+	indicator techan.Indicator
+	window    int
+}
+
+func NewWMAIndicator(indicator techan.Indicator, window int) techan.Indicator {
+	return wmaIndicator{indicator: indicator, window: window}
+}
+
+func (wma wmaIndicator) Calculate(index int) big.Decimal {
+	if index < wma.window-1 {
+		return big.ZERO
+	}
+	sum := big.ZERO
+	for i := 0; i < wma.window; i++ {
+		sum = sum.Add(wma.indicator.Calculate(index - i).Mul(big.NewFromInt(wma.window - i)))
+	}
+	return sum.Div(big.NewFromInt(wma.window * (wma.window + 1) / 2))
+}
+
+// obvIndicator adds volume of candlestick closed above previous close and subtracts volume of one closed below.
+type obvIndicator struct {
+	series *techan.TimeSeries
+}
+
+func NewOBVIndicator(series *techan.TimeSeries) techan.Indicator {
+	return obvIndicator{series: series}
+}
+
+func (obv obvIndicator) Calculate(index int) big.Decimal {
+	result := big.ZERO
+	for i := 1; i <= index; i++ {
+		current, prev := obv.series.Candles[i], obv.series.Candles[i-1]
+		switch {
+		case current.ClosePrice.GT(prev.ClosePrice):
+			result = result.Add(current.Volume)
+		case current.ClosePrice.LT(prev.ClosePrice):
+			result = result.Sub(current.Volume)
+		}
+	}
+	return result
+}
+
+type vwapIndicator struct {
+	series *techan.TimeSeries
+	prices techan.Indicator
+	window int
+}
+
+func NewVWAPIndicator(series *techan.TimeSeries, window int) techan.Indicator {
+	return vwapIndicator{series: series, prices: techan.NewTypicalPriceIndicator(series), window: window}
+}
+
+func (vwap vwapIndicator) Calculate(index int) big.Decimal {
+	if index < vwap.window-1 {
+		return big.ZERO
+	}
+	amount, volume := big.ZERO, big.ZERO
+	for i := index - vwap.window + 1; i <= index; i++ {
+		candleVolume := vwap.series.Candles[i].Volume
+		amount = amount.Add(vwap.prices.Calculate(i).Mul(candleVolume))
+		volume = volume.Add(candleVolume)
+	}
+	if volume.Zero() {
+		return big.ZERO
+	}
+	return amount.Div(volume)
+}
@@ -0,0 +1,155 @@
+package indicator
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/sdcoffey/big"
+	"github.com/sdcoffey/techan"
+)
+
+type testCandle struct {
+	high, low, close, volume float64
+}
+
+func newSeries(candles ...testCandle) *techan.TimeSeries {
+	series := techan.NewTimeSeries()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, item := range candles {
+		candle := techan.NewCandle(techan.NewTimePeriod(start.Add(time.Duration(i)*time.Hour), time.Hour))
+		candle.OpenPrice = big.NewDecimal(item.close)
+		candle.MaxPrice = big.NewDecimal(item.high)
+		candle.MinPrice = big.NewDecimal(item.low)
+		candle.ClosePrice = big.NewDecimal(item.close)
+		candle.Volume = big.NewDecimal(item.volume)
+		series.AddCandle(candle)
+	}
+	return series
+}
+
+func closeSeries(closes ...float64) *techan.TimeSeries {
+	candles := make([]testCandle, 0, len(closes))
+	for _, val := range closes {
+		candles = append(candles, testCandle{high: val, low: val, close: val, volume: 1})
+	}
+	return newSeries(candles...)
+}
+
+func assertValue(t *testing.T, name string, got big.Decimal, want float64) {
+	t.Helper()
+	if math.Abs(got.Float()-want) > 1e-9 {
+		t.Errorf("%s: got %v, want %v", name, got.Float(), want)
+	}
+}
+
+func TestWMA(t *testing.T) {
+	wma := NewWMAIndicator(techan.NewClosePriceIndicator(closeSeries(1, 2, 3, 4, 5)), 3)
+	assertValue(t, "before window", wma.Calculate(1), 0)
+	assertValue(t, "first value", wma.Calculate(2), (3*3+2*2+1)/6.0)
+	assertValue(t, "last value", wma.Calculate(4), (5*3+4*2+3)/6.0)
+}
+
+func TestOBV(t *testing.T) {
+	series := newSeries(
+		testCandle{high: 10, low: 10, close: 10, volume: 1},
+		testCandle{high: 11, low: 11, close: 11, volume: 2},
+		testCandle{high: 11, low: 11, close: 11, volume: 3},
+		testCandle{high: 9, low: 9, close: 9, volume: 4},
+		testCandle{high: 12, low: 12, close: 12, volume: 5},
+	)
+	obv := NewOBVIndicator(series)
+	assertValue(t, "first candlestick", obv.Calculate(0), 0)
+	assertValue(t, "unchanged close", obv.Calculate(2), 2)
+	assertValue(t, "last value", obv.Calculate(4), 2-4+5)
+}
+
+func TestVWAP(t *testing.T) {
+	series := newSeries(
+		testCandle{high: 100, low: 100, close: 100, volume: 10},
+		testCandle{high: 12, low: 9, close: 12, volume: 1},
+		testCandle{high: 15, low: 12, close: 12, volume: 3},
+		testCandle{high: 15, low: 12, close: 12, volume: 0},
+		testCandle{high: 15, low: 12, close: 12, volume: 0},
+	)
+	vwap := NewVWAPIndicator(series, 2)
+	assertValue(t, "before window", vwap.Calculate(0), 0)
+	// Typical prices are 11 and 13, candlestick out of window is not counted.
+	assertValue(t, "window", vwap.Calculate(2), (11*1+13*3)/4.0)
+	assertValue(t, "zero volume", vwap.Calculate(4), 0)
+}
+
+func TestRegistryParams(t *testing.T) {
+	params, err := Default.Params("sma", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["window"] != 20 {
+		t.Errorf("got default window %v, want 20", params["window"])
+	}
+	params, err = Default.Params(Bollinger, map[string]float64{"sigma": 2.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["sigma"] != 2.5 || params["window"] != 20 {
+		t.Errorf("got params %v", params)
+	}
+	for name, item := range map[string]struct {
+		indicator string
+		params    map[string]float64
+	}{
+		"fractional window": {indicator: SMA, params: map[string]float64{"window": 2.5}},
+		"zero window":       {indicator: SMA, params: map[string]float64{"window": 0}},
+		"too large window":  {indicator: SMA, params: map[string]float64{"window": maxWindow + 1}},
+		"unknown param":     {indicator: SMA, params: map[string]float64{"period": 10}},
+		"param of OBV":      {indicator: OBV, params: map[string]float64{"window": 10}},
+	} {
+		if _, err := Default.Params(item.indicator, item.params); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := Default.Params("unknown", nil); !errors.Is(err, ErrUnknownIndicator) {
+		t.Errorf("got error %v, want %v", err, ErrUnknownIndicator)
+	}
+}
+
+func TestRegistryLookback(t *testing.T) {
+	for _, item := range []struct {
+		indicator string
+		params    map[string]float64
+		lookback  int
+	}{
+		{indicator: SMA, lookback: 20},
+		{indicator: WMA, params: map[string]float64{"window": 5}, lookback: 5},
+		{indicator: RSI, lookback: 15},
+		{indicator: ATR, params: map[string]float64{"window": 10}, lookback: 11},
+		{indicator: MACD, lookback: 34},
+		{indicator: MACD, params: map[string]float64{"fast": 30, "slow": 10, "signal": 5}, lookback: 34},
+		{indicator: Stochastic, lookback: 16},
+		{indicator: OBV, lookback: 2},
+		{indicator: VWAP, lookback: 20},
+	} {
+		lookback, err := Default.Lookback(item.indicator, item.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lookback != item.lookback {
+			t.Errorf("%s %v: got lookback %d, want %d", item.indicator, item.params, lookback, item.lookback)
+		}
+	}
+}
+
+func TestRegistryCalculate(t *testing.T) {
+	series := closeSeries(1, 2, 3, 4, 5)
+	if _, err := Default.Calculate(series, WMA, map[string]float64{"window": 6}); !errors.Is(err, ErrNotEnoughData) {
+		t.Errorf("got error %v, want %v", err, ErrNotEnoughData)
+	}
+	values, err := Default.Calculate(series, WMA, map[string]float64{"window": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(values[OutputValue]-26.0/6) > 1e-9 {
+		t.Errorf("got value %v, want %v", values[OutputValue], 26.0/6)
+	}
+}
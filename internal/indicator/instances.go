@@ -0,0 +1,69 @@
+package indicator
+
+import (
+	"context"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/pkg/errors"
+)
+
+var _ domain.IndicatorInstanceStorage = (*Instances)(nil)
+
+// Instances joins read only instances defined in config with instances created by API.
+type Instances struct {
+	static  []domain.IndicatorInstance
+	storage domain.IndicatorInstanceStorage
+}
+
+// NewInstances validates static instances by registry.
+func NewInstances(
+	registry *Registry, static []domain.IndicatorInstance, storage domain.IndicatorInstanceStorage,
+) (*Instances, error) {
+	instances := make([]domain.IndicatorInstance, 0, len(static))
+	for _, item := range static {
+		instance, err := registry.Instance(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "indicator instance %s", item.Name)
+		}
+		instance.ReadOnly = true
+		instances = append(instances, instance)
+	}
+	return &Instances{static: instances, storage: storage}, nil
+}
+
+func (i *Instances) IndicatorInstances(ctx context.Context) ([]domain.IndicatorInstance, error) {
+	stored, err := i.storage.IndicatorInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instances := append([]domain.IndicatorInstance{}, i.static...)
+	for _, item := range stored {
+		if !i.isStatic(item.ID) {
+			instances = append(instances, item)
+		}
+	}
+	return instances, nil
+}
+
+func (i *Instances) SaveIndicatorInstance(ctx context.Context, instance domain.IndicatorInstance) error {
+	if i.isStatic(instance.ID) {
+		return domain.ErrIndicatorInstanceReadOnly
+	}
+	return i.storage.SaveIndicatorInstance(ctx, instance)
+}
+
+func (i *Instances) DeleteIndicatorInstance(ctx context.Context, id string) error {
+	if i.isStatic(id) {
+		return domain.ErrIndicatorInstanceReadOnly
+	}
+	return i.storage.DeleteIndicatorInstance(ctx, id)
+}
+
+func (i *Instances) isStatic(id string) bool {
+	for _, item := range i.static {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
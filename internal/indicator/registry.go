@@ -0,0 +1,191 @@
+package indicator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/pkg/errors"
+	"github.com/sdcoffey/techan"
+)
+
+var (
+	ErrUnknownIndicator = errors.New("unknown indicator")
+	ErrNotEnoughData    = errors.New("not enough candlesticks")
+)
+
+// Param describes parameter of indicator, values out of [Min, Max] are rejected.
+type Param struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Default     float64 `json:"default"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	Integer     bool    `json:"integer"`
+}
+
+// Params are values of indicator parameters by name.
+type Params map[string]float64
+
+func (p Params) Int(name string) int {
+	return int(p[name])
+}
+
+// Definition is registered indicator: parameters schema and named output series built on time series.
+type Definition struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Params      []Param  `json:"params"`
+	Outputs     []string `json:"outputs"`
+
+	// Lookback returns number of candlesticks required for the first value.
+	Lookback func(params Params) int `json:"-"`
+	// Build returns indicator of every output.
+	Build func(series *techan.TimeSeries, params Params) map[string]techan.Indicator `json:"-"`
+}
+
+// Registry keeps indicator definitions by name, names are case insensitive.
+type Registry struct {
+	definitions map[string]Definition
+	mu          sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{definitions: make(map[string]Definition)}
+}
+
+func (r *Registry) Register(definition Definition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.definitions[strings.ToUpper(definition.Name)] = definition
+}
+
+func (r *Registry) Definition(name string) (Definition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	definition, has := r.definitions[strings.ToUpper(name)]
+	if !has {
+		return Definition{}, errors.Wrap(ErrUnknownIndicator, name)
+	}
+	return definition, nil
+}
+
+// Definitions returns registered indicators ordered by name.
+func (r *Registry) Definitions() []Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	definitions := make([]Definition, 0, len(r.definitions))
+	for _, definition := range r.definitions {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
+
+// Params validates params of indicator and fills missed ones with defaults.
+func (r *Registry) Params(name string, params map[string]float64) (Params, error) {
+	definition, err := r.Definition(name)
+	if err != nil {
+		return nil, err
+	}
+	return definition.params(params)
+}
+
+func (d Definition) params(params map[string]float64) (Params, error) {
+	result := make(Params, len(d.Params))
+	for _, param := range d.Params {
+		val, has := params[param.Name]
+		if !has {
+			val = param.Default
+		}
+		if param.Integer && val != math.Trunc(val) {
+			return nil, fmt.Errorf("%s: %s must be integer, got %v", d.Name, param.Name, val)
+		}
+		if val < param.Min || val > param.Max {
+			return nil, fmt.Errorf("%s: %s must be in [%v, %v], got %v", d.Name, param.Name, param.Min, param.Max, val)
+		}
+		result[param.Name] = val
+	}
+	for name := range params {
+		if _, has := result[name]; !has {
+			return nil, fmt.Errorf("%s: unknown param %s", d.Name, name)
+		}
+	}
+	return result, nil
+}
+
+// Lookback returns number of candlesticks required for the first value of indicator.
+func (r *Registry) Lookback(name string, params map[string]float64) (int, error) {
+	definition, err := r.Definition(name)
+	if err != nil {
+		return 0, err
+	}
+	validParams, err := definition.params(params)
+	if err != nil {
+		return 0, err
+	}
+	return definition.Lookback(validParams), nil
+}
+
+// Calculate returns outputs of indicator on the last candlestick of series.
+// ErrNotEnoughData is returned when series is shorter than lookback of indicator.
+func (r *Registry) Calculate(series *techan.TimeSeries, name string, params map[string]float64) (map[string]float64, error) {
+	definition, err := r.Definition(name)
+	if err != nil {
+		return nil, err
+	}
+	validParams, err := definition.params(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(series.Candles) < definition.Lookback(validParams) {
+		return nil, ErrNotEnoughData
+	}
+	index := series.LastIndex()
+	values := make(map[string]float64, len(definition.Outputs))
+	for output, indicator := range definition.Build(series, validParams) {
+		val := indicator.Calculate(index).Float()
+		// Flat series gives division by zero in oscillators, such values are not valid json numbers.
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			val = 0
+		}
+		values[output] = val
+	}
+	return values, nil
+}
+
+// Instance validates indicator instance, fills defaults of params and ID,
+// e.g. binance_BTCUSDT_4h_EMA_21 for EMA(21) on BTCUSDT/4h. Instances with default and explicit params have the same ID.
+func (r *Registry) Instance(instance domain.IndicatorInstance) (domain.IndicatorInstance, error) {
+	instance = instance.Normalize()
+	if !slices.Contains(domain.ListExchanges, instance.Exchange) {
+		return domain.IndicatorInstance{}, fmt.Errorf("unknown exchange: %s", instance.Exchange)
+	}
+	if instance.Symbol == "" {
+		return domain.IndicatorInstance{}, errors.New("empty symbol")
+	}
+	if !instance.Interval.Valid() {
+		return domain.IndicatorInstance{}, fmt.Errorf("unknown interval: %s", instance.Interval)
+	}
+	definition, err := r.Definition(instance.Name)
+	if err != nil {
+		return domain.IndicatorInstance{}, err
+	}
+	params, err := definition.params(instance.Params)
+	if err != nil {
+		return domain.IndicatorInstance{}, err
+	}
+	instance.Name = definition.Name
+	instance.Params = params
+	parts := []string{instance.Exchange, instance.Symbol, instance.Interval.String(), instance.Name}
+	for _, param := range definition.Params {
+		parts = append(parts, strconv.FormatFloat(params[param.Name], 'f', -1, 64))
+	}
+	instance.ID = strings.Join(parts, "_")
+	return instance, nil
+}
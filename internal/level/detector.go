@@ -0,0 +1,178 @@
+package level
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+)
+
+const (
+	DefaultSwingWindow = 3
+	DefaultTolerance   = 0.005
+	DefaultMinTouches  = 2
+)
+
+// Params of level detection.
+type Params struct {
+	// SwingWindow is number of candlesticks on each side which high of swing high (low of swing low) must exceed.
+	SwingWindow int
+	// Tolerance is relative distance of swings clustered into one zone, zone is widened by half of it on both sides.
+	Tolerance float64
+	// MinTouches is number of swings which form zone.
+	MinTouches int
+}
+
+func DefaultParams() Params {
+	return Params{SwingWindow: DefaultSwingWindow, Tolerance: DefaultTolerance, MinTouches: DefaultMinTouches}
+}
+
+type swing struct {
+	price float64
+	time  time.Time
+}
+
+// Detect returns zones of swings of candlesticks ordered by open time, ordered by price.
+// Breaks and retests of zone are counted since its first swing.
+func Detect(candles []dto.Candlestick, params Params) []domain.Level {
+	if len(candles) == 0 {
+		return nil
+	}
+	var (
+		levels []domain.Level
+		now    = time.Now()
+		last   = candles[len(candles)-1]
+	)
+	for _, cluster := range clusters(swings(candles, params.SwingWindow), params.Tolerance) {
+		if len(cluster) < params.MinTouches {
+			continue
+		}
+		level := domain.Level{
+			Exchange:   last.Exchange,
+			Symbol:     last.Symbol,
+			Interval:   domain.Interval(last.Interval),
+			Low:        cluster[0].price * (1 - params.Tolerance/2),
+			High:       cluster[len(cluster)-1].price * (1 + params.Tolerance/2),
+			Touches:    len(cluster),
+			FirstTouch: cluster[0].time,
+			LastTouch:  cluster[0].time,
+			UpdatedAt:  now,
+		}
+		for _, item := range cluster {
+			if item.time.Before(level.FirstTouch) {
+				level.FirstTouch = item.time
+			}
+			if item.time.After(level.LastTouch) {
+				level.LastTouch = item.time
+			}
+		}
+		track(&level, candles)
+		level.Kind = domain.ResistanceLevel
+		if position(level, domain.PriceFloat(last.ClosePrice)) > 0 {
+			level.Kind = domain.SupportLevel
+		}
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// swings returns swing highs and lows, candlestick is swing when its high (low) is strictly above (below)
+// highs (lows) of window candlesticks on both sides.
+func swings(candles []dto.Candlestick, window int) []swing {
+	var result []swing
+	for i := window; i < len(candles)-window; i++ {
+		isHigh, isLow := true, true
+		for j := i - window; j <= i+window && (isHigh || isLow); j++ {
+			if j == i {
+				continue
+			}
+			if candles[j].HighPrice.GreaterThanOrEqual(candles[i].HighPrice) {
+				isHigh = false
+			}
+			if candles[j].LowPrice.LessThanOrEqual(candles[i].LowPrice) {
+				isLow = false
+			}
+		}
+		if isHigh {
+			result = append(result, swing{price: domain.PriceFloat(candles[i].HighPrice), time: candles[i].OpenTime})
+		}
+		if isLow {
+			result = append(result, swing{price: domain.PriceFloat(candles[i].LowPrice), time: candles[i].OpenTime})
+		}
+	}
+	return result
+}
+
+// clusters groups swings ordered by price, swing joins cluster when it is within tolerance of the lowest swing of cluster.
+func clusters(swings []swing, tolerance float64) [][]swing {
+	sort.Slice(swings, func(i, j int) bool { return swings[i].price < swings[j].price })
+	var result [][]swing
+	for _, item := range swings {
+		n := len(result)
+		if n > 0 && item.price-result[n-1][0].price <= result[n-1][0].price*tolerance {
+			result[n-1] = append(result[n-1], item)
+			continue
+		}
+		result = append(result, []swing{item})
+	}
+	return result
+}
+
+// track counts breaks and retests of level by candlesticks opened since the first touch.
+func track(level *domain.Level, candles []dto.Candlestick) {
+	// side of the last close outside of zone: -1 below, 1 above, 0 unknown.
+	var side float64
+	for _, candle := range candles {
+		if candle.OpenTime.Before(level.FirstTouch) {
+			continue
+		}
+		closeSide := position(*level, domain.PriceFloat(candle.ClosePrice))
+		if closeSide == 0 {
+			continue
+		}
+		switch {
+		case side != 0 && closeSide != side:
+			level.Breaks++
+			level.LastBreak = timePtr(candle.OpenTime)
+		case level.LastBreak != nil && touches(*level, candle):
+			level.Retests++
+			level.LastRetest = timePtr(candle.OpenTime)
+		}
+		side = closeSide
+	}
+}
+
+func position(level domain.Level, price float64) float64 {
+	switch {
+	case price > level.High:
+		return 1
+	case price < level.Low:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func touches(level domain.Level, candle dto.Candlestick) bool {
+	return domain.PriceFloat(candle.LowPrice) <= level.High && domain.PriceFloat(candle.HighPrice) >= level.Low
+}
+
+// Nearest returns the highest zone below price and the lowest zone above price, ok flags are false when there is no zone.
+func Nearest(levels []domain.Level, price float64) (below domain.Level, okBelow bool, above domain.Level, okAbove bool) {
+	belowHigh, aboveLow := math.Inf(-1), math.Inf(1)
+	for _, item := range levels {
+		if item.High < price && item.High > belowHigh {
+			below, okBelow, belowHigh = item, true, item.High
+		}
+		if item.Low > price && item.Low < aboveLow {
+			above, okAbove, aboveLow = item, true, item.Low
+		}
+	}
+	return below, okBelow, above, okAbove
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
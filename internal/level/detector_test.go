@@ -0,0 +1,155 @@
+package level
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/shopspring/decimal"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// candle returns candlestick opened i minutes after start, open price is close price.
+func candle(i int, high, low, close float64) dto.Candlestick {
+	return dto.Candlestick{
+		Exchange:   domain.BinanceExchange,
+		Symbol:     domain.BTCUSDT,
+		Interval:   domain.OneMinuteInterval.String(),
+		OpenTime:   start.Add(time.Duration(i) * time.Minute),
+		OpenPrice:  decimal.NewFromFloat(close),
+		HighPrice:  decimal.NewFromFloat(high),
+		LowPrice:   decimal.NewFromFloat(low),
+		ClosePrice: decimal.NewFromFloat(close),
+	}
+}
+
+func minute(i int) time.Time {
+	return start.Add(time.Duration(i) * time.Minute)
+}
+
+func TestSwings(t *testing.T) {
+	candles := []dto.Candlestick{
+		candle(0, 10, 9, 9.5),
+		candle(1, 10.5, 8, 9.5),
+		candle(2, 11, 6, 9.5),
+		candle(3, 13, 7, 9.5),
+		// High equal to swing high does not make swing of neighbour.
+		candle(4, 13, 8, 9.5),
+		candle(5, 12, 9, 9.5),
+	}
+	got := swings(candles, 1)
+	want := []swing{{price: 6, time: minute(2)}}
+	if len(got) != len(want) {
+		t.Fatalf("got swings %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("swing %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	candles[4] = candle(4, 12, 8, 9.5)
+	got = swings(candles, 1)
+	if len(got) != 2 || got[1] != (swing{price: 13, time: minute(3)}) {
+		t.Errorf("got swings %+v, want swing high 13", got)
+	}
+}
+
+func TestClusters(t *testing.T) {
+	got := clusters([]swing{
+		{price: 200},
+		{price: 101},
+		{price: 100.4},
+		{price: 100},
+	}, 0.005)
+	want := [][]float64{{100, 100.4}, {101}, {200}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d clusters, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("cluster %d: got %+v, want prices %v", i, got[i], want[i])
+		}
+		for j := range want[i] {
+			if got[i][j].price != want[i][j] {
+				t.Errorf("cluster %d: got %+v, want prices %v", i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestTrack(t *testing.T) {
+	level := domain.Level{Low: 99, High: 101, FirstTouch: minute(1)}
+	track(&level, []dto.Candlestick{
+		// Candlestick before the first touch is not counted.
+		candle(0, 103, 101.5, 102),
+		candle(1, 99, 97, 98),
+		candle(2, 103, 101.5, 102),
+		// Close inside zone keeps side of the last close outside of it.
+		candle(3, 101, 99.5, 100),
+		candle(4, 103, 100.5, 102),
+		candle(5, 104, 102, 103),
+		candle(6, 99, 97, 98),
+	})
+	if level.Breaks != 2 || level.LastBreak == nil || !level.LastBreak.Equal(minute(6)) {
+		t.Errorf("got %d breaks, the last %v, want 2 at %s", level.Breaks, level.LastBreak, minute(6))
+	}
+	if level.Retests != 1 || level.LastRetest == nil || !level.LastRetest.Equal(minute(4)) {
+		t.Errorf("got %d retests, the last %v, want 1 at %s", level.Retests, level.LastRetest, minute(4))
+	}
+}
+
+func TestNearest(t *testing.T) {
+	levels := []domain.Level{
+		{Low: 110, High: 111},
+		{Low: 90, High: 92},
+		{Low: 101, High: 102},
+		{Low: 98, High: 99},
+	}
+	for price, want := range map[float64]struct {
+		below, above float64
+		okBelow      bool
+		okAbove      bool
+	}{
+		100: {below: 98, okBelow: true, above: 101, okAbove: true},
+		91:  {above: 98, okAbove: true},
+		120: {below: 110, okBelow: true},
+	} {
+		below, okBelow, above, okAbove := Nearest(levels, price)
+		if okBelow != want.okBelow || okAbove != want.okAbove ||
+			(okBelow && below.Low != want.below) || (okAbove && above.Low != want.above) {
+			t.Errorf("price %v: got below %+v %t, above %+v %t", price, below, okBelow, above, okAbove)
+		}
+	}
+}
+
+func TestDetectKind(t *testing.T) {
+	candles := []dto.Candlestick{
+		candle(0, 106, 101, 104),
+		candle(1, 105, 100, 103),
+		candle(2, 107, 102, 106),
+		candle(3, 106, 100.2, 104),
+		candle(4, 106, 100.25, 105),
+	}
+	params := Params{SwingWindow: 1, Tolerance: DefaultTolerance, MinTouches: 2}
+	levels := Detect(candles, params)
+	if len(levels) != 1 {
+		t.Fatalf("got levels %+v, want zone of two swing lows", levels)
+	}
+	level := levels[0]
+	if level.Touches != 2 || !level.FirstTouch.Equal(minute(1)) || !level.LastTouch.Equal(minute(3)) {
+		t.Errorf("got level %+v", level)
+	}
+	if level.Kind != domain.SupportLevel {
+		t.Errorf("close above zone: got %s, want support", level.Kind)
+	}
+
+	// Close inside zone above its midpoint is not above the zone.
+	candles[4] = candle(4, 106, 100.25, 100.3)
+	levels = Detect(candles, params)
+	if len(levels) != 1 || levels[0].Kind != domain.ResistanceLevel {
+		t.Errorf("close inside zone: got levels %+v, want resistance", levels)
+	}
+}
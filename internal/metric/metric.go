@@ -56,4 +56,141 @@ var (
 		Name:      "save_new_symbol",
 		Help:      "The total save new symbol",
 	})
+
+	StreamReconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "stream_reconnects",
+		Help:      "The total reconnects of exchange stream",
+	}, []string{"exchange"})
+	StreamMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "stream_messages",
+		Help:      "The total messages received from exchange stream",
+	}, []string{"exchange"})
+	StreamGaps = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "stream_gaps",
+		Help:      "The total gaps detected in candlesticks of exchange stream",
+	}, []string{"exchange"})
+
+	BackfillCandlesticks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "backfill_candlesticks",
+		Help:      "The total candlesticks loaded by backfill",
+	}, []string{"exchange", "interval"})
+	BackfillJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "backfill_jobs",
+		Help:      "The total backfill jobs by status",
+	}, []string{"status"})
+	BackfillRemaining = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "backfill_remaining_seconds",
+		Help:      "The time range which is left to backfill in running job",
+	}, []string{"exchange", "symbol", "interval"})
+
+	ResampleCandlesticks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "resample_candlesticks",
+		Help:      "The total derived candlesticks saved by resampler",
+	}, []string{"exchange", "interval"})
+	ResampleDivergences = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "resample_divergences",
+		Help:      "The total fields of derived candlesticks which differ from exchange candlesticks",
+	}, []string{"exchange", "interval"})
+
+	IndicatorSnapshots = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "indicator_snapshots",
+		Help:      "The total calculated indicator snapshots",
+	})
+	IndicatorValues = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "indicator_values",
+		Help:      "The total calculated values of indicator instances",
+	})
+	IndicatorDuration = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "indicator_duration",
+		Help:      "The total duration of indicators calculation in ms",
+	})
+
+	CandlestickPatterns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "candlestick_patterns",
+		Help:      "The total candlestick patterns saved by detector, pattern is saved on every run while its candlestick is in depth",
+	}, []string{"exchange", "interval", "pattern"})
+
+	Levels = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "levels",
+		Help:      "The number of support and resistance levels of series saved by the last detection",
+	}, []string{"exchange", "symbol", "interval"})
+	LevelEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "level_events",
+		Help:      "The total price changes crossed levels",
+	}, []string{"exchange", "event"})
+
+	Alerts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "alerts",
+		Help:      "The total fired alerts by kind of rule",
+	}, []string{"kind"})
+
+	Notifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "notifications",
+		Help:      "The total alerts delivered to sink by status",
+	}, []string{"sink", "status"})
+	NotificationDuration = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "notification_duration",
+		Help:      "The total duration of delivery to sink with retries in ms",
+	}, []string{"sink"})
+
+	Listings = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "listings",
+		Help:      "The total symbols listed on exchange, cross kind is symbol traded on another exchange before",
+	}, []string{"exchange", "kind"})
+	ListingsCompleted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "listings_completed",
+		Help:      "The total listings which price action of the first 24h is tracked",
+	})
+
+	Spreads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "spreads",
+		Help:      "The total new or changed cross-exchange spreads of symbol minutes",
+	}, []string{"buy_exchange", "sell_exchange"})
+	SpreadOpportunities = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "spread_opportunities",
+		Help:      "The total new or changed cross-exchange spreads which are profitable after fees",
+	}, []string{"buy_exchange", "sell_exchange"})
+
+	RetentionRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "retention_rows",
+		Help:      "The total rows deleted by retention policies, mode dry_run counts rows which would be deleted",
+	}, []string{"table", "mode"})
+	RetentionDuration = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "retention_duration",
+		Help:      "The total duration of retention policies of table in ms",
+	}, []string{"table"})
+
+	PriceHistoryRollups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "price_history_rollups",
+		Help:      "The total rollups of prices into tier of price history",
+	}, []string{"tier"})
+	PriceHistoryRollupDuration = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "price_history_rollup_duration",
+		Help:      "The total duration of rollups of prices into tier of price history in ms",
+	}, []string{"tier"})
 )
@@ -0,0 +1,212 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"text/template"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultPoll       = 5 * time.Second
+	DefaultMaxElapsed = time.Minute
+)
+
+// Sink delivers message rendered from alert to one channel.
+type Sink interface {
+	Name() string
+	Render(alert domain.Alert) (Message, error)
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is alert with subject and body rendered by templates of sink.
+type Message struct {
+	Subject string
+	Body    string
+	Alert   domain.Alert
+}
+
+var _ domain.AlertNotifier = (*Notifier)(nil)
+
+// Notifier saves alerts to outbox and delivers them from outbox to every sink in Run, so alerts fired by
+// calculation are delivered by notify command and are not lost on restart. Alert which is not delivered to sink
+// after retries is saved as dead letter. Delivery is at least once: alert claimed by stopped notifier is claimed
+// again after lease and is sent to all sinks.
+type Notifier struct {
+	sinks       []Sink
+	outbox      domain.NotificationOutbox
+	deadLetters domain.DeadLetterStorage
+	poll        time.Duration
+	maxElapsed  time.Duration
+}
+
+func NewNotifier(outbox domain.NotificationOutbox, deadLetters domain.DeadLetterStorage, sinks ...Sink) *Notifier {
+	return &Notifier{
+		sinks:       sinks,
+		outbox:      outbox,
+		deadLetters: deadLetters,
+		poll:        DefaultPoll,
+		maxElapsed:  DefaultMaxElapsed,
+	}
+}
+
+// WithPoll sets period of checking outbox for alerts.
+func (n *Notifier) WithPoll(d time.Duration) {
+	if d > 0 {
+		n.poll = d
+	}
+}
+
+// WithMaxElapsed sets duration of retries of delivery to sink.
+func (n *Notifier) WithMaxElapsed(d time.Duration) {
+	if d > 0 {
+		n.maxElapsed = d
+	}
+}
+
+// Enabled reports whether there are sinks, alerts are not added to outbox without them.
+func (n *Notifier) Enabled() bool {
+	return len(n.sinks) > 0
+}
+
+// NotifyAlerts saves alerts to outbox for delivery.
+func (n *Notifier) NotifyAlerts(ctx context.Context, alerts []domain.Alert) error {
+	if len(n.sinks) == 0 || len(alerts) == 0 {
+		return nil
+	}
+	return backoff.Retry(func() error {
+		return n.outbox.AddNotifications(ctx, alerts)
+	}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+}
+
+func (n *Notifier) Run(ctx context.Context) error {
+	if len(n.sinks) == 0 {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	ticker := time.NewTicker(n.poll)
+	defer ticker.Stop()
+	for {
+		if err := n.deliver(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			zap.L().Error("error deliver notifications", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver sends alerts of outbox until it is empty. Alert interrupted by shutdown stays in outbox.
+func (n *Notifier) deliver(ctx context.Context) error {
+	// Claim is held while alert is retried on every sink.
+	lease := time.Duration(len(n.sinks))*(n.maxElapsed+requestTimeout) + n.poll
+	for {
+		notification, err := n.outbox.ClaimNotification(ctx, lease)
+		if err != nil {
+			return errors.Wrap(err, "claim notification")
+		}
+		if notification == nil {
+			return nil
+		}
+		n.Send(ctx, notification.Alert)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := n.outbox.DeleteNotification(ctx, notification.ID); err != nil {
+			return errors.Wrapf(err, "delete notification %d", notification.ID)
+		}
+	}
+}
+
+// Send delivers alert to every sink with retries and returns errors of sinks which failed,
+// failed delivery is saved as dead letter unless ctx is done.
+func (n *Notifier) Send(ctx context.Context, alert domain.Alert) map[string]error {
+	failed := make(map[string]error)
+	for _, sink := range n.sinks {
+		policy := backoff.NewExponentialBackOff()
+		policy.MaxElapsedTime = n.maxElapsed
+		start := time.Now()
+		err := backoff.Retry(func() error {
+			msg, err := sink.Render(alert)
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			return sink.Send(ctx, msg)
+		}, backoff.WithContext(policy, ctx))
+		metric.NotificationDuration.WithLabelValues(sink.Name()).Add(float64(time.Since(start).Milliseconds()))
+		if err == nil {
+			metric.Notifications.WithLabelValues(sink.Name(), "sent").Inc()
+			continue
+		}
+		failed[sink.Name()] = err
+		if ctx.Err() != nil {
+			// Delivery is interrupted by shutdown, alert is not a dead letter.
+			continue
+		}
+		metric.Notifications.WithLabelValues(sink.Name(), "failed").Inc()
+		zap.L().Error("error send notification", zap.String("sink", sink.Name()), zap.Int64("rule", alert.RuleID), zap.Error(err))
+		if errSave := n.deadLetter(ctx, sink.Name(), alert, err); errSave != nil {
+			zap.L().Error("error save dead letter", zap.String("sink", sink.Name()), zap.Error(errSave))
+		}
+	}
+	return failed
+}
+
+func (n *Notifier) deadLetter(ctx context.Context, sink string, alert domain.Alert, cause error) error {
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		return errors.Wrap(err, "marshal alert")
+	}
+	return n.deadLetters.SaveDeadLetter(ctx, domain.DeadLetter{
+		Sink:      sink,
+		Payload:   string(payload),
+		Error:     cause.Error(),
+		CreatedAt: time.Now(),
+	})
+}
+
+// base is name and templates of sink, templates are executed on alert.
+type base struct {
+	name    string
+	subject *template.Template
+	body    *template.Template
+}
+
+func newBase(name, subject, body string) (base, error) {
+	subjectTemplate, err := template.New(name + "_subject").Parse(subject)
+	if err != nil {
+		return base{}, errors.Wrapf(err, "%s: parse subject template", name)
+	}
+	bodyTemplate, err := template.New(name + "_body").Parse(body)
+	if err != nil {
+		return base{}, errors.Wrapf(err, "%s: parse body template", name)
+	}
+	return base{name: name, subject: subjectTemplate, body: bodyTemplate}, nil
+}
+
+func (b base) Name() string {
+	return b.name
+}
+
+func (b base) Render(alert domain.Alert) (Message, error) {
+	var subject, body bytes.Buffer
+	if err := b.subject.Execute(&subject, alert); err != nil {
+		return Message{}, errors.Wrap(err, "render subject")
+	}
+	if err := b.body.Execute(&body, alert); err != nil {
+		return Message{}, errors.Wrap(err, "render body")
+	}
+	return Message{Subject: subject.String(), Body: body.String(), Alert: alert}, nil
+}
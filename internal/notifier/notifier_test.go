@@ -0,0 +1,162 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/testutil"
+)
+
+// outbox is in-memory outbox, claimed alerts are kept until they are deleted.
+type outbox struct {
+	mu            sync.Mutex
+	id            int64
+	notifications []domain.OutboxNotification
+	claimed       map[int64]time.Time
+	deadLetters   []domain.DeadLetter
+}
+
+func newOutbox() *outbox {
+	return &outbox{claimed: make(map[int64]time.Time)}
+}
+
+func (o *outbox) AddNotifications(_ context.Context, alerts []domain.Alert) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, alert := range alerts {
+		o.id++
+		o.notifications = append(o.notifications, domain.OutboxNotification{ID: o.id, Alert: alert})
+	}
+	return nil
+}
+
+func (o *outbox) ClaimNotification(_ context.Context, lease time.Duration) (*domain.OutboxNotification, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, item := range o.notifications {
+		if claimed, has := o.claimed[item.ID]; has && time.Since(claimed) < lease {
+			continue
+		}
+		o.claimed[item.ID] = time.Now()
+		return &item, nil
+	}
+	return nil, nil
+}
+
+func (o *outbox) DeleteNotification(_ context.Context, id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, item := range o.notifications {
+		if item.ID == id {
+			o.notifications = append(o.notifications[:i], o.notifications[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (o *outbox) SaveDeadLetter(_ context.Context, letter domain.DeadLetter) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.deadLetters = append(o.deadLetters, letter)
+	return nil
+}
+
+func (o *outbox) DeadLetters(context.Context, time.Time) ([]domain.DeadLetter, error) {
+	return nil, nil
+}
+
+func (o *outbox) state() (int, int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.notifications), len(o.deadLetters)
+}
+
+// sink records delivered messages, send blocks until context is done when block is set.
+type sink struct {
+	base
+	block bool
+
+	mu   sync.Mutex
+	sent []Message
+}
+
+func newSink(t *testing.T, block bool) *sink {
+	t.Helper()
+	b, err := newBase("test", DefaultSubject, DefaultBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sink{base: b, block: block}
+}
+
+func (s *sink) Send(ctx context.Context, msg Message) error {
+	if s.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *sink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func TestNotifierDeliversOutbox(t *testing.T) {
+	storage, target := newOutbox(), newSink(t, false)
+	// Alerts are saved by one notifier and delivered by another one as by calculate and notify commands.
+	producer := NewNotifier(storage, storage, target)
+	alerts := []domain.Alert{{RuleID: 1, Message: "first"}, {RuleID: 2, Message: "second"}}
+	if err := producer.NotifyAlerts(context.Background(), alerts); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := storage.state(); pending != 2 {
+		t.Fatalf("got %d alerts in outbox, want 2", pending)
+	}
+
+	consumer := NewNotifier(storage, storage, target)
+	consumer.WithPoll(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = consumer.Run(ctx)
+	}()
+	testutil.WaitFor(t, 5*time.Second, func() bool {
+		pending, _ := storage.state()
+		return pending == 0 && target.count() == 2
+	})
+	cancel()
+	<-done
+	if target.sent[0].Body != "first" || target.sent[1].Body != "second" {
+		t.Errorf("got messages %+v", target.sent)
+	}
+}
+
+func TestNotifierKeepsAlertOnShutdown(t *testing.T) {
+	storage := newOutbox()
+	notifier := NewNotifier(storage, storage, newSink(t, true))
+	if err := notifier.NotifyAlerts(context.Background(), []domain.Alert{{RuleID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := notifier.Run(ctx); err == nil {
+		t.Fatal("expected error of canceled context")
+	}
+	pending, deadLetters := storage.state()
+	if pending != 1 {
+		t.Errorf("got %d alerts in outbox, interrupted alert must stay", pending)
+	}
+	if deadLetters != 0 {
+		t.Errorf("got %d dead letters, interrupted alert is not a dead letter", deadLetters)
+	}
+}
@@ -0,0 +1,373 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	WebhookSink  = "webhook"
+	TelegramSink = "telegram"
+	SlackSink    = "slack"
+	SMTPSink     = "smtp"
+	StdoutSink   = "stdout"
+
+	DefaultTelegramURL = "https://api.telegram.org"
+	DefaultSubject     = "{{.Kind}} alert {{.RuleName}}"
+	DefaultBody        = "{{.Message}}"
+
+	// SignatureHeader is header of webhook request with hex HMAC-SHA256 of "<timestamp>.<body>" by secret of sink.
+	SignatureHeader = "X-Signature"
+	// TimestampHeader is header of webhook request with unix time of signature.
+	TimestampHeader = "X-Timestamp"
+
+	requestTimeout = 10 * time.Second
+)
+
+// Config of notifier, alerts are delivered to every sink.
+type Config struct {
+	// Poll is period of checking outbox for alerts to deliver.
+	Poll time.Duration `mapstructure:"poll"`
+	// MaxElapsed is duration of retries of delivery to sink before alert is saved as dead letter.
+	MaxElapsed time.Duration `mapstructure:"max_elapsed"`
+	Sinks      []SinkConfig  `mapstructure:"sinks"`
+}
+
+// SinkConfig describes sink of Type, fields which are not used by type are ignored.
+// Subject and Body are text/template of domain.Alert.
+type SinkConfig struct {
+	Type    string `mapstructure:"type"`
+	Name    string `mapstructure:"name"`
+	Subject string `mapstructure:"subject"`
+	Body    string `mapstructure:"body"`
+
+	// URL is endpoint of webhook and slack sinks and API of telegram sink.
+	URL string `mapstructure:"url"`
+	// Secret signs body of webhook request.
+	Secret string `mapstructure:"secret"`
+
+	Token  string `mapstructure:"token"`
+	ChatID string `mapstructure:"chat_id"`
+
+	Host     string   `mapstructure:"host"`
+	Port     string   `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// NewSinks creates sinks of config, names of sinks are unique.
+func NewSinks(conf Config, client *http.Client) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(conf.Sinks))
+	names := make(map[string]bool, len(conf.Sinks))
+	for i, item := range conf.Sinks {
+		sink, err := NewSink(item, client)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sinks[%d]", i)
+		}
+		if names[sink.Name()] {
+			return nil, fmt.Errorf("sinks[%d]: duplicate name %s", i, sink.Name())
+		}
+		names[sink.Name()] = true
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+func NewSink(conf SinkConfig, client *http.Client) (Sink, error) {
+	name := conf.Name
+	if name == "" {
+		name = conf.Type
+	}
+	subject, body := conf.Subject, conf.Body
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	if body == "" {
+		body = DefaultBody
+	}
+	b, err := newBase(name, subject, body)
+	if err != nil {
+		return nil, err
+	}
+	switch conf.Type {
+	case WebhookSink:
+		if err := validateURL(conf.URL); err != nil {
+			return nil, err
+		}
+		return &Webhook{base: b, client: client, url: conf.URL, secret: conf.Secret}, nil
+	case TelegramSink:
+		apiURL := conf.URL
+		if apiURL == "" {
+			apiURL = DefaultTelegramURL
+		}
+		if err := validateURL(apiURL); err != nil {
+			return nil, err
+		}
+		if conf.Token == "" || conf.ChatID == "" {
+			return nil, errors.New("telegram sink requires token and chat_id")
+		}
+		return &Telegram{base: b, client: client, url: apiURL, token: conf.Token, chatID: conf.ChatID}, nil
+	case SlackSink:
+		if err := validateURL(conf.URL); err != nil {
+			return nil, err
+		}
+		return &Slack{base: b, client: client, url: conf.URL}, nil
+	case SMTPSink:
+		if conf.Host == "" || conf.Port == "" || conf.From == "" || len(conf.To) == 0 {
+			return nil, errors.New("smtp sink requires host, port, from and to")
+		}
+		return &SMTP{
+			base:     b,
+			addr:     net.JoinHostPort(conf.Host, conf.Port),
+			host:     conf.Host,
+			username: conf.Username,
+			password: conf.Password,
+			from:     conf.From,
+			to:       conf.To,
+		}, nil
+	case StdoutSink:
+		return &Stdout{base: b, out: os.Stdout}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type: %s", conf.Type)
+	}
+}
+
+// Webhook posts json {"subject", "body", "alert"} signed by HMAC-SHA256 when secret is set.
+type Webhook struct {
+	base
+	client *http.Client
+	url    string
+	secret string
+}
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]any{"subject": msg.Subject, "body": msg.Body, "alert": msg.Alert})
+	if err != nil {
+		return backoff.Permanent(errors.Wrap(err, "marshal payload"))
+	}
+	headers := make(map[string]string)
+	if w.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[TimestampHeader] = timestamp
+		headers[SignatureHeader] = Sign(w.secret, timestamp, payload)
+	}
+	return postJSON(ctx, w.client, w.url, payload, headers)
+}
+
+// Sign returns hex HMAC-SHA256 of "<timestamp>.<payload>", receiver of webhook checks signature with the same secret.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Telegram sends body of message to chat with sendMessage method of bot API.
+type Telegram struct {
+	base
+	client *http.Client
+	url    string
+	token  string
+	chatID string
+}
+
+func (t *Telegram) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]any{"chat_id": t.chatID, "text": msg.Body})
+	if err != nil {
+		return backoff.Permanent(errors.Wrap(err, "marshal payload"))
+	}
+	endpoint, err := url.JoinPath(t.url, "bot"+t.token, "sendMessage")
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	if err := postJSON(ctx, t.client, endpoint, payload, nil); err != nil {
+		// Request errors contain url with token of bot.
+		return redact(err, t.token)
+	}
+	return nil
+}
+
+func redact(err error, secret string) error {
+	msg := strings.ReplaceAll(err.Error(), secret, "***")
+	var permanent *backoff.PermanentError
+	if errors.As(err, &permanent) {
+		return backoff.Permanent(errors.New(msg))
+	}
+	return errors.New(msg)
+}
+
+// Slack posts body of message to incoming webhook, the format is accepted by Slack compatible chats.
+type Slack struct {
+	base
+	client *http.Client
+	url    string
+}
+
+func (s *Slack) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]any{"text": msg.Body})
+	if err != nil {
+		return backoff.Permanent(errors.Wrap(err, "marshal payload"))
+	}
+	return postJSON(ctx, s.client, s.url, payload, nil)
+}
+
+// SMTP sends plain text email, authentication is used when username is set.
+type SMTP struct {
+	base
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// headerReplacer removes line breaks which would start another header.
+var headerReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// Send delivers message in one SMTP session bounded by ctx and requestTimeout.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	body := strings.Builder{}
+	body.WriteString("From: " + s.from + "\r\n")
+	body.WriteString("To: " + strings.Join(s.to, ", ") + "\r\n")
+	body.WriteString("Subject: " + headerReplacer.Replace(msg.Subject) + "\r\n")
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	body.WriteString("\r\n")
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return errors.Wrap(err, "dial")
+	}
+	defer func() { _ = conn.Close() }()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return errors.Wrap(err, "set deadline")
+	}
+	// Session is interrupted when ctx is canceled before deadline.
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+	return s.session(conn, auth, []byte(body.String()))
+}
+
+// session is smtp.SendMail on established connection.
+func (s *SMTP) session(conn net.Conn, auth smtp.Auth, body []byte) error {
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		return errors.Wrap(err, "greeting")
+	}
+	defer func() { _ = client.Close() }()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return errors.Wrap(err, "starttls")
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return backoff.Permanent(errors.New("server does not support AUTH"))
+		}
+		if err := client.Auth(auth); err != nil {
+			return errors.Wrap(err, "auth")
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return errors.Wrap(err, "mail")
+	}
+	for _, to := range s.to {
+		if err := client.Rcpt(to); err != nil {
+			return errors.Wrapf(err, "rcpt %s", to)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "data")
+	}
+	if _, err := w.Write(body); err != nil {
+		return errors.Wrap(err, "write body")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "close body")
+	}
+	return client.Quit()
+}
+
+// Stdout writes subject and body of message line by line.
+type Stdout struct {
+	base
+	out io.Writer
+}
+
+func (s *Stdout) Send(_ context.Context, msg Message) error {
+	_, err := fmt.Fprintf(s.out, "%s %s: %s\n", msg.Alert.Date.Format(time.RFC3339), msg.Subject, msg.Body)
+	return err
+}
+
+// postJSON posts payload, response 4xx except 429 is permanent error which is not retried.
+func postJSON(ctx context.Context, client *http.Client, endpoint string, payload []byte, headers map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return backoff.Permanent(errors.Wrap(err, "create request"))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "execute request")
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError &&
+		resp.StatusCode != http.StatusTooManyRequests {
+		return backoff.Permanent(err)
+	}
+	return err
+}
+
+func validateURL(val string) error {
+	u, err := url.Parse(val)
+	if err != nil {
+		return errors.Wrap(err, "url")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("url must be absolute, got %q", val)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/cenkalti/backoff/v4"
+)
+
+var testMessage = Message{
+	Subject: "price alert",
+	Body:    "BTCUSDT/binance price above 70000",
+	Alert:   domain.Alert{RuleID: 1, Kind: domain.PriceAlert, Exchange: domain.BinanceExchange, Symbol: "BTCUSDT"},
+}
+
+func newTestSink(t *testing.T, conf SinkConfig) Sink {
+	t.Helper()
+	sink, err := NewSink(conf, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sink
+}
+
+func TestWebhookSignsPayload(t *testing.T) {
+	var (
+		body    []byte
+		headers http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	sink := newTestSink(t, SinkConfig{Type: WebhookSink, URL: server.URL, Secret: "secret"})
+	if err := sink.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	timestamp := headers.Get(TimestampHeader)
+	if timestamp == "" || headers.Get(SignatureHeader) != Sign("secret", timestamp, body) {
+		t.Errorf("wrong signature %q of timestamp %q", headers.Get(SignatureHeader), timestamp)
+	}
+	var payload struct {
+		Subject string       `json:"subject"`
+		Body    string       `json:"body"`
+		Alert   domain.Alert `json:"alert"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Subject != testMessage.Subject || payload.Body != testMessage.Body || payload.Alert.RuleID != 1 {
+		t.Errorf("got payload %+v", payload)
+	}
+}
+
+func TestWebhookStatuses(t *testing.T) {
+	for status, permanent := range map[int]bool{
+		http.StatusBadRequest:          true,
+		http.StatusNotFound:            true,
+		http.StatusTooManyRequests:     false,
+		http.StatusInternalServerError: false,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		err := newTestSink(t, SinkConfig{Type: WebhookSink, URL: server.URL}).Send(context.Background(), testMessage)
+		server.Close()
+		if err == nil {
+			t.Fatalf("status %d: expected error", status)
+		}
+		var permanentErr *backoff.PermanentError
+		if errors.As(err, &permanentErr) != permanent {
+			t.Errorf("status %d: permanent error is %t, want %t", status, !permanent, permanent)
+		}
+	}
+}
+
+func TestTelegramSendMessage(t *testing.T) {
+	var (
+		path    string
+		payload map[string]string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	sink := newTestSink(t, SinkConfig{Type: TelegramSink, URL: server.URL, Token: "123:token", ChatID: "42"})
+	if err := sink.Send(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/bot123:token/sendMessage" {
+		t.Errorf("got path %s", path)
+	}
+	if payload["chat_id"] != "42" || payload["text"] != testMessage.Body {
+		t.Errorf("got payload %v", payload)
+	}
+}
+
+func TestTelegramRedactsToken(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	sink := newTestSink(t, SinkConfig{Type: TelegramSink, URL: server.URL, Token: "123:token", ChatID: "42"})
+	err := sink.Send(context.Background(), testMessage)
+	if err == nil {
+		t.Fatal("expected error of closed server")
+	}
+	if strings.Contains(err.Error(), "123:token") {
+		t.Errorf("error contains token: %v", err)
+	}
+}
+
+// smtpServer is fake SMTP server which accepts one message and records it.
+type smtpServer struct {
+	addr string
+
+	mu         sync.Mutex
+	recipients []string
+	data       string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	s := &smtpServer{addr: listener.Addr().String()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	text := textproto.NewConn(conn)
+	reply := func(line string) {
+		_ = text.PrintfLine("%s", line)
+	}
+	reply("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			reply("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.recipients = append(s.recipients, strings.TrimPrefix(line, "RCPT TO:"))
+			s.mu.Unlock()
+			reply("250 OK")
+		case "DATA":
+			reply("354 end with .")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	server := newSMTPServer(t)
+	host, port, _ := net.SplitHostPort(server.addr)
+	sink := newTestSink(t, SinkConfig{
+		Type: SMTPSink, Host: host, Port: port, From: "alerts@localhost", To: []string{"a@localhost", "b@localhost"},
+	})
+	msg := testMessage
+	msg.Subject = "price alert\r\nBcc: victim@localhost"
+	if err := sink.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if len(server.recipients) != 2 {
+		t.Errorf("got recipients %v", server.recipients)
+	}
+	headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(server.data))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := headers.Get("Subject"); got != "price alert Bcc: victim@localhost" {
+		t.Errorf("got subject %q", got)
+	}
+	if headers.Get("Bcc") != "" {
+		t.Error("subject injected Bcc header")
+	}
+	if !strings.Contains(server.data, testMessage.Body) {
+		t.Errorf("body is missed in %q", server.data)
+	}
+}
+
+func TestSMTPSendCanceled(t *testing.T) {
+	// Server accepts connection and never greets.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	sink := newTestSink(t, SinkConfig{Type: SMTPSink, Host: host, Port: port, From: "alerts@localhost", To: []string{"a@localhost"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := sink.Send(ctx, testMessage); err == nil {
+		t.Fatal("expected error of silent server")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("send returned after %s, session must be interrupted by context", elapsed)
+	}
+}
@@ -0,0 +1,260 @@
+package pattern
+
+import (
+	"math"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// DojiBodyRatio is the largest share of range taken by body of doji.
+	DojiBodyRatio = 0.1
+	// TrendLength is number of candlesticks before hammer and shooting star which must move against them.
+	TrendLength = 3
+)
+
+// Detection is pattern recognized on a candlestick.
+type Detection struct {
+	Pattern   domain.Pattern
+	Direction domain.PatternDirection
+	Strength  float64
+}
+
+// detector recognizes pattern finished by candlestick with index i, previous candlesticks are available.
+type detector func(candles []dto.Candlestick, i int) (Detection, bool)
+
+var detectors = []detector{
+	pinBar,
+	engulfing,
+	doji,
+	hammer,
+	shootingStar,
+	insideBar,
+	outsideBar,
+	threeWhiteSoldiers,
+	threeBlackCrows,
+}
+
+// Detect returns patterns of every candlestick of series ordered by open time.
+func Detect(candles []dto.Candlestick) []domain.CandlestickPattern {
+	return DetectLast(candles, len(candles))
+}
+
+// DetectLast returns patterns of the last count candlesticks of series ordered by open time,
+// earlier candlesticks are used only as part of multi-candlestick patterns.
+func DetectLast(candles []dto.Candlestick, count int) []domain.CandlestickPattern {
+	var (
+		result []domain.CandlestickPattern
+		now    = time.Now()
+	)
+	for i := max(len(candles)-count, 0); i < len(candles); i++ {
+		for _, detect := range detectors {
+			detection, ok := detect(candles, i)
+			if !ok {
+				continue
+			}
+			result = append(result, domain.CandlestickPattern{
+				Exchange:  candles[i].Exchange,
+				Symbol:    candles[i].Symbol,
+				Interval:  domain.Interval(candles[i].Interval),
+				OpenTime:  candles[i].OpenTime,
+				Pattern:   detection.Pattern,
+				Direction: detection.Direction,
+				Strength:  math.Round(detection.Strength*1e4) / 1e4,
+				CreatedAt: now,
+			})
+		}
+	}
+	return result
+}
+
+// PinBar returns share of candlestick range taken by long wick when body is not more than third of range
+// and the wick is not less than two thirds of range: positive for long lower wick, negative for long upper wick.
+func PinBar(candle dto.Candlestick) float64 {
+	c := shape(candle)
+	if c.length <= 0 || c.body*3 > c.length {
+		return 0
+	}
+	switch {
+	case c.lowerWick*3 >= c.length*2:
+		return c.lowerWick / c.length
+	case c.upperWick*3 >= c.length*2:
+		return -c.upperWick / c.length
+	default:
+		return 0
+	}
+}
+
+type candleShape struct {
+	length, body, upperWick, lowerWick float64
+	bullish, bearish                   bool
+}
+
+func shape(candle dto.Candlestick) candleShape {
+	return candleShape{
+		length:    domain.PriceFloat(candle.HighPrice.Sub(candle.LowPrice)),
+		body:      domain.PriceFloat(candle.ClosePrice.Sub(candle.OpenPrice).Abs()),
+		upperWick: domain.PriceFloat(candle.HighPrice.Sub(decimal.Max(candle.OpenPrice, candle.ClosePrice))),
+		lowerWick: domain.PriceFloat(decimal.Min(candle.OpenPrice, candle.ClosePrice).Sub(candle.LowPrice)),
+		bullish:   candle.ClosePrice.GreaterThan(candle.OpenPrice),
+		bearish:   candle.ClosePrice.LessThan(candle.OpenPrice),
+	}
+}
+
+func pinBar(candles []dto.Candlestick, i int) (Detection, bool) {
+	val := PinBar(candles[i])
+	switch {
+	case val > 0:
+		return Detection{Pattern: domain.PinBarPattern, Direction: domain.BullishDirection, Strength: val}, true
+	case val < 0:
+		return Detection{Pattern: domain.PinBarPattern, Direction: domain.BearishDirection, Strength: -val}, true
+	default:
+		return Detection{}, false
+	}
+}
+
+// engulfing is candlestick which body covers body of previous candlestick of opposite direction,
+// strength grows with ratio of bodies.
+func engulfing(candles []dto.Candlestick, i int) (Detection, bool) {
+	if i < 1 {
+		return Detection{}, false
+	}
+	prev, current := candles[i-1], candles[i]
+	p, c := shape(prev), shape(current)
+	if p.body <= 0 || c.body <= p.body {
+		return Detection{}, false
+	}
+	strength := 1 - p.body/c.body
+	switch {
+	case p.bearish && c.bullish && current.OpenPrice.LessThanOrEqual(prev.ClosePrice) &&
+		current.ClosePrice.GreaterThanOrEqual(prev.OpenPrice):
+		return Detection{Pattern: domain.EngulfingPattern, Direction: domain.BullishDirection, Strength: strength}, true
+	case p.bullish && c.bearish && current.OpenPrice.GreaterThanOrEqual(prev.ClosePrice) &&
+		current.ClosePrice.LessThanOrEqual(prev.OpenPrice):
+		return Detection{Pattern: domain.EngulfingPattern, Direction: domain.BearishDirection, Strength: strength}, true
+	default:
+		return Detection{}, false
+	}
+}
+
+// doji is candlestick with body not more than DojiBodyRatio of range, the smaller body the stronger doji.
+func doji(candles []dto.Candlestick, i int) (Detection, bool) {
+	c := shape(candles[i])
+	if c.length <= 0 || c.body > c.length*DojiBodyRatio {
+		return Detection{}, false
+	}
+	return Detection{
+		Pattern:   domain.DojiPattern,
+		Direction: domain.NeutralDirection,
+		Strength:  1 - c.body/(c.length*DojiBodyRatio),
+	}, true
+}
+
+// hammer is candlestick with small body at the top of range and lower wick at least twice as long as body after downtrend.
+func hammer(candles []dto.Candlestick, i int) (Detection, bool) {
+	c := shape(candles[i])
+	if !hammerShape(c.lowerWick, c.upperWick, c) || !trend(candles, i, -1) {
+		return Detection{}, false
+	}
+	return Detection{Pattern: domain.HammerPattern, Direction: domain.BullishDirection, Strength: c.lowerWick / c.length}, true
+}
+
+// shootingStar is candlestick with small body at the bottom of range and upper wick at least twice as long as body after uptrend.
+func shootingStar(candles []dto.Candlestick, i int) (Detection, bool) {
+	c := shape(candles[i])
+	if !hammerShape(c.upperWick, c.lowerWick, c) || !trend(candles, i, 1) {
+		return Detection{}, false
+	}
+	return Detection{Pattern: domain.ShootingStarPattern, Direction: domain.BearishDirection, Strength: c.upperWick / c.length}, true
+}
+
+func hammerShape(longWick, shortWick float64, c candleShape) bool {
+	return c.length > 0 && c.body > 0 && longWick >= c.body*2 && shortWick <= c.length*DojiBodyRatio
+}
+
+// trend reports whether close prices of TrendLength candlesticks before i move in direction (1 is up, -1 is down).
+func trend(candles []dto.Candlestick, i int, direction float64) bool {
+	if i < TrendLength {
+		return false
+	}
+	for j := i - TrendLength + 1; j < i; j++ {
+		if float64(candles[j].ClosePrice.Cmp(candles[j-1].ClosePrice))*direction <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// insideBar is candlestick which range is inside range of previous one, the narrower range the stronger it is.
+func insideBar(candles []dto.Candlestick, i int) (Detection, bool) {
+	if i < 1 {
+		return Detection{}, false
+	}
+	prev, current := candles[i-1], candles[i]
+	if current.HighPrice.GreaterThanOrEqual(prev.HighPrice) || current.LowPrice.LessThanOrEqual(prev.LowPrice) {
+		return Detection{}, false
+	}
+	return Detection{
+		Pattern:   domain.InsideBarPattern,
+		Direction: domain.NeutralDirection,
+		Strength:  1 - shape(current).length/shape(prev).length,
+	}, true
+}
+
+// outsideBar is candlestick which range covers range of previous one, direction is given by its body.
+func outsideBar(candles []dto.Candlestick, i int) (Detection, bool) {
+	if i < 1 {
+		return Detection{}, false
+	}
+	prev, current := candles[i-1], candles[i]
+	if current.HighPrice.LessThanOrEqual(prev.HighPrice) || current.LowPrice.GreaterThanOrEqual(prev.LowPrice) {
+		return Detection{}, false
+	}
+	c := shape(current)
+	direction := domain.NeutralDirection
+	switch {
+	case c.bullish:
+		direction = domain.BullishDirection
+	case c.bearish:
+		direction = domain.BearishDirection
+	}
+	return Detection{Pattern: domain.OutsideBarPattern, Direction: direction, Strength: 1 - shape(prev).length/c.length}, true
+}
+
+func threeWhiteSoldiers(candles []dto.Candlestick, i int) (Detection, bool) {
+	strength, ok := threeCandles(candles, i, 1)
+	return Detection{Pattern: domain.ThreeWhiteSoldiersPattern, Direction: domain.BullishDirection, Strength: strength}, ok
+}
+
+func threeBlackCrows(candles []dto.Candlestick, i int) (Detection, bool) {
+	strength, ok := threeCandles(candles, i, -1)
+	return Detection{Pattern: domain.ThreeBlackCrowsPattern, Direction: domain.BearishDirection, Strength: strength}, ok
+}
+
+// threeCandles checks three candlesticks of direction (1 is up, -1 is down) each opened inside body of previous one
+// and closed beyond its close. Strength is average share of range taken by body.
+func threeCandles(candles []dto.Candlestick, i int, direction float64) (float64, bool) {
+	if i < 2 {
+		return 0, false
+	}
+	var strength float64
+	for j := i - 2; j <= i; j++ {
+		c := shape(candles[j])
+		if c.length <= 0 || float64(candles[j].ClosePrice.Cmp(candles[j].OpenPrice))*direction <= 0 {
+			return 0, false
+		}
+		if j > i-2 {
+			prev := candles[j-1]
+			if float64(candles[j].ClosePrice.Cmp(prev.ClosePrice))*direction <= 0 ||
+				candles[j].OpenPrice.LessThan(decimal.Min(prev.OpenPrice, prev.ClosePrice)) ||
+				candles[j].OpenPrice.GreaterThan(decimal.Max(prev.OpenPrice, prev.ClosePrice)) {
+				return 0, false
+			}
+		}
+		strength += c.body / c.length
+	}
+	return strength / 3, true
+}
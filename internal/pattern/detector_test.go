@@ -0,0 +1,202 @@
+package pattern
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/shopspring/decimal"
+)
+
+func candle(open, high, low, close float64) dto.Candlestick {
+	return dto.Candlestick{
+		OpenPrice:  decimal.NewFromFloat(open),
+		HighPrice:  decimal.NewFromFloat(high),
+		LowPrice:   decimal.NewFromFloat(low),
+		ClosePrice: decimal.NewFromFloat(close),
+	}
+}
+
+// closes returns candlesticks of close prices with body between close prices of neighbours.
+func closes(prices ...float64) []dto.Candlestick {
+	result := make([]dto.Candlestick, 0, len(prices))
+	for _, price := range prices {
+		result = append(result, candle(price, price+0.1, price-0.1, price))
+	}
+	return result
+}
+
+func TestDetectors(t *testing.T) {
+	for _, item := range []struct {
+		name    string
+		detect  detector
+		candles []dto.Candlestick
+		ok      bool
+		want    Detection
+	}{
+		{
+			name:    "bullish pin bar",
+			detect:  pinBar,
+			candles: []dto.Candlestick{candle(9, 10, 0, 10)},
+			ok:      true,
+			want:    Detection{Pattern: domain.PinBarPattern, Direction: domain.BullishDirection, Strength: 0.9},
+		},
+		{
+			name:    "bearish pin bar",
+			detect:  pinBar,
+			candles: []dto.Candlestick{candle(1, 10, 0, 0)},
+			ok:      true,
+			want:    Detection{Pattern: domain.PinBarPattern, Direction: domain.BearishDirection, Strength: 0.9},
+		},
+		{
+			name:    "pin bar with large body",
+			detect:  pinBar,
+			candles: []dto.Candlestick{candle(4, 10, 0, 8)},
+		},
+		{
+			name:    "bullish engulfing",
+			detect:  engulfing,
+			candles: []dto.Candlestick{candle(10, 10, 9, 9), candle(9, 11, 9, 11)},
+			ok:      true,
+			want:    Detection{Pattern: domain.EngulfingPattern, Direction: domain.BullishDirection, Strength: 0.5},
+		},
+		{
+			name:    "bearish engulfing",
+			detect:  engulfing,
+			candles: []dto.Candlestick{candle(9, 10, 9, 10), candle(10, 10, 8, 8)},
+			ok:      true,
+			want:    Detection{Pattern: domain.EngulfingPattern, Direction: domain.BearishDirection, Strength: 0.5},
+		},
+		{
+			name:    "engulfing of the same direction",
+			detect:  engulfing,
+			candles: []dto.Candlestick{candle(9, 10, 9, 10), candle(9, 11, 9, 11)},
+		},
+		{
+			name:    "doji without body",
+			detect:  doji,
+			candles: []dto.Candlestick{candle(5, 10, 0, 5)},
+			ok:      true,
+			want:    Detection{Pattern: domain.DojiPattern, Direction: domain.NeutralDirection, Strength: 1},
+		},
+		{
+			name:    "doji with half of max body",
+			detect:  doji,
+			candles: []dto.Candlestick{candle(5, 10, 0, 5.5)},
+			ok:      true,
+			want:    Detection{Pattern: domain.DojiPattern, Direction: domain.NeutralDirection, Strength: 0.5},
+		},
+		{
+			name:    "doji with large body",
+			detect:  doji,
+			candles: []dto.Candlestick{candle(5, 10, 0, 7)},
+		},
+		{
+			name:    "hammer after downtrend",
+			detect:  hammer,
+			candles: append(closes(14, 13, 12), candle(10, 10.5, 8, 10.5)),
+			ok:      true,
+			want:    Detection{Pattern: domain.HammerPattern, Direction: domain.BullishDirection, Strength: 0.8},
+		},
+		{
+			name:    "hammer after uptrend",
+			detect:  hammer,
+			candles: append(closes(10, 11, 12), candle(10, 10.5, 8, 10.5)),
+		},
+		{
+			name:    "shooting star after uptrend",
+			detect:  shootingStar,
+			candles: append(closes(10, 11, 12), candle(10.5, 12.5, 10, 10)),
+			ok:      true,
+			want:    Detection{Pattern: domain.ShootingStarPattern, Direction: domain.BearishDirection, Strength: 0.8},
+		},
+		{
+			name:    "shooting star without trend",
+			detect:  shootingStar,
+			candles: []dto.Candlestick{candle(10.5, 12.5, 10, 10)},
+		},
+		{
+			name:    "inside bar",
+			detect:  insideBar,
+			candles: []dto.Candlestick{candle(1, 10, 0, 9), candle(3, 8, 2, 7)},
+			ok:      true,
+			want:    Detection{Pattern: domain.InsideBarPattern, Direction: domain.NeutralDirection, Strength: 0.4},
+		},
+		{
+			name:    "inside bar with equal high",
+			detect:  insideBar,
+			candles: []dto.Candlestick{candle(1, 10, 0, 9), candle(3, 10, 2, 7)},
+		},
+		{
+			name:    "bullish outside bar",
+			detect:  outsideBar,
+			candles: []dto.Candlestick{candle(3, 8, 2, 7), candle(3, 10, 0, 9)},
+			ok:      true,
+			want:    Detection{Pattern: domain.OutsideBarPattern, Direction: domain.BullishDirection, Strength: 0.4},
+		},
+		{
+			name:    "outside bar inside previous one",
+			detect:  outsideBar,
+			candles: []dto.Candlestick{candle(1, 10, 0, 9), candle(3, 8, 2, 7)},
+		},
+		{
+			name:    "three white soldiers",
+			detect:  threeWhiteSoldiers,
+			candles: []dto.Candlestick{candle(10, 13, 9, 12), candle(11, 14, 10, 13), candle(12, 15, 11, 14)},
+			ok:      true,
+			want:    Detection{Pattern: domain.ThreeWhiteSoldiersPattern, Direction: domain.BullishDirection, Strength: 0.5},
+		},
+		{
+			name:    "three white soldiers opened above body",
+			detect:  threeWhiteSoldiers,
+			candles: []dto.Candlestick{candle(10, 13, 9, 12), candle(12.5, 14, 12, 13), candle(12, 15, 11, 14)},
+		},
+		{
+			name:    "three black crows",
+			detect:  threeBlackCrows,
+			candles: []dto.Candlestick{candle(14, 15, 11, 12), candle(13, 14, 10, 11), candle(12, 13, 9, 10)},
+			ok:      true,
+			want:    Detection{Pattern: domain.ThreeBlackCrowsPattern, Direction: domain.BearishDirection, Strength: 0.5},
+		},
+		{
+			name:    "three black crows with bullish candlestick",
+			detect:  threeBlackCrows,
+			candles: []dto.Candlestick{candle(14, 15, 11, 12), candle(11, 14, 10, 13), candle(12, 13, 9, 10)},
+		},
+	} {
+		t.Run(item.name, func(t *testing.T) {
+			got, ok := item.detect(item.candles, len(item.candles)-1)
+			if ok != item.ok {
+				t.Fatalf("detected %t, want %t", ok, item.ok)
+			}
+			if !ok {
+				return
+			}
+			if got.Pattern != item.want.Pattern || got.Direction != item.want.Direction ||
+				math.Abs(got.Strength-item.want.Strength) > 1e-9 {
+				t.Errorf("got %+v, want %+v", got, item.want)
+			}
+		})
+	}
+}
+
+func TestDetectLast(t *testing.T) {
+	candles := []dto.Candlestick{candle(10, 10, 9, 9), candle(9, 11, 9, 11)}
+	candles[1].OpenTime = time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	// Engulfing is detected on the last candlestick, the previous one is only part of it.
+	patterns := DetectLast(candles, 1)
+	for _, item := range patterns {
+		if !item.OpenTime.Equal(candles[1].OpenTime) {
+			t.Errorf("pattern %s of candlestick out of depth", item.Pattern)
+		}
+	}
+	var engulfing bool
+	for _, item := range patterns {
+		engulfing = engulfing || item.Pattern == domain.EngulfingPattern
+	}
+	if !engulfing {
+		t.Errorf("got patterns %+v, want engulfing", patterns)
+	}
+}
@@ -0,0 +1,169 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultHost = "https://api.binance.com"
+
+	// Request weights from https://binance-docs.github.io/apidocs/spot/en/#market-data-endpoints
+	allPricesWeight    = 4
+	symbolPriceWeight  = 2
+	candlesticksWeight = 2
+
+	candlesticksLimit = 500
+	// historyLimit is max limit of klines request.
+	historyLimit = 1000
+)
+
+var _ source.Exchange = (*Client)(nil)
+
+type Client struct {
+	host      *url.URL
+	requester *source.Requester
+}
+
+func New(host string, requester *source.Requester) (*Client, error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse host")
+	}
+	return &Client{host: hostURL, requester: requester}, nil
+}
+
+func (c *Client) Name() string {
+	return domain.BinanceExchange
+}
+
+type tickerPrice struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+func (c *Client) Prices(ctx context.Context) ([]domain.SourcePrice, error) {
+	var resp []tickerPrice
+	if err := c.requester.GetJSON(ctx, c.url("/api/v3/ticker/price", nil), allPricesWeight, &resp); err != nil {
+		return nil, errors.Wrap(err, "load binance prices")
+	}
+	now := time.Now().In(time.UTC)
+	prices := make([]domain.SourcePrice, 0, len(resp))
+	for _, item := range resp {
+		prices = append(prices, domain.SourcePrice{
+			Exchange: domain.BinanceExchange,
+			Symbol:   source.NormalizeSymbol(item.Symbol),
+			Price:    item.Price,
+			Date:     now,
+		})
+	}
+	return prices, nil
+}
+
+func (c *Client) SymbolPrice(ctx context.Context, symbol string) (domain.SourcePrice, error) {
+	var resp tickerPrice
+	params := url.Values{"symbol": {source.NormalizeSymbol(symbol)}}
+	if err := c.requester.GetJSON(ctx, c.url("/api/v3/ticker/price", params), symbolPriceWeight, &resp); err != nil {
+		return domain.SourcePrice{}, errors.Wrap(err, "load binance symbol price")
+	}
+	return domain.SourcePrice{
+		Exchange: domain.BinanceExchange,
+		Symbol:   source.NormalizeSymbol(resp.Symbol),
+		Price:    resp.Price,
+		Date:     time.Now().In(time.UTC),
+	}, nil
+}
+
+// Candlesticks returns last candlesticks ordered by open time, the last one can be not closed yet.
+func (c *Client) Candlesticks(ctx context.Context, symbol string, interval domain.Interval) ([]dto.Candlestick, error) {
+	return c.klines(ctx, symbol, interval, url.Values{"limit": {strconv.Itoa(candlesticksLimit)}})
+}
+
+// CandlesticksBefore returns candlesticks opened before end ordered by open time.
+func (c *Client) CandlesticksBefore(
+	ctx context.Context, symbol string, interval domain.Interval, end time.Time,
+) ([]dto.Candlestick, error) {
+	return c.klines(ctx, symbol, interval, url.Values{
+		"limit":   {strconv.Itoa(historyLimit)},
+		"endTime": {strconv.FormatInt(end.UnixMilli()-1, 10)},
+	})
+}
+
+func (c *Client) klines(
+	ctx context.Context, symbol string, interval domain.Interval, params url.Values,
+) ([]dto.Candlestick, error) {
+	// Kline: [openTime, open, high, low, close, volume, closeTime, quoteVolume, numberTrades, ...]
+	var resp [][]json.RawMessage
+	params.Set("symbol", source.NormalizeSymbol(symbol))
+	params.Set("interval", interval.String())
+	if err := c.requester.GetJSON(ctx, c.url("/api/v3/klines", params), candlesticksWeight, &resp); err != nil {
+		return nil, errors.Wrap(err, "load binance klines")
+	}
+	now := time.Now().In(time.UTC)
+	candlesticks := make([]dto.Candlestick, 0, len(resp))
+	for _, kline := range resp {
+		candlestick, err := parseKline(kline)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse binance kline")
+		}
+		candlestick.Symbol = source.NormalizeSymbol(symbol)
+		candlestick.Exchange = domain.BinanceExchange
+		candlestick.Interval = interval.String()
+		candlestick.CreatedAt = now
+		candlesticks = append(candlesticks, candlestick)
+	}
+	return candlesticks, nil
+}
+
+func (c *Client) url(path string, params url.Values) string {
+	u := c.host.JoinPath(path)
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
+func parseKline(kline []json.RawMessage) (dto.Candlestick, error) {
+	if len(kline) < 9 {
+		return dto.Candlestick{}, fmt.Errorf("kline has %d fields", len(kline))
+	}
+	var (
+		openTime, closeTime, numberTrades int64
+		prices                            [5]string
+	)
+	if err := json.Unmarshal(kline[0], &openTime); err != nil {
+		return dto.Candlestick{}, errors.Wrap(err, "open time")
+	}
+	if err := json.Unmarshal(kline[6], &closeTime); err != nil {
+		return dto.Candlestick{}, errors.Wrap(err, "close time")
+	}
+	if err := json.Unmarshal(kline[8], &numberTrades); err != nil {
+		return dto.Candlestick{}, errors.Wrap(err, "number trades")
+	}
+	for i := range prices {
+		if err := json.Unmarshal(kline[i+1], &prices[i]); err != nil {
+			return dto.Candlestick{}, errors.Wrapf(err, "field %d", i+1)
+		}
+	}
+	values, err := domain.ParsePrices(prices[:]...)
+	if err != nil {
+		return dto.Candlestick{}, err
+	}
+	return dto.Candlestick{
+		OpenTime:     time.UnixMilli(openTime).In(time.UTC),
+		CloseTime:    time.UnixMilli(closeTime).In(time.UTC),
+		OpenPrice:    values[0],
+		HighPrice:    values[1],
+		LowPrice:     values[2],
+		ClosePrice:   values[3],
+		Volume:       values[4],
+		NumberTrades: int(numberTrades),
+	}, nil
+}
@@ -0,0 +1,119 @@
+package binance
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/testutil"
+	"github.com/shopspring/decimal"
+)
+
+// fixtureServer returns client of server with recorded responses and query of the last request.
+func fixtureServer(t *testing.T, fixtures map[string]string) (*Client, *url.Values) {
+	t.Helper()
+	server, query := testutil.FixtureServer(t, fixtures)
+	client, err := New(server.URL, source.NewRequester(server.Client(), 100, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, query
+}
+
+func TestPrices(t *testing.T) {
+	client, _ := fixtureServer(t, map[string]string{"/api/v3/ticker/price": "ticker_price.json"})
+	prices, err := client.Prices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []domain.SourcePrice{
+		{Exchange: domain.BinanceExchange, Symbol: "BTCUSDT", Price: "67012.34000000"},
+		{Exchange: domain.BinanceExchange, Symbol: "ETHUSDT", Price: "3521.10000000"},
+		{Exchange: domain.BinanceExchange, Symbol: "PEPEUSDT", Price: "0.00001234"},
+	}
+	if len(prices) != len(expected) {
+		t.Fatalf("got %d prices, want %d", len(prices), len(expected))
+	}
+	for i, item := range expected {
+		if prices[i].Exchange != item.Exchange || prices[i].Symbol != item.Symbol || prices[i].Price != item.Price {
+			t.Errorf("price %d: got %+v, want %+v", i, prices[i], item)
+		}
+		if prices[i].Date.IsZero() {
+			t.Errorf("price %d has no date", i)
+		}
+	}
+}
+
+func TestSymbolPriceNormalizesSymbol(t *testing.T) {
+	client, query := fixtureServer(t, map[string]string{"/api/v3/ticker/price": "ticker_price_symbol.json"})
+	price, err := client.SymbolPrice(context.Background(), "btc-usdt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := query.Get("symbol"); got != "BTCUSDT" {
+		t.Errorf("requested symbol %q, want BTCUSDT", got)
+	}
+	if price.Symbol != "BTCUSDT" || price.Price != "67012.34000000" {
+		t.Errorf("got %+v", price)
+	}
+}
+
+func TestCandlesticks(t *testing.T) {
+	client, query := fixtureServer(t, map[string]string{"/api/v3/klines": "klines.json"})
+	candlesticks, err := client.Candlesticks(context.Background(), "btc_usdt", domain.OneHourInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("symbol") != "BTCUSDT" || query.Get("interval") != "1h" || query.Get("limit") != "500" {
+		t.Errorf("wrong query: %s", query.Encode())
+	}
+	if len(candlesticks) != 2 {
+		t.Fatalf("got %d candlesticks, want 2", len(candlesticks))
+	}
+	first := candlesticks[0]
+	if first.Symbol != "BTCUSDT" || first.Exchange != domain.BinanceExchange || first.Interval != "1h" {
+		t.Errorf("wrong candlestick: %+v", first)
+	}
+	if !first.OpenTime.Equal(time.UnixMilli(1700000000000)) || !first.CloseTime.Equal(time.UnixMilli(1700003599999)) {
+		t.Errorf("wrong times: %s - %s", first.OpenTime, first.CloseTime)
+	}
+	prices := map[string]struct{ got, want decimal.Decimal }{
+		"open":   {first.OpenPrice, decimal.RequireFromString("37000.01")},
+		"high":   {first.HighPrice, decimal.RequireFromString("37100")},
+		"low":    {first.LowPrice, decimal.RequireFromString("36900.5")},
+		"close":  {first.ClosePrice, decimal.RequireFromString("37050.25")},
+		"volume": {first.Volume, decimal.RequireFromString("123.456")},
+	}
+	for name, item := range prices {
+		if !item.got.Equal(item.want) {
+			t.Errorf("%s: got %s, want %s", name, item.got, item.want)
+		}
+	}
+	if first.NumberTrades != 1500 {
+		t.Errorf("got %d trades, want 1500", first.NumberTrades)
+	}
+	if !candlesticks[1].OpenTime.After(first.OpenTime) {
+		t.Error("candlesticks are not ordered by open time")
+	}
+}
+
+func TestCandlesticksBefore(t *testing.T) {
+	client, query := fixtureServer(t, map[string]string{"/api/v3/klines": "klines.json"})
+	end := time.UnixMilli(1700007200000)
+	if _, err := client.CandlesticksBefore(context.Background(), "BTCUSDT", domain.OneHourInterval, end); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("endTime") != "1700007199999" || query.Get("limit") != "1000" {
+		t.Errorf("wrong query: %s", query.Encode())
+	}
+}
+
+func TestCandlesticksMalformed(t *testing.T) {
+	client, _ := fixtureServer(t, map[string]string{"/api/v3/klines": "klines_malformed.json"})
+	if _, err := client.Candlesticks(context.Background(), "BTCUSDT", domain.OneHourInterval); err == nil {
+		t.Error("expected error of malformed price")
+	}
+}
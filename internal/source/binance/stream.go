@@ -0,0 +1,163 @@
+package binance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/stream"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultStreamHost = "wss://stream.binance.com:9443/ws"
+
+	// allMiniTickersStream sends prices of all symbols changed during the last second.
+	allMiniTickersStream = "!miniTicker@arr"
+	streamsPerMessage    = 100
+)
+
+var _ stream.Feed = (*Stream)(nil)
+
+// Stream is a websocket feed of binance: mini tickers of all symbols and klines of subscribed symbols.
+type Stream struct {
+	url string
+}
+
+func NewStream(url string) *Stream {
+	return &Stream{url: url}
+}
+
+func (s *Stream) Name() string {
+	return domain.BinanceExchange
+}
+
+func (s *Stream) URL() string {
+	return s.url
+}
+
+type subscribeMessage struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+func (s *Stream) SubscribeMessages(subscription stream.Subscription) []any {
+	streams := []string{allMiniTickersStream}
+	for _, symbol := range subscription.Symbols() {
+		for _, interval := range subscription[symbol] {
+			streams = append(streams, fmt.Sprintf(
+				"%s@kline_%s", strings.ToLower(source.NormalizeSymbol(symbol)), interval,
+			))
+		}
+	}
+	messages := make([]any, 0, len(streams)/streamsPerMessage+1)
+	for i := 0; i < len(streams); i += streamsPerMessage {
+		messages = append(messages, subscribeMessage{
+			Method: "SUBSCRIBE",
+			Params: streams[i:min(i+streamsPerMessage, len(streams))],
+			ID:     len(messages) + 1,
+		})
+	}
+	return messages
+}
+
+// PingMessage returns nil: binance uses websocket ping frames.
+func (s *Stream) PingMessage() any {
+	return nil
+}
+
+type miniTicker struct {
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	Close     string `json:"c"`
+}
+
+type klineEvent struct {
+	Event     string `json:"e"`
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	Kline     struct {
+		OpenTime     int64  `json:"t"`
+		CloseTime    int64  `json:"T"`
+		Interval     string `json:"i"`
+		Open         string `json:"o"`
+		Close        string `json:"c"`
+		High         string `json:"h"`
+		Low          string `json:"l"`
+		Volume       string `json:"v"`
+		NumberTrades int    `json:"n"`
+		Closed       bool   `json:"x"`
+	} `json:"k"`
+}
+
+func (s *Stream) Parse(msg []byte) ([]stream.Event, error) {
+	msg = bytes.TrimSpace(msg)
+	if len(msg) > 0 && msg[0] == '[' {
+		return parseMiniTickers(msg)
+	}
+	var event klineEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		return nil, errors.Wrap(err, "decode binance stream message")
+	}
+	if event.Event != "kline" {
+		// Subscription responses and unknown events.
+		return nil, nil
+	}
+	return parseKlineEvent(event)
+}
+
+func parseMiniTickers(msg []byte) ([]stream.Event, error) {
+	var tickers []miniTicker
+	if err := json.Unmarshal(msg, &tickers); err != nil {
+		return nil, errors.Wrap(err, "decode binance mini tickers")
+	}
+	events := make([]stream.Event, 0, len(tickers))
+	for _, ticker := range tickers {
+		price, err := domain.ParsePrice(ticker.Close)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse price of %s", ticker.Symbol)
+		}
+		events = append(events, stream.Event{
+			Type: stream.PriceEvent,
+			Price: domain.SymbolPrice{
+				Exchange: domain.BinanceExchange,
+				Symbol:   source.NormalizeSymbol(ticker.Symbol),
+				Price:    price,
+				Date:     time.UnixMilli(ticker.EventTime).In(time.UTC),
+			},
+		})
+	}
+	return events, nil
+}
+
+func parseKlineEvent(event klineEvent) ([]stream.Event, error) {
+	k := event.Kline
+	values, err := domain.ParsePrices(k.Open, k.High, k.Low, k.Close, k.Volume)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse kline of %s", event.Symbol)
+	}
+	return []stream.Event{{
+		Type: stream.CandlestickEvent,
+		Candlestick: dto.Candlestick{
+			Symbol:       source.NormalizeSymbol(event.Symbol),
+			Exchange:     domain.BinanceExchange,
+			OpenTime:     time.UnixMilli(k.OpenTime).In(time.UTC),
+			CloseTime:    time.UnixMilli(k.CloseTime).In(time.UTC),
+			OpenPrice:    values[0],
+			HighPrice:    values[1],
+			LowPrice:     values[2],
+			ClosePrice:   values[3],
+			Volume:       values[4],
+			NumberTrades: k.NumberTrades,
+			Interval:     k.Interval,
+			CreatedAt:    time.UnixMilli(event.EventTime).In(time.UTC),
+		},
+		Closed: k.Closed,
+	}}, nil
+}
@@ -0,0 +1,199 @@
+package bybit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultHost = "https://api.bybit.com"
+
+	spotCategory      = "spot"
+	candlesticksLimit = 200
+	// historyLimit is max limit of kline request.
+	historyLimit  = 1000
+	requestWeight = 1
+)
+
+// intervals maps common interval to bybit kline interval.
+var intervals = map[domain.Interval]string{
+	domain.OneMinuteInterval:     "1",
+	domain.FiveMinuteInterval:    "5",
+	domain.FifteenMinuteInterval: "15",
+	domain.ThirtyMinuteInterval:  "30",
+	domain.OneHourInterval:       "60",
+	domain.FourHourInterval:      "240",
+	domain.OneDayInterval:        "D",
+	domain.OneWeekInterval:       "W",
+}
+
+var _ source.Exchange = (*Client)(nil)
+
+type Client struct {
+	host      *url.URL
+	requester *source.Requester
+}
+
+func New(host string, requester *source.Requester) (*Client, error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse host")
+	}
+	return &Client{host: hostURL, requester: requester}, nil
+}
+
+func (c *Client) Name() string {
+	return domain.BybitExchange
+}
+
+type response[T any] struct {
+	RetCode int    `json:"retCode"`
+	RetMsg  string `json:"retMsg"`
+	Result  T      `json:"result"`
+	Time    int64  `json:"time"`
+}
+
+type tickers struct {
+	List []struct {
+		Symbol    string `json:"symbol"`
+		LastPrice string `json:"lastPrice"`
+	} `json:"list"`
+}
+
+type klines struct {
+	Symbol string `json:"symbol"`
+	// Kline: [startTime, open, high, low, close, volume, turnover], ordered from the newest.
+	List [][]string `json:"list"`
+}
+
+func (c *Client) Prices(ctx context.Context) ([]domain.SourcePrice, error) {
+	return c.tickers(ctx, url.Values{"category": {spotCategory}})
+}
+
+func (c *Client) SymbolPrice(ctx context.Context, symbol string) (domain.SourcePrice, error) {
+	prices, err := c.tickers(ctx, url.Values{"category": {spotCategory}, "symbol": {source.NormalizeSymbol(symbol)}})
+	if err != nil {
+		return domain.SourcePrice{}, err
+	}
+	if len(prices) == 0 {
+		return domain.SourcePrice{}, fmt.Errorf("not found bybit price for %s", symbol)
+	}
+	return prices[0], nil
+}
+
+// Candlesticks returns last candlesticks ordered by open time, the last one can be not closed yet.
+func (c *Client) Candlesticks(ctx context.Context, symbol string, interval domain.Interval) ([]dto.Candlestick, error) {
+	return c.klines(ctx, symbol, interval, url.Values{"limit": {strconv.Itoa(candlesticksLimit)}})
+}
+
+// CandlesticksBefore returns candlesticks opened before end ordered by open time.
+func (c *Client) CandlesticksBefore(
+	ctx context.Context, symbol string, interval domain.Interval, end time.Time,
+) ([]dto.Candlestick, error) {
+	return c.klines(ctx, symbol, interval, url.Values{
+		"limit": {strconv.Itoa(historyLimit)},
+		"end":   {strconv.FormatInt(end.UnixMilli()-1, 10)},
+	})
+}
+
+func (c *Client) klines(
+	ctx context.Context, symbol string, interval domain.Interval, params url.Values,
+) ([]dto.Candlestick, error) {
+	bybitInterval, has := intervals[interval]
+	if !has {
+		return nil, fmt.Errorf("unsupported bybit interval: %s", interval)
+	}
+	var resp response[klines]
+	params.Set("category", spotCategory)
+	params.Set("symbol", source.NormalizeSymbol(symbol))
+	params.Set("interval", bybitInterval)
+	if err := c.get(ctx, "/v5/market/kline", params, &resp); err != nil {
+		return nil, errors.Wrap(err, "load bybit klines")
+	}
+	now := time.Now().In(time.UTC)
+	candlesticks := make([]dto.Candlestick, 0, len(resp.Result.List))
+	for _, kline := range resp.Result.List {
+		candlestick, err := parseKline(kline)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse bybit kline")
+		}
+		candlestick.Symbol = source.NormalizeSymbol(symbol)
+		candlestick.Exchange = domain.BybitExchange
+		candlestick.Interval = interval.String()
+		candlestick.CloseTime = interval.CloseTime(candlestick.OpenTime)
+		candlestick.CreatedAt = now
+		candlesticks = append(candlesticks, candlestick)
+	}
+	sort.Slice(candlesticks, func(i, j int) bool {
+		return candlesticks[i].OpenTime.Before(candlesticks[j].OpenTime)
+	})
+	return candlesticks, nil
+}
+
+func (c *Client) tickers(ctx context.Context, params url.Values) ([]domain.SourcePrice, error) {
+	var resp response[tickers]
+	if err := c.get(ctx, "/v5/market/tickers", params, &resp); err != nil {
+		return nil, errors.Wrap(err, "load bybit tickers")
+	}
+	date := time.UnixMilli(resp.Time).In(time.UTC)
+	if resp.Time == 0 {
+		date = time.Now().In(time.UTC)
+	}
+	prices := make([]domain.SourcePrice, 0, len(resp.Result.List))
+	for _, item := range resp.Result.List {
+		prices = append(prices, domain.SourcePrice{
+			Exchange: domain.BybitExchange,
+			Symbol:   source.NormalizeSymbol(item.Symbol),
+			Price:    item.LastPrice,
+			Date:     date,
+		})
+	}
+	return prices, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, dest interface{ code() (int, string) }) error {
+	u := c.host.JoinPath(path)
+	u.RawQuery = params.Encode()
+	if err := c.requester.GetJSON(ctx, u.String(), requestWeight, dest); err != nil {
+		return err
+	}
+	if code, msg := dest.code(); code != 0 {
+		return fmt.Errorf("bybit error %d: %s", code, msg)
+	}
+	return nil
+}
+
+func (r *response[T]) code() (int, string) {
+	return r.RetCode, r.RetMsg
+}
+
+func parseKline(kline []string) (dto.Candlestick, error) {
+	if len(kline) < 6 {
+		return dto.Candlestick{}, fmt.Errorf("kline has %d fields", len(kline))
+	}
+	openTime, err := strconv.ParseInt(kline[0], 10, 64)
+	if err != nil {
+		return dto.Candlestick{}, errors.Wrap(err, "open time")
+	}
+	values, err := domain.ParsePrices(kline[1:6]...)
+	if err != nil {
+		return dto.Candlestick{}, err
+	}
+	return dto.Candlestick{
+		OpenTime:   time.UnixMilli(openTime).In(time.UTC),
+		OpenPrice:  values[0],
+		HighPrice:  values[1],
+		LowPrice:   values[2],
+		ClosePrice: values[3],
+		Volume:     values[4],
+	}, nil
+}
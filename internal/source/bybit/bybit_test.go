@@ -0,0 +1,114 @@
+package bybit
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/testutil"
+	"github.com/shopspring/decimal"
+)
+
+// fixtureServer returns client of server with recorded responses and query of the last request.
+func fixtureServer(t *testing.T, fixtures map[string]string) (*Client, *url.Values) {
+	t.Helper()
+	server, query := testutil.FixtureServer(t, fixtures)
+	client, err := New(server.URL, source.NewRequester(server.Client(), 100, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, query
+}
+
+func TestPrices(t *testing.T) {
+	client, query := fixtureServer(t, map[string]string{"/v5/market/tickers": "tickers.json"})
+	prices, err := client.Prices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("category") != spotCategory {
+		t.Errorf("wrong query: %s", query.Encode())
+	}
+	date := time.UnixMilli(1700000000123)
+	expected := []domain.SourcePrice{
+		{Exchange: domain.BybitExchange, Symbol: "BTCUSDT", Price: "67012.34", Date: date},
+		{Exchange: domain.BybitExchange, Symbol: "PEPEUSDT", Price: "0.00001234", Date: date},
+	}
+	if len(prices) != len(expected) {
+		t.Fatalf("got %d prices, want %d", len(prices), len(expected))
+	}
+	for i, item := range expected {
+		if prices[i].Exchange != item.Exchange || prices[i].Symbol != item.Symbol ||
+			prices[i].Price != item.Price || !prices[i].Date.Equal(item.Date) {
+			t.Errorf("price %d: got %+v, want %+v", i, prices[i], item)
+		}
+	}
+}
+
+func TestSymbolPriceNormalizesSymbol(t *testing.T) {
+	client, query := fixtureServer(t, map[string]string{"/v5/market/tickers": "tickers.json"})
+	price, err := client.SymbolPrice(context.Background(), "btc/usdt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := query.Get("symbol"); got != "BTCUSDT" {
+		t.Errorf("requested symbol %q, want BTCUSDT", got)
+	}
+	if price.Symbol != "BTCUSDT" || price.Price != "67012.34" {
+		t.Errorf("got %+v", price)
+	}
+}
+
+func TestCandlesticks(t *testing.T) {
+	client, query := fixtureServer(t, map[string]string{"/v5/market/kline": "kline.json"})
+	candlesticks, err := client.Candlesticks(context.Background(), "btc-usdt", domain.OneHourInterval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("symbol") != "BTCUSDT" || query.Get("interval") != "60" || query.Get("limit") != "200" {
+		t.Errorf("wrong query: %s", query.Encode())
+	}
+	if len(candlesticks) != 2 {
+		t.Fatalf("got %d candlesticks, want 2", len(candlesticks))
+	}
+	// Bybit returns klines from the newest, candlesticks are ordered by open time.
+	first := candlesticks[0]
+	if !first.OpenTime.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("wrong open time of the first candlestick: %s", first.OpenTime)
+	}
+	if !first.CloseTime.Equal(time.UnixMilli(1700003599999)) {
+		t.Errorf("wrong close time: %s", first.CloseTime)
+	}
+	if first.Symbol != "BTCUSDT" || first.Exchange != domain.BybitExchange || first.Interval != "1h" {
+		t.Errorf("wrong candlestick: %+v", first)
+	}
+	prices := map[string]struct{ got, want decimal.Decimal }{
+		"open":   {first.OpenPrice, decimal.RequireFromString("37000.01")},
+		"high":   {first.HighPrice, decimal.RequireFromString("37100")},
+		"low":    {first.LowPrice, decimal.RequireFromString("36900.5")},
+		"close":  {first.ClosePrice, decimal.RequireFromString("37050.25")},
+		"volume": {first.Volume, decimal.RequireFromString("123.456")},
+	}
+	for name, item := range prices {
+		if !item.got.Equal(item.want) {
+			t.Errorf("%s: got %s, want %s", name, item.got, item.want)
+		}
+	}
+}
+
+func TestCandlesticksUnsupportedInterval(t *testing.T) {
+	client, _ := fixtureServer(t, map[string]string{"/v5/market/kline": "kline.json"})
+	if _, err := client.Candlesticks(context.Background(), "BTCUSDT", domain.Interval("3d")); err == nil {
+		t.Error("expected error of unsupported interval")
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	client, _ := fixtureServer(t, map[string]string{"/v5/market/tickers": "error.json"})
+	if _, err := client.Prices(context.Background()); err == nil {
+		t.Error("expected error of non-zero retCode")
+	}
+}
@@ -0,0 +1,176 @@
+package bybit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/stream"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultStreamHost = "wss://stream.bybit.com/v5/public/spot"
+
+	// argsPerMessage is a limit of topics in one subscribe request for spot.
+	argsPerMessage = 10
+)
+
+var _ stream.Feed = (*Stream)(nil)
+
+// Stream is a websocket feed of bybit: tickers and klines of subscribed symbols.
+type Stream struct {
+	url string
+}
+
+func NewStream(url string) *Stream {
+	return &Stream{url: url}
+}
+
+func (s *Stream) Name() string {
+	return domain.BybitExchange
+}
+
+func (s *Stream) URL() string {
+	return s.url
+}
+
+type operation struct {
+	Op   string   `json:"op"`
+	Args []string `json:"args,omitempty"`
+}
+
+func (s *Stream) SubscribeMessages(subscription stream.Subscription) []any {
+	var topics []string
+	for _, symbol := range subscription.Symbols() {
+		symbolIntervals := subscription[symbol]
+		symbol = source.NormalizeSymbol(symbol)
+		topics = append(topics, "tickers."+symbol)
+		for _, interval := range symbolIntervals {
+			bybitInterval, has := intervals[interval]
+			if !has {
+				zap.L().Warn("unsupported bybit interval", zap.Stringer("interval", interval))
+				continue
+			}
+			topics = append(topics, fmt.Sprintf("kline.%s.%s", bybitInterval, symbol))
+		}
+	}
+	messages := make([]any, 0, len(topics)/argsPerMessage+1)
+	for i := 0; i < len(topics); i += argsPerMessage {
+		messages = append(messages, operation{Op: "subscribe", Args: topics[i:min(i+argsPerMessage, len(topics))]})
+	}
+	return messages
+}
+
+// PingMessage returns heartbeat, bybit closes connection without it.
+func (s *Stream) PingMessage() any {
+	return operation{Op: "ping"}
+}
+
+type streamMessage struct {
+	Topic string          `json:"topic"`
+	TS    int64           `json:"ts"`
+	Data  json.RawMessage `json:"data"`
+}
+
+type streamTicker struct {
+	Symbol    string `json:"symbol"`
+	LastPrice string `json:"lastPrice"`
+}
+
+type streamKline struct {
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Interval string `json:"interval"`
+	Open     string `json:"open"`
+	Close    string `json:"close"`
+	High     string `json:"high"`
+	Low      string `json:"low"`
+	Volume   string `json:"volume"`
+	Confirm  bool   `json:"confirm"`
+}
+
+func (s *Stream) Parse(msg []byte) ([]stream.Event, error) {
+	var message streamMessage
+	if err := json.Unmarshal(msg, &message); err != nil {
+		return nil, errors.Wrap(err, "decode bybit stream message")
+	}
+	switch {
+	case strings.HasPrefix(message.Topic, "tickers."):
+		return parseStreamTicker(message)
+	case strings.HasPrefix(message.Topic, "kline."):
+		return parseStreamKlines(message)
+	}
+	// Operation responses: subscribe, pong.
+	return nil, nil
+}
+
+func parseStreamTicker(message streamMessage) ([]stream.Event, error) {
+	var ticker streamTicker
+	if err := json.Unmarshal(message.Data, &ticker); err != nil {
+		return nil, errors.Wrap(err, "decode bybit ticker")
+	}
+	price, err := domain.ParsePrice(ticker.LastPrice)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse price of %s", ticker.Symbol)
+	}
+	return []stream.Event{{
+		Type: stream.PriceEvent,
+		Price: domain.SymbolPrice{
+			Exchange: domain.BybitExchange,
+			Symbol:   source.NormalizeSymbol(ticker.Symbol),
+			Price:    price,
+			Date:     time.UnixMilli(message.TS).In(time.UTC),
+		},
+	}}, nil
+}
+
+func parseStreamKlines(message streamMessage) ([]stream.Event, error) {
+	// Topic: kline.{interval}.{symbol}
+	parts := strings.Split(message.Topic, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("wrong bybit kline topic: %s", message.Topic)
+	}
+	var klines []streamKline
+	if err := json.Unmarshal(message.Data, &klines); err != nil {
+		return nil, errors.Wrap(err, "decode bybit klines")
+	}
+	interval, has := intervalByCode(parts[1])
+	if !has {
+		return nil, fmt.Errorf("unsupported bybit interval: %s", parts[1])
+	}
+	events := make([]stream.Event, 0, len(klines))
+	for _, kline := range klines {
+		candlestick, err := parseKline([]string{
+			strconv.FormatInt(kline.Start, 10), kline.Open, kline.High, kline.Low, kline.Close, kline.Volume,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "parse bybit kline")
+		}
+		candlestick.Symbol = source.NormalizeSymbol(parts[2])
+		candlestick.Exchange = domain.BybitExchange
+		candlestick.Interval = interval.String()
+		candlestick.CloseTime = time.UnixMilli(kline.End).In(time.UTC)
+		candlestick.CreatedAt = time.UnixMilli(message.TS).In(time.UTC)
+		events = append(events, stream.Event{
+			Type:        stream.CandlestickEvent,
+			Candlestick: candlestick,
+			Closed:      kline.Confirm,
+		})
+	}
+	return events, nil
+}
+
+func intervalByCode(code string) (domain.Interval, bool) {
+	for interval, bybitInterval := range intervals {
+		if bybitInterval == code {
+			return interval, true
+		}
+	}
+	return "", false
+}
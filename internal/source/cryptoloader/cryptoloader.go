@@ -0,0 +1,108 @@
+package cryptoloader
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_loader/api/http/client"
+)
+
+var _ domain.MarketDataSource = (*Source)(nil)
+
+// Source is adapter of crypto_loader http client.
+type Source struct {
+	client *client.Client
+}
+
+func New(host string, httpClient *http.Client) (*Source, error) {
+	c, err := client.NewClient(host, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{client: c}, nil
+}
+
+func (s *Source) AllSymbolPrices(ctx context.Context) ([]domain.SourcePrice, error) {
+	resp, err := s.client.AllSymbolPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toSourcePrices(resp), nil
+}
+
+func (s *Source) ExchangePrices(ctx context.Context, exchange string) ([]domain.SourcePrice, error) {
+	resp, err := s.client.ExchangePrices(ctx, exchange)
+	if err != nil {
+		return nil, err
+	}
+	return toSourcePrices(resp), nil
+}
+
+// SymbolSnapshot returns candlesticks of requested intervals, crypto_loader has snapshot only for 4h and 1h.
+func (s *Source) SymbolSnapshot(
+	ctx context.Context, exchange, symbol string, intervals ...domain.Interval,
+) (domain.SymbolSnapshot, error) {
+	resp, err := s.client.SymbolSnapshot(ctx, exchange, symbol)
+	if err != nil {
+		return domain.SymbolSnapshot{}, err
+	}
+	snapshot := domain.SymbolSnapshot{
+		Symbol:    resp.Symbol,
+		Exchange:  resp.Exchange,
+		Price:     resp.Price,
+		CreatedAt: resp.CreatedAt,
+	}
+	for _, item := range toCandlesticks(resp.Candlestick4H, resp.Candlestick1H) {
+		if len(intervals) == 0 || slices.Contains(intervals, domain.Interval(item.Interval)) {
+			snapshot.Candlesticks = append(snapshot.Candlesticks, item)
+		}
+	}
+	return snapshot, nil
+}
+
+func (s *Source) Candlesticks(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) ([]dto.Candlestick, error) {
+	resp, err := s.client.Candlesticks(ctx, exchange, symbol, interval.String())
+	if err != nil {
+		return nil, err
+	}
+	return toCandlesticks(resp...), nil
+}
+
+func toSourcePrices(data []client.PriceResponse) []domain.SourcePrice {
+	prices := make([]domain.SourcePrice, 0, len(data))
+	for _, item := range data {
+		prices = append(prices, domain.SourcePrice{
+			Exchange: item.Exchange,
+			Symbol:   item.Symbol,
+			Price:    item.Price,
+			Date:     item.Date,
+		})
+	}
+	return prices
+}
+
+func toCandlesticks(data ...client.SymbolSnapshotCandlestick) []dto.Candlestick {
+	candlesticks := make([]dto.Candlestick, 0, len(data))
+	for _, item := range data {
+		candlesticks = append(candlesticks, dto.Candlestick{
+			Symbol:       item.Symbol,
+			Exchange:     item.Exchange,
+			OpenTime:     item.OpenTime,
+			CloseTime:    item.CloseTime,
+			OpenPrice:    domain.PriceFromFloat(item.OpenPrice),
+			HighPrice:    domain.PriceFromFloat(item.HighPrice),
+			LowPrice:     domain.PriceFromFloat(item.LowPrice),
+			ClosePrice:   domain.PriceFromFloat(item.ClosePrice),
+			Volume:       domain.PriceFromFloat(item.Volume),
+			NumberTrades: item.NumberTrades,
+			Interval:     item.Interval,
+			CreatedAt:    item.CreatedAt,
+		})
+	}
+	return candlesticks
+}
@@ -0,0 +1,263 @@
+package replay_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/calculation"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/components/loader"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/source/replay"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/testutil"
+	"github.com/shopspring/decimal"
+)
+
+// memory is in-memory storage of prices, candlesticks and price changes used by pipeline.
+type memory struct {
+	domain.CandlestickLoader
+
+	mu           sync.Mutex
+	prices       map[string]domain.SymbolPrice
+	candlesticks map[string]dto.Candlestick
+	changes      map[string]domain.PriceChange
+}
+
+func newMemory() *memory {
+	return &memory{
+		prices:       make(map[string]domain.SymbolPrice),
+		candlesticks: make(map[string]dto.Candlestick),
+		changes:      make(map[string]domain.PriceChange),
+	}
+}
+
+func (m *memory) SavePrices(_ context.Context, prices []*domain.SymbolPrice) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, price := range prices {
+		m.prices[price.Exchange+price.Symbol+price.Date.String()] = *price
+	}
+	return nil
+}
+
+func (m *memory) AddNewSymbol(context.Context, []domain.SymbolPrice) error {
+	return nil
+}
+
+func (m *memory) ExchangeSymbols(context.Context) ([]dto.ExchangeSymbol, error) {
+	return nil, nil
+}
+
+func (m *memory) PopularSymbols(_ context.Context, limit int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	exchanges := make(map[string]map[string]bool)
+	for _, price := range m.prices {
+		if exchanges[price.Symbol] == nil {
+			exchanges[price.Symbol] = make(map[string]bool)
+		}
+		exchanges[price.Symbol][price.Exchange] = true
+	}
+	var symbols []string
+	for symbol, items := range exchanges {
+		if len(items) >= limit {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+func (m *memory) FirstDatetime(_ context.Context, symbol string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var first time.Time
+	for _, price := range m.prices {
+		if price.Symbol == symbol && (first.IsZero() || price.Date.Before(first)) {
+			first = price.Date
+		}
+	}
+	return first, nil
+}
+
+func (m *memory) SymbolPrices(_ context.Context, symbol string, from, to time.Time) ([]domain.SymbolPrice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []domain.SymbolPrice
+	for _, price := range m.prices {
+		if price.Symbol == symbol && !price.Date.Before(from) && !price.Date.After(to) {
+			result = append(result, price)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date.Before(result[j].Date) })
+	return result, nil
+}
+
+func (m *memory) Save(_ context.Context, data []domain.PriceChange) ([]domain.PriceChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var inserted []domain.PriceChange
+	for _, item := range data {
+		key := item.Exchange + item.Symbol + item.Date.String()
+		if _, has := m.changes[key]; has {
+			continue
+		}
+		m.changes[key] = item
+		inserted = append(inserted, item)
+	}
+	return inserted, nil
+}
+
+func (m *memory) LastDatetimeSymbolRow(_ context.Context, symbol string) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var last time.Time
+	for _, item := range m.changes {
+		if item.Symbol == symbol && item.Date.After(last) {
+			last = item.Date
+		}
+	}
+	return last, nil
+}
+
+func (m *memory) SaveCandlesticks(data []dto.Candlestick) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range data {
+		m.candlesticks[item.Exchange+item.Symbol+item.Interval+item.OpenTime.String()] = item
+	}
+}
+
+func (m *memory) count(items func(m *memory) int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return items(m)
+}
+
+// candlestickStorage saves candlesticks to memory, loader only saves them.
+type candlestickStorage struct {
+	*memory
+}
+
+func (s candlestickStorage) Save(_ context.Context, data []dto.Candlestick) error {
+	s.SaveCandlesticks(data)
+	return nil
+}
+
+type watchlist []domain.WatchlistItem
+
+func (w watchlist) Watchlist(context.Context) ([]domain.WatchlistItem, error) {
+	return w, nil
+}
+
+func (w watchlist) WatchlistItem(_ context.Context, exchange, symbol string) (domain.WatchlistItem, error) {
+	for _, item := range w {
+		if item.Exchange == exchange && item.Symbol == symbol {
+			return item, nil
+		}
+	}
+	return domain.WatchlistItem{}, domain.ErrWatchlistItemNotFound
+}
+
+type handler struct {
+	mu      sync.Mutex
+	changes int
+}
+
+func (h *handler) HandlePriceChanges(_ context.Context, changes []domain.PriceChange) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.changes += len(changes)
+	return nil
+}
+
+// TestPipeline runs loader, storage and calculation of price changes on replayed frames without network.
+func TestPipeline(t *testing.T) {
+	const count = 30
+	var (
+		symbols = []string{domain.BTCUSDT, domain.ETHUSDT}
+		start   = time.Now().Add(-3 * time.Hour).Truncate(time.Hour).UTC()
+		frames  = replay.Generate(1, domain.ListExchanges, symbols, start, count, time.Minute)
+		storage = newMemory()
+		conf    = loader.Config{
+			LoadPricesDuration:         time.Millisecond,
+			LoadExchangePricesDuration: time.Millisecond,
+			LoadSymbolsDuration:        time.Hour,
+			LoadSnapshotDuration:       time.Hour,
+			LoadCandlesticksDuration:   5 * time.Millisecond,
+		}
+		items = watchlist{{Exchange: domain.BinanceExchange, Symbol: domain.BTCUSDT, Intervals: []domain.Interval{
+			domain.OneMinuteInterval,
+		}}}
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	source := replay.New(frames...)
+	price := loader.NewPrice(source, storage, storage, storage, conf)
+	loaderDone := make(chan struct{})
+	go func() {
+		defer close(loaderDone)
+		_ = loader.NewLoader(source, storage, candlestickStorage{storage}, items, price, conf).Run(ctx)
+	}()
+	testutil.WaitFor(t, 10*time.Second, func() bool {
+		return storage.count(func(m *memory) int { return len(m.prices) }) == count*len(domain.ListExchanges)*len(symbols) &&
+			storage.count(func(m *memory) int { return len(m.candlesticks) }) == count-1
+	})
+	cancel()
+	<-loaderDone
+
+	changesHandler := &handler{}
+	calculator := calculation.NewChangeCalculator(storage, storage, storage)
+	calculator.WithMinExchanges(len(domain.ListExchanges))
+	calculator.WithHandlers(changesHandler)
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		_ = calculator.Run(ctx, 10*time.Millisecond)
+	}()
+	expected := (count - 1) * len(domain.ListExchanges) * len(symbols)
+	testutil.WaitFor(t, 10*time.Second, func() bool {
+		changesHandler.mu.Lock()
+		defer changesHandler.mu.Unlock()
+		return storage.count(func(m *memory) int { return len(m.changes) }) == expected && changesHandler.changes >= expected
+	})
+	// Window of changes is recalculated, already saved changes are not handled again.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	storage.mu.Lock()
+	defer storage.mu.Unlock()
+
+	for i := 1; i < count; i++ {
+		for j, item := range frames[i].Prices {
+			prev := frames[i-1].Prices[j]
+			cur, prevPrice := decimal.RequireFromString(item.Price), decimal.RequireFromString(prev.Price)
+			change, has := storage.changes[item.Exchange+item.Symbol+item.Date.String()]
+			if !has {
+				t.Fatalf("no price change of %s/%s at %s", item.Symbol, item.Exchange, item.Date)
+			}
+			if !change.Price.Equal(cur) || !change.PrevPrice.Equal(prevPrice) {
+				t.Fatalf("prices of %s/%s at %s: got %s -> %s, want %s -> %s",
+					item.Symbol, item.Exchange, item.Date, change.PrevPrice, change.Price, prevPrice, cur)
+			}
+			coefficient := cur.Sub(prevPrice).Div(cur).Mul(decimal.NewFromInt(10000)).IntPart()
+			if change.CoefficientOfChange != coefficient {
+				t.Fatalf("coefficient of %s/%s at %s: got %d, want %d",
+					item.Symbol, item.Exchange, item.Date, change.CoefficientOfChange, coefficient)
+			}
+		}
+	}
+	for _, item := range storage.candlesticks {
+		if item.Exchange != domain.BinanceExchange || item.Symbol != domain.BTCUSDT ||
+			item.Interval != domain.OneMinuteInterval.String() {
+			t.Fatalf("candlestick of symbol out of watchlist: %+v", item)
+		}
+	}
+	changesHandler.mu.Lock()
+	defer changesHandler.mu.Unlock()
+	if changesHandler.changes != expected {
+		t.Fatalf("handlers received %d price changes, want %d", changesHandler.changes, expected)
+	}
+}
@@ -0,0 +1,221 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	_ domain.MarketDataSource   = (*Source)(nil)
+	_ domain.CandlestickHistory = (*Source)(nil)
+)
+
+// historyLimit is max count of candlesticks returned by CandlesticksBefore.
+const historyLimit = 500
+
+// Frame is state of market in one moment.
+type Frame struct {
+	Prices       []domain.SourcePrice `json:"prices"`
+	Candlesticks []dto.Candlestick    `json:"candlesticks"`
+}
+
+// Source is deterministic in-memory source, it replays frames one by one.
+// Every call of AllSymbolPrices moves to the next frame, other methods read current frame.
+// After the last frame the source keeps returning it.
+type Source struct {
+	frames []Frame
+	pos    int
+	mu     sync.Mutex
+}
+
+func New(frames ...Frame) *Source {
+	return &Source{frames: frames, pos: -1}
+}
+
+// Load reads frames from json file: [{"prices": [...], "candlesticks": [...]}, ...].
+func Load(path string) (*Source, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read replay file")
+	}
+	var frames []Frame
+	if err := json.Unmarshal(content, &frames); err != nil {
+		return nil, errors.Wrap(err, "parse replay file")
+	}
+	return New(frames...), nil
+}
+
+// Generate creates frames with random walk prices and candlesticks of intervals not shorter than step,
+// the same seed gives the same frames.
+func Generate(seed int64, exchanges, symbols []string, start time.Time, count int, step time.Duration) []Frame {
+	rnd := rand.New(rand.NewSource(seed))
+	prices := make(map[string]float64)
+	candles := make(map[string]*dto.Candlestick)
+	frames := make([]Frame, 0, count)
+	for i := 0; i < count; i++ {
+		date := start.Add(time.Duration(i) * step).In(time.UTC)
+		frame := Frame{}
+		for _, exchange := range exchanges {
+			for _, symbol := range symbols {
+				key := exchange + symbol
+				price, has := prices[key]
+				if !has {
+					price = 1 + rnd.Float64()*1000
+				}
+				price *= 1 + (rnd.Float64()-0.5)/100
+				prices[key] = price
+				frame.Prices = append(frame.Prices, domain.SourcePrice{
+					Exchange: exchange,
+					Symbol:   symbol,
+					Price:    strconv.FormatFloat(price, 'f', -1, 64),
+					Date:     date,
+				})
+
+				volume, trades := domain.PriceFromFloat(rnd.Float64()*10), rnd.Intn(100)
+				candlePrice := domain.PriceFromFloat(price)
+				for _, interval := range domain.ListIntervals {
+					if interval.Duration() < step {
+						continue
+					}
+					openTime := interval.Truncate(date)
+					candle := candles[key+interval.String()]
+					if candle == nil || !candle.OpenTime.Equal(openTime) {
+						if candle != nil {
+							frame.Candlesticks = append(frame.Candlesticks, *candle)
+						}
+						candle = &dto.Candlestick{
+							Symbol:    symbol,
+							Exchange:  exchange,
+							OpenTime:  openTime,
+							CloseTime: interval.CloseTime(openTime),
+							OpenPrice: candlePrice,
+							HighPrice: candlePrice,
+							LowPrice:  candlePrice,
+							Interval:  interval.String(),
+							CreatedAt: date,
+						}
+						candles[key+interval.String()] = candle
+					}
+					candle.HighPrice = decimal.Max(candle.HighPrice, candlePrice)
+					candle.LowPrice = decimal.Min(candle.LowPrice, candlePrice)
+					candle.ClosePrice = candlePrice
+					candle.Volume = candle.Volume.Add(volume)
+					candle.NumberTrades += trades
+				}
+			}
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func (s *Source) AllSymbolPrices(ctx context.Context) ([]domain.SourcePrice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pos < len(s.frames)-1 {
+		s.pos++
+	}
+	frame := s.current()
+	return append([]domain.SourcePrice(nil), frame.Prices...), nil
+}
+
+func (s *Source) ExchangePrices(ctx context.Context, exchange string) ([]domain.SourcePrice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var prices []domain.SourcePrice
+	for _, item := range s.current().Prices {
+		if item.Exchange == exchange {
+			prices = append(prices, item)
+		}
+	}
+	return prices, nil
+}
+
+func (s *Source) SymbolSnapshot(
+	ctx context.Context, exchange, symbol string, intervals ...domain.Interval,
+) (domain.SymbolSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := domain.SymbolSnapshot{Symbol: symbol, Exchange: exchange}
+	for _, item := range s.current().Prices {
+		if item.Exchange == exchange && item.Symbol == symbol {
+			snapshot.Price = item.Price
+			snapshot.CreatedAt = item.Date
+		}
+	}
+	last := make(map[string]dto.Candlestick)
+	for _, item := range s.candlesticks(exchange, symbol) {
+		if len(intervals) > 0 && !slices.Contains(intervals, domain.Interval(item.Interval)) {
+			continue
+		}
+		if item.CloseTime.After(last[item.Interval].CloseTime) {
+			last[item.Interval] = item
+		}
+	}
+	for _, item := range last {
+		snapshot.Candlesticks = append(snapshot.Candlesticks, item)
+	}
+	return snapshot, nil
+}
+
+func (s *Source) Candlesticks(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) ([]dto.Candlestick, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []dto.Candlestick
+	for _, item := range s.candlesticks(exchange, symbol) {
+		if item.Interval == interval.String() {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (s *Source) CandlesticksBefore(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, end time.Time,
+) ([]dto.Candlestick, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []dto.Candlestick
+	for _, item := range s.candlesticks(exchange, symbol) {
+		if item.Interval == interval.String() && item.OpenTime.Before(end) {
+			result = append(result, item)
+		}
+	}
+	if len(result) > historyLimit {
+		result = result[len(result)-historyLimit:]
+	}
+	return result, nil
+}
+
+// candlesticks returns candlesticks of all frames up to current one.
+func (s *Source) candlesticks(exchange, symbol string) []dto.Candlestick {
+	var result []dto.Candlestick
+	for i := 0; i <= s.pos && i < len(s.frames); i++ {
+		for _, item := range s.frames[i].Candlesticks {
+			if item.Exchange == exchange && item.Symbol == symbol {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+func (s *Source) current() Frame {
+	if s.pos < 0 || len(s.frames) == 0 {
+		return Frame{}
+	}
+	return s.frames[s.pos]
+}
@@ -0,0 +1,86 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultMaxElapsedTime = time.Minute
+	maxErrorBodyLength    = 512
+)
+
+// Requester sends GET requests to exchange REST API with rate limit and retries.
+type Requester struct {
+	client  *http.Client
+	limiter *rate.Limiter
+}
+
+// NewRequester creates requester which spends not more than limit weight per second.
+func NewRequester(client *http.Client, limit float64, burst int) *Requester {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &Requester{client: client, limiter: rate.NewLimiter(rate.Limit(limit), burst)}
+}
+
+// GetJSON decodes response into dest. Network errors, 5xx and 429/418 are retried with exponential backoff,
+// other statuses fail immediately.
+func (r *Requester) GetJSON(ctx context.Context, url string, weight int, dest any) error {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = defaultMaxElapsedTime
+	return backoff.Retry(func() error {
+		if err := r.limiter.WaitN(ctx, min(weight, r.limiter.Burst())); err != nil {
+			return backoff.Permanent(err)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return backoff.Permanent(errors.Wrap(err, "create request"))
+		}
+		resp, err := r.client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return backoff.Permanent(ctx.Err())
+			}
+			return errors.Wrap(err, "send request")
+		}
+		defer func() { _ = resp.Body.Close() }()
+		switch {
+		case resp.StatusCode == http.StatusOK:
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot:
+			waitRetryAfter(ctx, resp.Header.Get("Retry-After"))
+			return fmt.Errorf("rate limit exceeded: %d", resp.StatusCode)
+		case resp.StatusCode >= http.StatusInternalServerError:
+			return fmt.Errorf("wrong status code: %d", resp.StatusCode)
+		default:
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+			return backoff.Permanent(fmt.Errorf("wrong status code: %d (%s)", resp.StatusCode, body))
+		}
+		if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+			return backoff.Permanent(errors.Wrap(err, "decode response"))
+		}
+		return nil
+	}, backoff.WithContext(b, ctx))
+}
+
+func waitRetryAfter(ctx context.Context, header string) {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds <= 0 {
+		return
+	}
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
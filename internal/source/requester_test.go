@@ -0,0 +1,103 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type ticker struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+func TestGetJSONRetriesTooManyRequests(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{"symbol": "BTCUSDT", "price": "67012.34"}`))
+	}))
+	defer server.Close()
+
+	var resp ticker
+	start := time.Now()
+	if err := NewRequester(server.Client(), 100, 100).GetJSON(context.Background(), server.URL, 1, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retry after %s, want at least Retry-After 1s", elapsed)
+	}
+	if resp.Symbol != "BTCUSDT" || resp.Price != "67012.34" {
+		t.Errorf("got %+v", resp)
+	}
+}
+
+func TestGetJSONRetriesServerError(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"symbol": "BTCUSDT", "price": "67012.34"}`))
+	}))
+	defer server.Close()
+
+	var resp ticker
+	if err := NewRequester(server.Client(), 100, 100).GetJSON(context.Background(), server.URL, 1, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+}
+
+func TestGetJSONClientErrorIsPermanent(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound} {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"code": -1121, "msg": "Invalid symbol."}`))
+		}))
+
+		var resp ticker
+		err := NewRequester(server.Client(), 100, 100).GetJSON(context.Background(), server.URL, 1, &resp)
+		server.Close()
+		if err == nil {
+			t.Fatalf("status %d: expected error", status)
+		}
+		if got := requests.Load(); got != 1 {
+			t.Errorf("status %d: got %d requests, want 1", status, got)
+		}
+	}
+}
+
+func TestGetJSONCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var resp ticker
+	start := time.Now()
+	if err := NewRequester(server.Client(), 100, 100).GetJSON(ctx, server.URL, 1, &resp); err == nil {
+		t.Fatal("expected error of canceled context")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request returned after %s, Retry-After must be interrupted by context", elapsed)
+	}
+}
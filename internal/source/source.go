@@ -0,0 +1,141 @@
+package source
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var (
+	_ domain.MarketDataSource   = (*Exchanges)(nil)
+	_ domain.CandlestickHistory = (*Exchanges)(nil)
+)
+
+var ErrUnknownExchange = errors.New("unknown exchange")
+
+// Exchange is a market data adapter of one exchange.
+type Exchange interface {
+	Name() string
+	Prices(ctx context.Context) ([]domain.SourcePrice, error)
+	SymbolPrice(ctx context.Context, symbol string) (domain.SourcePrice, error)
+	Candlesticks(ctx context.Context, symbol string, interval domain.Interval) ([]dto.Candlestick, error)
+	CandlesticksBefore(ctx context.Context, symbol string, interval domain.Interval, end time.Time) ([]dto.Candlestick, error)
+}
+
+// Exchanges combines adapters of exchanges into one MarketDataSource.
+type Exchanges struct {
+	exchanges map[string]Exchange
+	names     []string
+}
+
+func NewExchanges(exchanges ...Exchange) *Exchanges {
+	s := &Exchanges{exchanges: make(map[string]Exchange, len(exchanges))}
+	for _, exchange := range exchanges {
+		s.exchanges[exchange.Name()] = exchange
+		s.names = append(s.names, exchange.Name())
+	}
+	return s
+}
+
+// AllSymbolPrices returns prices of all exchanges, it fails only when no exchange responded.
+func (s *Exchanges) AllSymbolPrices(ctx context.Context) ([]domain.SourcePrice, error) {
+	var (
+		result  []domain.SourcePrice
+		lastErr error
+		success int
+	)
+	for _, name := range s.names {
+		prices, err := s.exchanges[name].Prices(ctx)
+		if err != nil {
+			zap.L().Error("error load exchange prices", zap.String("exchange", name), zap.Error(err))
+			lastErr = err
+			continue
+		}
+		success++
+		result = append(result, prices...)
+	}
+	if success == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return result, nil
+}
+
+func (s *Exchanges) ExchangePrices(ctx context.Context, exchange string) ([]domain.SourcePrice, error) {
+	ex, has := s.exchanges[exchange]
+	if !has {
+		return nil, unknownExchange(exchange)
+	}
+	return ex.Prices(ctx)
+}
+
+func (s *Exchanges) SymbolSnapshot(
+	ctx context.Context, exchange, symbol string, intervals ...domain.Interval,
+) (domain.SymbolSnapshot, error) {
+	ex, has := s.exchanges[exchange]
+	if !has {
+		return domain.SymbolSnapshot{}, unknownExchange(exchange)
+	}
+	price, err := ex.SymbolPrice(ctx, symbol)
+	if err != nil {
+		return domain.SymbolSnapshot{}, errors.Wrap(err, "load symbol price")
+	}
+	snapshot := domain.SymbolSnapshot{
+		Symbol:    symbol,
+		Exchange:  exchange,
+		Price:     price.Price,
+		CreatedAt: price.Date,
+	}
+	if len(intervals) == 0 {
+		intervals = domain.ListIntervals
+	}
+	now := time.Now()
+	for _, interval := range intervals {
+		candlesticks, err := ex.Candlesticks(ctx, symbol, interval)
+		if err != nil {
+			return domain.SymbolSnapshot{}, errors.Wrapf(err, "load %s candlesticks", interval)
+		}
+		for i := len(candlesticks) - 1; i >= 0; i-- {
+			if candlesticks[i].CloseTime.Before(now) {
+				snapshot.Candlesticks = append(snapshot.Candlesticks, candlesticks[i])
+				break
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+func (s *Exchanges) Candlesticks(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) ([]dto.Candlestick, error) {
+	ex, has := s.exchanges[exchange]
+	if !has {
+		return nil, unknownExchange(exchange)
+	}
+	return ex.Candlesticks(ctx, symbol, interval)
+}
+
+func (s *Exchanges) CandlesticksBefore(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, end time.Time,
+) ([]dto.Candlestick, error) {
+	ex, has := s.exchanges[exchange]
+	if !has {
+		return nil, unknownExchange(exchange)
+	}
+	return ex.CandlesticksBefore(ctx, symbol, interval, end)
+}
+
+// unknownExchange is permanent error, so retries of callers stop at once.
+func unknownExchange(exchange string) error {
+	return backoff.Permanent(errors.Wrap(ErrUnknownExchange, exchange))
+}
+
+// NormalizeSymbol converts exchange symbol to common format: "btc-usdt", "BTC_USDT", "BTC/USDT" -> "BTCUSDT".
+func NormalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", "_", "", "/", "", " ", "").Replace(symbol))
+}
@@ -0,0 +1,45 @@
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+)
+
+func TestExchangesUnknownExchange(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		exchanges = NewExchanges()
+	)
+	for name, call := range map[string]func() error{
+		"ExchangePrices": func() error {
+			_, err := exchanges.ExchangePrices(ctx, "unknown")
+			return err
+		},
+		"SymbolSnapshot": func() error {
+			_, err := exchanges.SymbolSnapshot(ctx, "unknown", "BTCUSDT")
+			return err
+		},
+		"Candlesticks": func() error {
+			_, err := exchanges.Candlesticks(ctx, "unknown", "BTCUSDT", domain.OneHourInterval)
+			return err
+		},
+		"CandlesticksBefore": func() error {
+			_, err := exchanges.CandlesticksBefore(ctx, "unknown", "BTCUSDT", domain.OneHourInterval, time.Now())
+			return err
+		},
+	} {
+		err := call()
+		if !errors.Is(err, ErrUnknownExchange) {
+			t.Errorf("%s: got error %v, want ErrUnknownExchange", name, err)
+		}
+		var permanent *backoff.PermanentError
+		if !errors.As(err, &permanent) {
+			t.Errorf("%s: error of unknown exchange must not be retried", name)
+		}
+	}
+}
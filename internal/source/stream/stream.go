@@ -0,0 +1,287 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/metric"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultHeartbeat = 20 * time.Second
+	// DefaultRefresh is a period of checking subscription changes.
+	DefaultRefresh = time.Minute
+
+	writeTimeout = 5 * time.Second
+	// stableSession is a duration of session after which reconnect backoff is reset.
+	stableSession = time.Minute
+	// maxGap is allowed distance between close time of previous candlestick and open time of the next one.
+	maxGap = time.Second
+)
+
+type EventType int
+
+const (
+	PriceEvent EventType = iota
+	CandlestickEvent
+	GapEvent
+)
+
+type Event struct {
+	Type        EventType
+	Price       domain.SymbolPrice
+	Candlestick dto.Candlestick
+	// Closed is true when candlestick is final and will not be changed.
+	Closed bool
+	Gap    Gap
+}
+
+// Gap is a range of candlesticks missed by stream, e.g. during reconnect.
+type Gap struct {
+	Exchange string
+	Symbol   string
+	Interval domain.Interval
+	From     time.Time
+	To       time.Time
+}
+
+var errSubscriptionChanged = errors.New("subscription changed")
+
+// Subscription maps symbol to intervals of its klines, prices are subscribed for all symbols.
+type Subscription map[string][]domain.Interval
+
+// Symbols returns sorted symbols of subscription.
+func (s Subscription) Symbols() []string {
+	symbols := make([]string, 0, len(s))
+	for symbol := range s {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+func (s Subscription) Equal(other Subscription) bool {
+	return maps.EqualFunc(s, other, slices.Equal[[]domain.Interval])
+}
+
+// SubscriptionLoader returns actual subscription, it is called before connect and periodically during session.
+type SubscriptionLoader func(ctx context.Context) (Subscription, error)
+
+// Feed describes websocket protocol of exchange.
+type Feed interface {
+	Name() string
+	URL() string
+	SubscribeMessages(subscription Subscription) []any
+	// PingMessage is application level heartbeat, nil when exchange uses websocket ping frames only.
+	PingMessage() any
+	Parse(msg []byte) ([]Event, error)
+}
+
+// Client keeps websocket connection to exchange: reconnects, resubscribes, sends heartbeat and detects gaps in klines.
+// Connection is reopened when subscription changes.
+type Client struct {
+	feed             Feed
+	loadSubscription SubscriptionLoader
+	heartbeat        time.Duration
+	refresh          time.Duration
+	dialer           *websocket.Dialer
+
+	lastCandlesticks map[string]dto.Candlestick
+}
+
+func NewClient(feed Feed, loadSubscription SubscriptionLoader, heartbeat time.Duration) *Client {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	return &Client{
+		feed:             feed,
+		loadSubscription: loadSubscription,
+		heartbeat:        heartbeat,
+		refresh:          DefaultRefresh,
+		dialer:           websocket.DefaultDialer,
+		lastCandlesticks: make(map[string]dto.Candlestick),
+	}
+}
+
+// Run reads events into the channel until context is canceled, connection errors lead to reconnect.
+func (c *Client) Run(ctx context.Context, events chan<- []Event) error {
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 0
+	for {
+		start := time.Now()
+		err := c.session(ctx, events)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, errSubscriptionChanged) {
+			zap.L().Info("stream subscription changed", zap.String("exchange", c.feed.Name()))
+			continue
+		}
+		if time.Since(start) > stableSession {
+			b.Reset()
+		}
+		wait := b.NextBackOff()
+		metric.StreamReconnects.WithLabelValues(c.feed.Name()).Inc()
+		zap.L().Warn(
+			"stream disconnected",
+			zap.String("exchange", c.feed.Name()),
+			zap.Error(err),
+			zap.Duration("reconnect_after", wait),
+		)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) session(ctx context.Context, events chan<- []Event) error {
+	subscription, err := c.loadSubscription(ctx)
+	if err != nil {
+		return errors.Wrap(err, "load subscription")
+	}
+	conn, _, err := c.dialer.DialContext(ctx, c.feed.URL(), nil)
+	if err != nil {
+		return errors.Wrap(err, "dial")
+	}
+	sessionCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var muWrite sync.Mutex
+	writeJSON := func(msg any) error {
+		muWrite.Lock()
+		defer muWrite.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return conn.WriteJSON(msg)
+	}
+	go func() {
+		<-sessionCtx.Done()
+		_ = conn.Close()
+	}()
+
+	extendDeadline := func() { _ = conn.SetReadDeadline(time.Now().Add(2 * c.heartbeat)) }
+	extendDeadline()
+	conn.SetPongHandler(func(string) error {
+		extendDeadline()
+		return nil
+	})
+	for _, msg := range c.feed.SubscribeMessages(subscription) {
+		if err := writeJSON(msg); err != nil {
+			return errors.Wrap(err, "subscribe")
+		}
+	}
+	go c.sendHeartbeat(sessionCtx, conn, &muWrite, writeJSON)
+	go c.watchSubscription(sessionCtx, cancel, subscription)
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if cause := context.Cause(sessionCtx); errors.Is(cause, errSubscriptionChanged) {
+				return cause
+			}
+			return errors.Wrap(err, "read message")
+		}
+		extendDeadline()
+		metric.StreamMessages.WithLabelValues(c.feed.Name()).Inc()
+		parsed, err := c.feed.Parse(msg)
+		if err != nil {
+			zap.L().Error("error parse stream message", zap.String("exchange", c.feed.Name()), zap.Error(err))
+			continue
+		}
+		parsed = c.detectGaps(parsed)
+		if len(parsed) == 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case events <- parsed:
+		}
+	}
+}
+
+func (c *Client) sendHeartbeat(ctx context.Context, conn *websocket.Conn, muWrite *sync.Mutex, writeJSON func(any) error) {
+	ticker := time.NewTicker(c.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			muWrite.Lock()
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+			muWrite.Unlock()
+			if err == nil && c.feed.PingMessage() != nil {
+				err = writeJSON(c.feed.PingMessage())
+			}
+			if err != nil {
+				zap.L().Warn("error send heartbeat", zap.String("exchange", c.feed.Name()), zap.Error(err))
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// watchSubscription cancels session when subscription is changed.
+func (c *Client) watchSubscription(ctx context.Context, cancel context.CancelCauseFunc, current Subscription) {
+	ticker := time.NewTicker(c.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			subscription, err := c.loadSubscription(ctx)
+			if err != nil {
+				zap.L().Warn("error load subscription", zap.String("exchange", c.feed.Name()), zap.Error(err))
+				continue
+			}
+			if !subscription.Equal(current) {
+				cancel(errSubscriptionChanged)
+				return
+			}
+		}
+	}
+}
+
+// detectGaps adds GapEvent when closed candlestick does not follow the previous closed one.
+func (c *Client) detectGaps(events []Event) []Event {
+	result := events
+	for _, event := range events {
+		if event.Type != CandlestickEvent || !event.Closed {
+			continue
+		}
+		item := event.Candlestick
+		key := fmt.Sprintf("%s-%s-%s", item.Exchange, item.Symbol, item.Interval)
+		prev, has := c.lastCandlesticks[key]
+		if has && !item.OpenTime.After(prev.OpenTime) {
+			continue
+		}
+		c.lastCandlesticks[key] = item
+		if has && item.OpenTime.Sub(prev.CloseTime) > maxGap {
+			metric.StreamGaps.WithLabelValues(c.feed.Name()).Inc()
+			result = append(result, Event{Type: GapEvent, Gap: Gap{
+				Exchange: item.Exchange,
+				Symbol:   item.Symbol,
+				Interval: domain.Interval(item.Interval),
+				From:     prev.CloseTime,
+				To:       item.OpenTime,
+			}})
+		}
+	}
+	return result
+}
@@ -0,0 +1,309 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+	"github.com/AlekseyPorandaykin/crypto_analyst/internal/testutil"
+	"github.com/gorilla/websocket"
+)
+
+// testFeed is protocol of stand-in server: subscribe message lists symbols, messages are klines.
+type testFeed struct {
+	url string
+}
+
+type testSubscribe struct {
+	Symbols []string `json:"symbols"`
+}
+
+type testKline struct {
+	Symbol    string    `json:"symbol"`
+	Interval  string    `json:"interval"`
+	OpenTime  time.Time `json:"open_time"`
+	CloseTime time.Time `json:"close_time"`
+	Closed    bool      `json:"closed"`
+}
+
+func (f testFeed) Name() string {
+	return "test"
+}
+
+func (f testFeed) URL() string {
+	return f.url
+}
+
+func (f testFeed) SubscribeMessages(subscription Subscription) []any {
+	return []any{testSubscribe{Symbols: subscription.Symbols()}}
+}
+
+func (f testFeed) PingMessage() any {
+	return nil
+}
+
+func (f testFeed) Parse(msg []byte) ([]Event, error) {
+	var kline testKline
+	if err := json.Unmarshal(msg, &kline); err != nil {
+		return nil, err
+	}
+	return []Event{{
+		Type: CandlestickEvent,
+		Candlestick: dto.Candlestick{
+			Symbol:    kline.Symbol,
+			Exchange:  "test",
+			Interval:  kline.Interval,
+			OpenTime:  kline.OpenTime,
+			CloseTime: kline.CloseTime,
+		},
+		Closed: kline.Closed,
+	}}, nil
+}
+
+// standIn is local websocket server, serve handles connection with its number starting from 1.
+type standIn struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	connections   int
+	subscriptions [][]string
+	connected     []time.Time
+}
+
+func newStandIn(t *testing.T, serve func(n int, conn *websocket.Conn)) *standIn {
+	t.Helper()
+	s := &standIn{}
+	upgrader := websocket.Upgrader{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		var msg testSubscribe
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		n := s.connections
+		s.subscriptions = append(s.subscriptions, msg.Symbols)
+		s.connected = append(s.connected, time.Now())
+		s.mu.Unlock()
+		serve(n, conn)
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *standIn) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *standIn) state() (int, [][]string, []time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, append([][]string(nil), s.subscriptions...), append([]time.Time(nil), s.connected...)
+}
+
+// readUntilClosed reads messages, so control frames are handled, until connection is closed.
+func readUntilClosed(conn *websocket.Conn) {
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func staticSubscription(subscription Subscription) SubscriptionLoader {
+	return func(context.Context) (Subscription, error) {
+		return subscription, nil
+	}
+}
+
+func runClient(t *testing.T, client *Client) chan []Event {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan []Event, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = client.Run(ctx, events)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return events
+}
+
+func TestClientReconnectsWithBackoff(t *testing.T) {
+	server := newStandIn(t, func(n int, conn *websocket.Conn) {
+		if n == 1 {
+			// The first session is dropped by exchange.
+			return
+		}
+		readUntilClosed(conn)
+	})
+	client := NewClient(testFeed{url: server.url()}, staticSubscription(Subscription{
+		"BTCUSDT": {domain.OneMinuteInterval},
+	}), time.Minute)
+	runClient(t, client)
+
+	testutil.WaitFor(t, 5*time.Second, func() bool {
+		connections, _, _ := server.state()
+		return connections >= 2
+	})
+	_, subscriptions, connected := server.state()
+	for i, symbols := range subscriptions[:2] {
+		if len(symbols) != 1 || symbols[0] != "BTCUSDT" {
+			t.Errorf("session %d subscribed to %v", i+1, symbols)
+		}
+	}
+	// Initial interval of backoff is 500ms with randomization factor 0.5.
+	if wait := connected[1].Sub(connected[0]); wait < 200*time.Millisecond {
+		t.Errorf("reconnected after %s, want backoff", wait)
+	}
+}
+
+func TestClientResubscribesOnSubscriptionChange(t *testing.T) {
+	server := newStandIn(t, func(n int, conn *websocket.Conn) {
+		readUntilClosed(conn)
+	})
+	var (
+		mu           sync.Mutex
+		subscription = Subscription{"BTCUSDT": {domain.OneMinuteInterval}}
+	)
+	client := NewClient(testFeed{url: server.url()}, func(context.Context) (Subscription, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return subscription, nil
+	}, time.Minute)
+	client.refresh = 20 * time.Millisecond
+	runClient(t, client)
+
+	testutil.WaitFor(t, 5*time.Second, func() bool {
+		connections, _, _ := server.state()
+		return connections == 1
+	})
+	mu.Lock()
+	subscription = Subscription{"BTCUSDT": {domain.OneMinuteInterval}, "ETHUSDT": {domain.OneHourInterval}}
+	mu.Unlock()
+
+	testutil.WaitFor(t, 5*time.Second, func() bool {
+		connections, _, _ := server.state()
+		return connections >= 2
+	})
+	_, subscriptions, connected := server.state()
+	if got := subscriptions[1]; len(got) != 2 || got[0] != "BTCUSDT" || got[1] != "ETHUSDT" {
+		t.Errorf("resubscribed to %v, want [BTCUSDT ETHUSDT]", got)
+	}
+	// Changed subscription reconnects without backoff.
+	if wait := connected[1].Sub(connected[0]); wait > 2*time.Second {
+		t.Errorf("resubscribed after %s", wait)
+	}
+}
+
+func TestClientReconnectsOnHeartbeatTimeout(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		pings = make(map[int]int)
+	)
+	server := newStandIn(t, func(n int, conn *websocket.Conn) {
+		// Server neither answers pings nor sends messages.
+		conn.SetPingHandler(func(string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			pings[n]++
+			return nil
+		})
+		readUntilClosed(conn)
+	})
+	client := NewClient(testFeed{url: server.url()}, staticSubscription(Subscription{
+		"BTCUSDT": {domain.OneMinuteInterval},
+	}), 50*time.Millisecond)
+	runClient(t, client)
+
+	testutil.WaitFor(t, 5*time.Second, func() bool {
+		connections, _, _ := server.state()
+		return connections >= 2
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if pings[1] == 0 {
+		t.Error("client did not send heartbeat pings")
+	}
+}
+
+func TestClientEmitsGapEvent(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	kline := func(minute int, closed bool) testKline {
+		openTime := start.Add(time.Duration(minute) * time.Minute)
+		return testKline{
+			Symbol:    "BTCUSDT",
+			Interval:  domain.OneMinuteInterval.String(),
+			OpenTime:  openTime,
+			CloseTime: domain.OneMinuteInterval.CloseTime(openTime),
+			Closed:    closed,
+		}
+	}
+	server := newStandIn(t, func(n int, conn *websocket.Conn) {
+		for _, item := range []testKline{
+			kline(0, true),
+			kline(1, false),
+			kline(1, true),
+			// Duplicate of closed candlestick is not a gap.
+			kline(1, true),
+			// Candlesticks of minutes 2 and 3 are missed.
+			kline(4, true),
+		} {
+			if err := conn.WriteJSON(item); err != nil {
+				return
+			}
+		}
+		readUntilClosed(conn)
+	})
+	client := NewClient(testFeed{url: server.url()}, staticSubscription(Subscription{
+		"BTCUSDT": {domain.OneMinuteInterval},
+	}), time.Minute)
+	events := runClient(t, client)
+
+	var gaps []Gap
+	timeout := time.After(5 * time.Second)
+	for candlesticks := 0; candlesticks < 5; {
+		select {
+		case <-timeout:
+			t.Fatalf("received %d of 5 candlesticks", candlesticks)
+		case batch := <-events:
+			for _, event := range batch {
+				switch event.Type {
+				case CandlestickEvent:
+					candlesticks++
+				case GapEvent:
+					gaps = append(gaps, event.Gap)
+				}
+			}
+		}
+	}
+	if len(gaps) != 1 {
+		t.Fatalf("got gaps %+v, want one gap", gaps)
+	}
+	expected := Gap{
+		Exchange: "test",
+		Symbol:   "BTCUSDT",
+		Interval: domain.OneMinuteInterval,
+		From:     domain.OneMinuteInterval.CloseTime(start.Add(time.Minute)),
+		To:       start.Add(4 * time.Minute),
+	}
+	if gaps[0] != expected {
+		t.Errorf("got gap %+v, want %+v", gaps[0], expected)
+	}
+}
@@ -1,24 +1,80 @@
 package cache
 
 import (
-	lru "github.com/hashicorp/golang-lru/v2"
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+const (
+	// LengthIndicatorData is number of series whose the last indicators are kept.
+	LengthIndicatorData = 2 << 10
+	// IndicatorTTL limits staleness of snapshot when indicators are calculated by another process.
+	IndicatorTTL = time.Minute
+)
+
+var (
+	_ domain.IndicatorStorage      = (*Indicator)(nil)
+	_ domain.IndicatorValueStorage = (*Indicator)(nil)
 )
 
-// TODO каждый индикатор храниться в соем кэше, у каждого свой ключ
+type indicatorKey struct {
+	exchange string
+	symbol   string
+	interval domain.Interval
+}
+
+// Indicator keeps the last indicator snapshot of recently calculated or requested series
+// and the last value of indicator instances by instance id.
 type Indicator struct {
-	ma8 *lru.Cache[string, float64]
+	last   *expirable.LRU[indicatorKey, domain.IndicatorSnapshot]
+	values *expirable.LRU[string, domain.IndicatorValue]
 }
 
-func NewIndicator() (*Indicator, error) {
-	l, err := lru.New[string, float64](2 << 10)
-	if err != nil {
-		return nil, err
-	}
+func NewIndicator() *Indicator {
 	return &Indicator{
-		ma8: l,
-	}, nil
+		last:   expirable.NewLRU[indicatorKey, domain.IndicatorSnapshot](LengthIndicatorData, nil, IndicatorTTL),
+		values: expirable.NewLRU[string, domain.IndicatorValue](LengthIndicatorData, nil, IndicatorTTL),
+	}
 }
 
-func (i *Indicator) Save() {
-	i.ma8.Add("S", 12)
+func (i *Indicator) SaveIndicators(ctx context.Context, snapshots []domain.IndicatorSnapshot) error {
+	for _, item := range snapshots {
+		key := indicatorKey{exchange: item.Exchange, symbol: item.Symbol, interval: item.Interval}
+		if prev, has := i.last.Peek(key); has && prev.OpenTime.After(item.OpenTime) {
+			continue
+		}
+		i.last.Add(key, item)
+	}
+	return nil
+}
+
+func (i *Indicator) LastIndicator(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) (domain.IndicatorSnapshot, error) {
+	snapshot, has := i.last.Get(indicatorKey{exchange: exchange, symbol: symbol, interval: interval})
+	if !has {
+		return domain.IndicatorSnapshot{}, domain.ErrIndicatorNotFound
+	}
+	return snapshot, nil
+}
+
+func (i *Indicator) SaveIndicatorValues(ctx context.Context, values []domain.IndicatorValue) error {
+	for _, item := range values {
+		if prev, has := i.values.Peek(item.InstanceID); has && prev.OpenTime.After(item.OpenTime) {
+			continue
+		}
+		i.values.Add(item.InstanceID, item)
+	}
+	return nil
+}
+
+func (i *Indicator) LastIndicatorValue(ctx context.Context, instanceID string) (domain.IndicatorValue, error) {
+	value, has := i.values.Get(instanceID)
+	if !has {
+		return domain.IndicatorValue{}, domain.ErrIndicatorNotFound
+	}
+	return value, nil
 }
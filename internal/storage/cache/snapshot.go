@@ -2,22 +2,46 @@ package cache
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
 	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
 )
 
+// LengthCandlestickData is number of the last candlesticks kept for every exchange, symbol and interval,
+// ranges of more candlesticks are not covered by cache and are loaded from database.
 const LengthCandlestickData = 100
 
+// CandlestickTTL is how long series is considered up to date after the last save, candlesticks can be saved
+// to database by another process, so range which ends after the last cached candlestick is loaded from database.
+const CandlestickTTL = time.Minute
+
+var _ domain.CandlestickStorage = (*Candlestick)(nil)
+
+type candlestickKey struct {
+	exchange string
+	symbol   string
+	interval domain.Interval
+}
+
+// candlestickSeries keeps the last candlesticks of series ordered by open time.
+type candlestickSeries struct {
+	items     []dto.Candlestick
+	updatedAt time.Time
+}
+
 type Candlestick struct {
-	lastData map[string]map[string]map[string]dto.Candlestick
-	mu       sync.Mutex
+	series map[candlestickKey]*candlestickSeries
+	length int
+	mu     sync.RWMutex
 }
 
 func NewCandlestick() *Candlestick {
 	return &Candlestick{
-		lastData: make(map[string]map[string]map[string]dto.Candlestick),
+		series: make(map[candlestickKey]*candlestickSeries),
+		length: LengthCandlestickData,
 	}
 }
 
@@ -25,39 +49,130 @@ func (s *Candlestick) Save(ctx context.Context, data []dto.Candlestick) error {
 	if len(data) == 0 {
 		return nil
 	}
+	now := time.Now()
 	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, item := range data {
-		if s.lastData[item.Exchange] == nil {
-			s.lastData[item.Exchange] = make(map[string]map[string]dto.Candlestick)
+		key := candlestickKey{exchange: item.Exchange, symbol: item.Symbol, interval: domain.Interval(item.Interval)}
+		series := s.series[key]
+		if series == nil {
+			series = &candlestickSeries{items: make([]dto.Candlestick, 0, s.length)}
+			s.series[key] = series
+		}
+		series.add(item, s.length)
+		series.updatedAt = now
+	}
+	return nil
+}
+
+// add inserts candlestick by open time, candlestick of exchange is not replaced by derived one.
+func (series *candlestickSeries) add(item dto.Candlestick, length int) {
+	i := sort.Search(len(series.items), func(i int) bool {
+		return !series.items[i].OpenTime.Before(item.OpenTime)
+	})
+	if i < len(series.items) && series.items[i].OpenTime.Equal(item.OpenTime) {
+		if item.Derived && !series.items[i].Derived {
+			return
 		}
-		if s.lastData[item.Exchange][item.Symbol] == nil {
-			s.lastData[item.Exchange][item.Symbol] = make(map[string]dto.Candlestick)
+		series.items[i] = item
+		return
+	}
+	if len(series.items) >= length && i == 0 {
+		return
+	}
+	series.items = append(series.items, dto.Candlestick{})
+	copy(series.items[i+1:], series.items[i:])
+	series.items[i] = item
+	if len(series.items) > length {
+		series.items = series.items[len(series.items)-length:]
+	}
+}
+
+// between returns candlesticks opened in [from, to), ok is false when cached series does not cover the range.
+// Candlesticks of range are expected on grid of series, every one opens a duration of interval after the previous one,
+// so a hole at the start or inside the range is a miss, and series of exchange sessions shifted from UTC midnight
+// are covered as well as aligned ones.
+func (series *candlestickSeries) between(interval domain.Interval, from, to time.Time) ([]dto.Candlestick, bool) {
+	// The range starts before cached series when candlestick preceding the first cached one is opened in the range.
+	if len(series.items) == 0 || !series.items[0].OpenTime.Add(-interval.Duration()).Before(from) {
+		return nil, false
+	}
+	last := series.items[len(series.items)-1]
+	if to.After(last.CloseTime) && time.Since(series.updatedAt) > CandlestickTTL {
+		return nil, false
+	}
+	start := sort.Search(len(series.items), func(i int) bool {
+		return !series.items[i].OpenTime.Before(from)
+	})
+	expected := series.items[0].OpenTime
+	if start > 0 {
+		prev := series.items[start-1].OpenTime
+		steps := (from.Sub(prev) + interval.Duration() - 1) / interval.Duration()
+		expected = prev.Add(steps * interval.Duration())
+	}
+	result := make([]dto.Candlestick, 0, len(series.items)-start)
+	for _, item := range series.items[start:] {
+		if !item.OpenTime.Before(to) {
+			break
 		}
-		prevVal := s.lastData[item.Exchange][item.Symbol][item.Interval]
-		if prevVal.CloseTime.Before(item.CloseTime) {
-			s.lastData[item.Exchange][item.Symbol][item.Interval] = item
+		if !item.OpenTime.Equal(expected) {
+			return nil, false
 		}
+		result = append(result, item)
+		expected = expected.Add(interval.Duration())
 	}
-	s.mu.Unlock()
-	return nil
+	return result, true
 }
 
+// Candlesticks returns candlesticks of all intervals opened in [from, to) ordered by close time.
+// Cache does not know which intervals of symbol are stored in database, so nil is returned unless series of every
+// supported interval is cached and covers the range.
 func (s *Candlestick) Candlesticks(ctx context.Context, exchange, symbol string, from, to time.Time) ([]dto.Candlestick, error) {
-	return nil, nil
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []dto.Candlestick
+	for _, interval := range domain.ListIntervals {
+		series := s.series[candlestickKey{exchange: exchange, symbol: symbol, interval: interval}]
+		if series == nil {
+			return nil, nil
+		}
+		items, ok := series.between(interval, from, to)
+		if !ok {
+			return nil, nil
+		}
+		result = append(result, items...)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].CloseTime.Before(result[j].CloseTime) })
+	return result, nil
 }
 
-func (s *Candlestick) LastCandlestick(ctx context.Context, exchange, symbol, interval string) (*dto.Candlestick, error) {
-	s.mu.Lock()
-	lastData := s.lastData[exchange]
-	defer s.mu.Unlock()
-	symbolData := lastData[symbol]
-	if symbolData == nil {
+// IntervalCandlesticks returns candlesticks of interval opened in [from, to) ordered by open time,
+// nil is returned when cached series does not cover the range, e.g. range is longer than LengthCandlestickData.
+func (s *Candlestick) IntervalCandlesticks(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, from, to time.Time,
+) ([]dto.Candlestick, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	series := s.series[candlestickKey{exchange: exchange, symbol: symbol, interval: interval}]
+	if series == nil {
 		return nil, nil
 	}
-	for key, val := range symbolData {
-		if key == interval {
-			return &val, nil
-		}
+	items, ok := series.between(interval, from, to)
+	if !ok {
+		return nil, nil
+	}
+	return items, nil
+}
+
+func (s *Candlestick) LastCandlestick(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) (*dto.Candlestick, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	series := s.series[candlestickKey{exchange: exchange, symbol: symbol, interval: interval}]
+	if series == nil || len(series.items) == 0 {
+		return nil, nil
 	}
-	return nil, nil
+	last := series.items[len(series.items)-1]
+	return &last, nil
 }
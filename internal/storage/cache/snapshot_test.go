@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
+)
+
+func candlesticks(interval domain.Interval, first time.Time, count int) []dto.Candlestick {
+	result := make([]dto.Candlestick, 0, count)
+	for i := 0; i < count; i++ {
+		openTime := first.Add(time.Duration(i) * interval.Duration())
+		result = append(result, dto.Candlestick{
+			Exchange:  domain.BinanceExchange,
+			Symbol:    domain.BTCUSDT,
+			Interval:  interval.String(),
+			OpenTime:  openTime,
+			CloseTime: interval.CloseTime(openTime),
+		})
+	}
+	return result
+}
+
+func TestIntervalCandlesticksSessionOffset(t *testing.T) {
+	// Daily candlesticks of session which starts at 08:00 UTC.
+	first := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	cache := NewCandlestick()
+	if err := cache.Save(context.Background(), candlesticks(domain.OneDayInterval, first, 10)); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	items, err := cache.IntervalCandlesticks(
+		context.Background(), domain.BinanceExchange, domain.BTCUSDT, domain.OneDayInterval, from, from.AddDate(0, 0, 5),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 5 || !items[0].OpenTime.Equal(first.AddDate(0, 0, 2)) {
+		t.Fatalf("got %d candlesticks from %v, want 5 from 2024-01-03 08:00", len(items), items)
+	}
+}
+
+func TestIntervalCandlesticksNotCovered(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := candlesticks(domain.OneHourInterval, first, 10)
+	// Candlestick of 05:00 is missed.
+	data = append(data[:5], data[6:]...)
+	cache := NewCandlestick()
+	if err := cache.Save(context.Background(), data); err != nil {
+		t.Fatal(err)
+	}
+	for name, item := range map[string]struct{ from, to time.Time }{
+		"gap":            {first, first.Add(9 * time.Hour)},
+		"before series":  {first.Add(-time.Hour), first.Add(3 * time.Hour)},
+		"starts in gap":  {first.Add(5 * time.Hour), first.Add(9 * time.Hour)},
+		"contiguous end": {first.Add(6 * time.Hour), first.Add(9 * time.Hour)},
+	} {
+		items, err := cache.IntervalCandlesticks(
+			context.Background(), domain.BinanceExchange, domain.BTCUSDT, domain.OneHourInterval, item.from, item.to,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "contiguous end" {
+			if len(items) != 3 {
+				t.Errorf("%s: got %d candlesticks, want 3", name, len(items))
+			}
+			continue
+		}
+		if items != nil {
+			t.Errorf("%s: got %d candlesticks, want miss", name, len(items))
+		}
+	}
+}
+
+func TestCandlesticksAllIntervals(t *testing.T) {
+	from := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	cache := NewCandlestick()
+	expected := 0
+	for _, interval := range domain.ListIntervals {
+		count := int((time.Hour + interval.Duration() - 1) / interval.Duration())
+		expected += count
+		if interval == domain.OneWeekInterval {
+			continue
+		}
+		data := candlesticks(interval, from.Add(-2*interval.Duration()), count+2)
+		if err := cache.Save(context.Background(), data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Weekly candlesticks are not cached yet, they may be stored in database.
+	items, err := cache.Candlesticks(context.Background(), domain.BinanceExchange, domain.BTCUSDT, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items != nil {
+		t.Fatalf("got %d candlesticks, want miss without weekly series", len(items))
+	}
+
+	data := candlesticks(domain.OneWeekInterval, from.Add(-2*domain.OneWeekInterval.Duration()), 3)
+	if err := cache.Save(context.Background(), data); err != nil {
+		t.Fatal(err)
+	}
+	items, err = cache.Candlesticks(context.Background(), domain.BinanceExchange, domain.BTCUSDT, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != expected {
+		t.Errorf("got %d candlesticks, want %d", len(items), expected)
+	}
+}
+
+func TestIntervalCandlesticksBound(t *testing.T) {
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewCandlestick()
+	if err := cache.Save(context.Background(), candlesticks(domain.OneMinuteInterval, first, LengthCandlestickData+20)); err != nil {
+		t.Fatal(err)
+	}
+	to := first.Add(time.Duration(LengthCandlestickData+20) * time.Minute)
+	for count, covered := range map[int]bool{LengthCandlestickData: true, LengthCandlestickData + 1: false} {
+		items, err := cache.IntervalCandlesticks(
+			context.Background(), domain.BinanceExchange, domain.BTCUSDT, domain.OneMinuteInterval,
+			to.Add(-time.Duration(count)*time.Minute), to,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if covered != (len(items) == count) {
+			t.Errorf("range of %d candlesticks: got %d, covered %t", count, len(items), covered)
+		}
+	}
+}
@@ -47,10 +47,35 @@ func (c *CandlestickComposite) Candlesticks(ctx context.Context, exchange, symbo
 	if err != nil {
 		return nil, errors.Wrap(err, "error get candlesticks from longTermStorage")
 	}
+	if err := c.fastStorage.Save(ctx, candlesticks); err != nil {
+		return nil, errors.Wrap(err, "error fill fastStorage")
+	}
+	return candlesticks, nil
+}
+
+func (c *CandlestickComposite) IntervalCandlesticks(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, from, to time.Time,
+) ([]dto.Candlestick, error) {
+	candlesticks, err := c.fastStorage.IntervalCandlesticks(ctx, exchange, symbol, interval, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "error get candlesticks from fastStorage")
+	}
+	if len(candlesticks) > 0 {
+		return candlesticks, nil
+	}
+	candlesticks, err = c.longTermStorage.IntervalCandlesticks(ctx, exchange, symbol, interval, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "error get candlesticks from longTermStorage")
+	}
+	if err := c.fastStorage.Save(ctx, candlesticks); err != nil {
+		return nil, errors.Wrap(err, "error fill fastStorage")
+	}
 	return candlesticks, nil
 }
 
-func (c *CandlestickComposite) LastCandlestick(ctx context.Context, exchange, symbol, interval string) (*dto.Candlestick, error) {
+func (c *CandlestickComposite) LastCandlestick(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) (*dto.Candlestick, error) {
 	var (
 		candlestick *dto.Candlestick
 		err         error
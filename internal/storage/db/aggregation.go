@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
@@ -41,36 +40,63 @@ LIMIT 1;
 }
 
 func (repo *Aggregation) Save(ctx context.Context, data ...domain.PriceAggregation) error {
-	var (
-		query = `
-INSERT INTO crypto_analyst.price_aggregation(symbol, exchange, metric, key, value, updated_at) 
-VALUES %s ON CONFLICT (symbol, exchange, metric, key) 
-DO  UPDATE SET value = EXCLUDED.value, updated_at=EXCLUDED.updated_at ;
-`
-		params []string
-	)
+	rows := make([][]any, 0, len(data))
 	for _, item := range data {
-		params = append(params, fmt.Sprintf(
-			"('%s', '%s', '%s', '%s', '%s', '%s')",
+		rows = append(rows, []any{
 			item.Symbol,
 			item.Exchange,
-			item.Metric,
+			string(item.Metric),
 			item.Key,
 			item.Value,
-			item.UpdatedAt.Format(DatetimeFormat),
-		))
+			item.UpdatedAt.Truncate(time.Second),
+		})
 	}
-	if _, err := repo.db.ExecContext(ctx, fmt.Sprintf(query, strings.Join(params, ","))); err != nil {
-		return err
-	}
-	return nil
+	return batchInsert(
+		ctx,
+		repo.db,
+		`INSERT INTO crypto_analyst.price_aggregation(symbol, exchange, metric, key, value, updated_at)`,
+		`ON CONFLICT (symbol, exchange, metric, key) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at`,
+		rows,
+	)
 }
 
-func (repo *Aggregation) DeleteOldRows(ctx context.Context, to time.Time) error {
-	query := `
-DELETE FROM crypto_analyst.price_aggregation WHERE  updated_at < $1
-`
-	_, err := repo.db.ExecContext(ctx, query, to)
-
-	return err
+// ContinuousAggregation returns metric of symbol since from calculated by TimescaleDB continuous aggregate,
+// rows are the same as ChangeCoefficient calculates. Zero from returns all rows.
+func (repo *Aggregation) ContinuousAggregation(
+	ctx context.Context, metric domain.MetricAggregationPrice, symbol string, from time.Time,
+) ([]domain.PriceAggregation, error) {
+	item, has := metricAggregates[metric]
+	if !has {
+		return nil, fmt.Errorf("metric %s has no continuous aggregate", metric)
+	}
+	var (
+		query = fmt.Sprintf(`
+SELECT symbol, exchange, bucket + INTERVAL '%[1]d days' AS key_time, %[2]s AS value
+FROM crypto_analyst.%[3]s
+WHERE symbol = $1
+  AND bucket + INTERVAL '%[1]d days' >= $2
+ORDER BY bucket ASC
+`, item.keyOffset, item.column, item.view)
+		rows []struct {
+			Symbol   string    `db:"symbol"`
+			Exchange string    `db:"exchange"`
+			KeyTime  time.Time `db:"key_time"`
+			Value    float64   `db:"value"`
+		}
+	)
+	if err := repo.db.SelectContext(ctx, &rows, query, symbol, from.In(time.UTC)); err != nil {
+		return nil, err
+	}
+	result := make([]domain.PriceAggregation, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, domain.PriceAggregation{
+			Symbol:    row.Symbol,
+			Exchange:  row.Exchange,
+			Metric:    metric,
+			Key:       row.KeyTime.In(time.UTC).Format(time.DateTime),
+			Value:     fmt.Sprintf("%.2f", row.Value),
+			UpdatedAt: time.Now().In(time.UTC),
+		})
+	}
+	return result, nil
 }
@@ -0,0 +1,227 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// DefaultAlertLimit is number of alerts returned when filter has no limit.
+const DefaultAlertLimit = 500
+
+var (
+	_ domain.AlertRuleStorage  = (*Alert)(nil)
+	_ domain.AlertStorage      = (*Alert)(nil)
+	_ domain.FiredAlertStorage = (*Alert)(nil)
+)
+
+type Alert struct {
+	db *sqlx.DB
+}
+
+func NewAlert(db *sqlx.DB) *Alert {
+	return &Alert{db: db}
+}
+
+const alertRuleColumns = `id, name, kind, exchange, symbol, operator, threshold, indicator_id, output, cooldown_sec,
+       hysteresis, enabled, active, last_triggered, last_value, created_at, updated_at`
+
+func (repo *Alert) AlertRules(ctx context.Context) ([]domain.AlertRule, error) {
+	var (
+		query = `
+SELECT ` + alertRuleColumns + `
+FROM crypto_analyst.alert_rules
+ORDER BY id
+`
+		result []domain.AlertRule
+	)
+	if err := repo.db.SelectContext(ctx, &result, query); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (repo *Alert) AlertRule(ctx context.Context, id int64) (domain.AlertRule, error) {
+	var (
+		query = `
+SELECT ` + alertRuleColumns + `
+FROM crypto_analyst.alert_rules
+WHERE id = $1
+`
+		result domain.AlertRule
+	)
+	if err := repo.db.GetContext(ctx, &result, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.AlertRule{}, domain.ErrAlertRuleNotFound
+		}
+		return domain.AlertRule{}, err
+	}
+	return result, nil
+}
+
+func (repo *Alert) SaveAlertRule(ctx context.Context, rule domain.AlertRule) (domain.AlertRule, error) {
+	var (
+		insertQuery = `
+INSERT INTO crypto_analyst.alert_rules(name, kind, exchange, symbol, operator, threshold, indicator_id, output,
+                                       cooldown_sec, hysteresis, enabled, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
+RETURNING id
+`
+		updateQuery = `
+UPDATE crypto_analyst.alert_rules
+SET name         = $2,
+    kind         = $3,
+    exchange     = $4,
+    symbol       = $5,
+    operator     = $6,
+    threshold    = $7,
+    indicator_id = $8,
+    output       = $9,
+    cooldown_sec = $10,
+    hysteresis   = $11,
+    enabled      = $12,
+    updated_at   = $13
+WHERE id = $1
+`
+	)
+	now := time.Now().In(time.UTC).Truncate(time.Second)
+	if rule.ID == 0 {
+		err := repo.db.GetContext(
+			ctx, &rule.ID, insertQuery, rule.Name, string(rule.Kind), rule.Exchange, rule.Symbol, string(rule.Operator),
+			rule.Threshold, rule.IndicatorID, rule.Output, rule.CooldownSec, rule.Hysteresis, rule.Enabled, now,
+		)
+		if err != nil {
+			return domain.AlertRule{}, err
+		}
+		return repo.AlertRule(ctx, rule.ID)
+	}
+	res, err := repo.db.ExecContext(
+		ctx, updateQuery, rule.ID, rule.Name, string(rule.Kind), rule.Exchange, rule.Symbol, string(rule.Operator),
+		rule.Threshold, rule.IndicatorID, rule.Output, rule.CooldownSec, rule.Hysteresis, rule.Enabled, now,
+	)
+	if err != nil {
+		return domain.AlertRule{}, err
+	}
+	if err := affectedOne(res, domain.ErrAlertRuleNotFound); err != nil {
+		return domain.AlertRule{}, err
+	}
+	return repo.AlertRule(ctx, rule.ID)
+}
+
+func (repo *Alert) DeleteAlertRule(ctx context.Context, id int64) error {
+	var query = `
+DELETE FROM crypto_analyst.alert_rules
+WHERE id = $1
+`
+	res, err := repo.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	return affectedOne(res, domain.ErrAlertRuleNotFound)
+}
+
+const (
+	updateAlertRuleState = `
+UPDATE crypto_analyst.alert_rules
+SET active         = $2,
+    last_triggered = $3,
+    last_value     = $4
+WHERE id = $1
+`
+	insertAlerts = `
+INSERT INTO crypto_analyst.alerts(rule_id, rule_name, kind, exchange, symbol, value, message, datetime, created_at)`
+)
+
+func (repo *Alert) SaveAlertRuleState(ctx context.Context, id int64, state domain.AlertRuleState) error {
+	_, err := repo.db.ExecContext(ctx, updateAlertRuleState, id, state.Active, utcTime(state.LastTriggered), state.LastValue)
+	return err
+}
+
+func (repo *Alert) SaveAlerts(ctx context.Context, alerts []domain.Alert) error {
+	return batchInsert(ctx, repo.db, insertAlerts, "", alertRows(alerts))
+}
+
+func (repo *Alert) SaveFiredAlerts(
+	ctx context.Context, alerts []domain.Alert, states map[int64]domain.AlertRuleState, notify bool,
+) error {
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := insertRows(ctx, tx, insertAlerts, "", alertRows(alerts)); err != nil {
+		return errors.Wrap(err, "insert alerts")
+	}
+	if notify {
+		rows, err := outboxRows(alerts)
+		if err != nil {
+			return err
+		}
+		if err := insertRows(ctx, tx, insertOutboxNotifications, "", rows); err != nil {
+			return errors.Wrap(err, "insert outbox notifications")
+		}
+	}
+	for id, state := range states {
+		_, err := tx.ExecContext(ctx, updateAlertRuleState, id, state.Active, utcTime(state.LastTriggered), state.LastValue)
+		if err != nil {
+			return errors.Wrapf(err, "save state of alert rule %d", id)
+		}
+	}
+	return tx.Commit()
+}
+
+func alertRows(alerts []domain.Alert) [][]any {
+	rows := make([][]any, 0, len(alerts))
+	for _, item := range alerts {
+		rows = append(rows, []any{
+			item.RuleID,
+			item.RuleName,
+			string(item.Kind),
+			item.Exchange,
+			item.Symbol,
+			item.Value,
+			item.Message,
+			item.Date.In(time.UTC).Truncate(time.Second),
+			item.CreatedAt.In(time.UTC).Truncate(time.Second),
+		})
+	}
+	return rows
+}
+
+func (repo *Alert) Alerts(ctx context.Context, filter domain.AlertFilter) ([]domain.Alert, error) {
+	var (
+		query = `
+SELECT id, rule_id, rule_name, kind, exchange, symbol, value, message, datetime, created_at
+FROM crypto_analyst.alerts
+WHERE datetime >= $1
+  AND ($2 = 0 OR rule_id = $2)
+ORDER BY datetime DESC, id DESC
+LIMIT $3
+`
+		result []domain.Alert
+	)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultAlertLimit
+	}
+	if err := repo.db.SelectContext(ctx, &result, query, filter.Since.In(time.UTC), filter.RuleID, limit); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// affectedOne returns notFound when statement changed no rows.
+func affectedOne(res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
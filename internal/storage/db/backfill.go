@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ domain.BackfillStorage = (*Backfill)(nil)
+
+type Backfill struct {
+	db *sqlx.DB
+}
+
+func NewBackfill(db *sqlx.DB) *Backfill {
+	return &Backfill{db: db}
+}
+
+func (repo *Backfill) CreateBackfillJob(ctx context.Context, gap domain.CandlestickGap) (domain.BackfillJob, error) {
+	var (
+		query = `
+WITH inserted AS (
+    INSERT INTO crypto_analyst.backfill_jobs(exchange, symbol, candle_interval, from_time, to_time, cursor_time)
+        VALUES ($1, $2, $3, $4, $5, $5)
+        ON CONFLICT (exchange, symbol, candle_interval, from_time, to_time) DO NOTHING
+        RETURNING *)
+SELECT *
+FROM inserted
+UNION ALL
+SELECT *
+FROM crypto_analyst.backfill_jobs
+WHERE exchange = $1
+  AND symbol = $2
+  AND candle_interval = $3
+  AND from_time = $4
+  AND to_time = $5
+`
+		job domain.BackfillJob
+	)
+	err := repo.db.GetContext(
+		ctx,
+		&job,
+		query,
+		gap.Exchange,
+		gap.Symbol,
+		gap.Interval.String(),
+		gap.From.In(time.UTC).Truncate(time.Second),
+		gap.To.In(time.UTC).Truncate(time.Second),
+	)
+	return job, err
+}
+
+func (repo *Backfill) PendingBackfillJobs(ctx context.Context) ([]domain.BackfillJob, error) {
+	var (
+		query = `
+SELECT *
+FROM crypto_analyst.backfill_jobs
+WHERE status = $1
+ORDER BY id
+`
+		jobs []domain.BackfillJob
+	)
+	if err := repo.db.SelectContext(ctx, &jobs, query, domain.BackfillStatusPending); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (repo *Backfill) UpdateBackfillJob(ctx context.Context, job domain.BackfillJob) error {
+	var query = `
+UPDATE crypto_analyst.backfill_jobs
+SET cursor_time = $2,
+    status      = $3,
+    loaded      = $4,
+    updated_at  = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+	_, err := repo.db.ExecContext(ctx, query, job.ID, job.Cursor.In(time.UTC).Truncate(time.Second), job.Status, job.Loaded)
+	return err
+}
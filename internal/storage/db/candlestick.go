@@ -2,12 +2,14 @@ package db
 
 import (
 	"context"
-	"fmt"
-	"strings"
+	"database/sql"
+	"slices"
 	"time"
 
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
 	"github.com/AlekseyPorandaykin/crypto_analyst/dto"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 )
 
 type Candlestick struct {
@@ -19,44 +21,52 @@ func NewCandlestick(db *sqlx.DB) *Candlestick {
 }
 
 func (repo *Candlestick) Save(ctx context.Context, data []dto.Candlestick) error {
-	var values []string
-
-	if len(data) == 0 {
-		return nil
-	}
+	rows := make([][]any, 0, len(data))
+	// Upsert fails when one statement affects the same row twice, the last candlestick of the key is saved.
+	keys := make(map[string]int, len(data))
 	for _, item := range data {
-		values = append(
-			values,
-			fmt.Sprintf(
-				"('%s','%s','%s','%s',%f,%f,%f,%f,%f, %d, '%s','%s')",
-				item.Symbol,
-				item.Exchange,
-				item.OpenTime.In(time.UTC).Format(DatetimeFormat),
-				item.CloseTime.In(time.UTC).Format(DatetimeFormat),
-				item.OpenPrice,
-				item.HighPrice,
-				item.LowPrice,
-				item.ClosePrice,
-				item.Volume,
-				item.NumberTrades,
-				item.Interval,
-				item.CreatedAt.In(time.UTC).Format(DatetimeFormat),
-			),
-		)
+		key := item.Symbol + item.Exchange + item.Interval + item.OpenTime.UTC().Format(time.RFC3339)
+		if i, has := keys[key]; has {
+			rows[i] = nil
+		}
+		keys[key] = len(rows)
+		rows = append(rows, []any{
+			item.Symbol,
+			item.Exchange,
+			item.OpenTime.In(time.UTC).Truncate(time.Second),
+			item.CloseTime.In(time.UTC).Truncate(time.Second),
+			item.OpenPrice,
+			item.HighPrice,
+			item.LowPrice,
+			item.ClosePrice,
+			item.Volume,
+			item.NumberTrades,
+			item.Interval,
+			item.CreatedAt.In(time.UTC).Truncate(time.Second),
+			item.Derived,
+		})
 	}
-	query := fmt.Sprintf(
+	rows = slices.DeleteFunc(rows, func(row []any) bool { return row == nil })
+	return batchInsert(
+		ctx,
+		repo.db,
 		`
 INSERT INTO 
-    crypto_analyst.candlesticks(symbol, exchange, open_time, close_time, open_price, high_price, low_price,close_price, volume, number_trades, candle_interval, created_at)
-VALUES %s ON CONFLICT (symbol, exchange, open_time, close_time, candle_interval) DO NOTHING
-`,
-		strings.Join(values, ", "),
+    crypto_analyst.candlesticks(symbol, exchange, open_time, close_time, open_price, high_price, low_price, close_price, volume, number_trades, candle_interval, created_at, derived)`,
+		// Derived candlestick is replaced by exchange one or recalculated, exchange candlestick is never replaced.
+		`
+ON CONFLICT (symbol, exchange, open_time, close_time, candle_interval) DO UPDATE
+    SET open_price    = EXCLUDED.open_price,
+        high_price    = EXCLUDED.high_price,
+        low_price     = EXCLUDED.low_price,
+        close_price   = EXCLUDED.close_price,
+        volume        = EXCLUDED.volume,
+        number_trades = EXCLUDED.number_trades,
+        derived       = EXCLUDED.derived,
+        updated_at    = CURRENT_TIMESTAMP
+WHERE crypto_analyst.candlesticks.derived`,
+		rows,
 	)
-	_, err := repo.db.ExecContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	return nil
 }
 
 func (repo *Candlestick) Candlesticks(ctx context.Context, exchange, symbol string, from, to time.Time) ([]dto.Candlestick, error) {
@@ -73,22 +83,26 @@ SELECT symbol,
        volume,
        number_trades,
        candle_interval,
-       created_at
+       created_at,
+       derived
 FROM crypto_analyst.candlesticks
 WHERE exchange = $1 
   AND symbol = $2
-AND created_at>= $3 AND created_at<=$4
+  AND open_time >= $3
+  AND open_time < $4
 ORDER BY close_time
 `
 		result []dto.Candlestick
 	)
-	if err := repo.db.SelectContext(ctx, &result, query, exchange, symbol, from, to); err != nil {
+	if err := repo.db.SelectContext(ctx, &result, query, exchange, symbol, from.In(time.UTC), to.In(time.UTC)); err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
-func (repo *Candlestick) LastCandlestick(ctx context.Context, exchange, symbol, interval string) (*dto.Candlestick, error) {
+func (repo *Candlestick) LastCandlestick(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) (*dto.Candlestick, error) {
 	var (
 		query = `
 SELECT symbol,
@@ -102,18 +116,135 @@ SELECT symbol,
        volume,
        number_trades,
        candle_interval,
-       created_at
+       created_at,
+       derived
 FROM crypto_analyst.candlesticks
 WHERE exchange = $1 
   AND symbol = $2
   AND candle_interval = $3
-ORDER BY close_time
+ORDER BY close_time DESC
 LIMIT 1
 `
 		result dto.Candlestick
 	)
-	if err := repo.db.GetContext(ctx, &result, query, exchange, symbol, interval); err != nil {
+	if err := repo.db.GetContext(ctx, &result, query, exchange, symbol, interval.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &result, nil
 }
+
+// IntervalCandlesticks returns candlesticks of interval opened in [from, to) ordered by open time.
+func (repo *Candlestick) IntervalCandlesticks(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, from, to time.Time,
+) ([]dto.Candlestick, error) {
+	var (
+		query = `
+SELECT symbol,
+       exchange,
+       open_time,
+       close_time,
+       open_price,
+       high_price,
+       low_price,
+       close_price,
+       volume,
+       number_trades,
+       candle_interval,
+       created_at,
+       derived
+FROM crypto_analyst.candlesticks
+WHERE exchange = $1
+  AND symbol = $2
+  AND candle_interval = $3
+  AND open_time >= $4
+  AND open_time < $5
+ORDER BY open_time
+`
+		result []dto.Candlestick
+	)
+	err := repo.db.SelectContext(
+		ctx,
+		&result,
+		query,
+		exchange,
+		symbol,
+		interval.String(),
+		from.In(time.UTC).Truncate(time.Second),
+		to.In(time.UTC).Truncate(time.Second),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Gaps returns ranges between candlesticks opened in [from, to) which are not adjacent.
+func (repo *Candlestick) Gaps(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, from, to time.Time,
+) ([]domain.CandlestickGap, error) {
+	var (
+		query = `
+SELECT gap_from, gap_to
+FROM (SELECT LAG(close_time) OVER (ORDER BY open_time) AS gap_from,
+             open_time                                 AS gap_to
+      FROM crypto_analyst.candlesticks
+      WHERE exchange = $1
+        AND symbol = $2
+        AND candle_interval = $3
+        AND open_time >= $4
+        AND open_time < $5) AS series
+WHERE gap_to - gap_from > INTERVAL '1 second'
+ORDER BY gap_to
+`
+		rows []struct {
+			From time.Time `db:"gap_from"`
+			To   time.Time `db:"gap_to"`
+		}
+	)
+	if err := repo.db.SelectContext(ctx, &rows, query, exchange, symbol, interval.String(), from.In(time.UTC), to.In(time.UTC)); err != nil {
+		return nil, err
+	}
+	gaps := make([]domain.CandlestickGap, 0, len(rows))
+	for _, row := range rows {
+		gaps = append(gaps, domain.CandlestickGap{
+			Exchange: exchange,
+			Symbol:   symbol,
+			Interval: interval,
+			From:     row.From,
+			To:       row.To,
+		})
+	}
+	return gaps, nil
+}
+
+// Bounds returns open time of the first and close time of the last candlestick opened in [from, to),
+// ok is false when there are no candlesticks.
+func (repo *Candlestick) Bounds(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, from, to time.Time,
+) (first, last time.Time, ok bool, err error) {
+	var (
+		query = `
+SELECT min(open_time) AS first_open, max(close_time) AS last_close
+FROM crypto_analyst.candlesticks
+WHERE exchange = $1
+  AND symbol = $2
+  AND candle_interval = $3
+  AND open_time >= $4
+  AND open_time < $5
+`
+		row struct {
+			First *time.Time `db:"first_open"`
+			Last  *time.Time `db:"last_close"`
+		}
+	)
+	if err := repo.db.GetContext(ctx, &row, query, exchange, symbol, interval.String(), from.In(time.UTC), to.In(time.UTC)); err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	if row.First == nil || row.Last == nil {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	return *row.First, *row.Last, true, nil
+}
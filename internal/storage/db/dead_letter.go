@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+var _ domain.DeadLetterStorage = (*DeadLetter)(nil)
+
+type DeadLetter struct {
+	db *sqlx.DB
+}
+
+func NewDeadLetter(db *sqlx.DB) *DeadLetter {
+	return &DeadLetter{db: db}
+}
+
+func (repo *DeadLetter) SaveDeadLetter(ctx context.Context, letter domain.DeadLetter) error {
+	var query = `
+INSERT INTO crypto_analyst.notification_dead_letters(sink, payload, error, created_at)
+VALUES ($1, $2::JSONB, $3, $4)
+`
+	_, err := repo.db.ExecContext(
+		ctx, query, letter.Sink, letter.Payload, letter.Error, letter.CreatedAt.In(time.UTC).Truncate(time.Second),
+	)
+	return err
+}
+
+func (repo *DeadLetter) DeadLetters(ctx context.Context, from time.Time) ([]domain.DeadLetter, error) {
+	var (
+		query = `
+SELECT id, sink, payload::TEXT AS payload, error, created_at
+FROM crypto_analyst.notification_dead_letters
+WHERE created_at >= $1
+ORDER BY created_at DESC, id DESC
+`
+		result []domain.DeadLetter
+	)
+	if err := repo.db.SelectContext(ctx, &result, query, from.In(time.UTC)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
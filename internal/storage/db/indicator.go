@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var _ domain.IndicatorStorage = (*Indicator)(nil)
+
+type Indicator struct {
+	db *sqlx.DB
+}
+
+func NewIndicator(db *sqlx.DB) *Indicator {
+	return &Indicator{db: db}
+}
+
+func (repo *Indicator) SaveIndicators(ctx context.Context, snapshots []domain.IndicatorSnapshot) error {
+	rows := make([][]any, 0, len(snapshots))
+	for _, item := range snapshots {
+		rows = append(rows, []any{
+			item.Exchange,
+			item.Symbol,
+			item.Interval.String(),
+			item.OpenTime.In(time.UTC).Truncate(time.Second),
+			item.MA8,
+			item.MA40,
+			item.EMA,
+			item.WMA,
+			item.SMA,
+			item.RingLow,
+			item.RingHigh,
+			item.PinBar,
+			item.CreatedAt.In(time.UTC).Truncate(time.Second),
+		})
+	}
+	return batchInsert(
+		ctx,
+		repo.db,
+		`
+INSERT INTO crypto_analyst.indicators(exchange, symbol, candle_interval, open_time, ma8, ma40, ema, wma, sma, ring_low, ring_high, pin_bar, created_at)`,
+		`
+ON CONFLICT (exchange, symbol, candle_interval, open_time) DO UPDATE
+    SET ma8        = EXCLUDED.ma8,
+        ma40       = EXCLUDED.ma40,
+        ema        = EXCLUDED.ema,
+        wma        = EXCLUDED.wma,
+        sma        = EXCLUDED.sma,
+        ring_low   = EXCLUDED.ring_low,
+        ring_high  = EXCLUDED.ring_high,
+        pin_bar    = EXCLUDED.pin_bar,
+        created_at = EXCLUDED.created_at`,
+		rows,
+	)
+}
+
+func (repo *Indicator) LastIndicator(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) (domain.IndicatorSnapshot, error) {
+	var (
+		query = `
+SELECT *
+FROM crypto_analyst.indicators
+WHERE exchange = $1
+  AND symbol = $2
+  AND candle_interval = $3
+ORDER BY open_time DESC
+LIMIT 1
+`
+		result domain.IndicatorSnapshot
+	)
+	if err := repo.db.GetContext(ctx, &result, query, exchange, symbol, interval.String()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.IndicatorSnapshot{}, domain.ErrIndicatorNotFound
+		}
+		return domain.IndicatorSnapshot{}, err
+	}
+	return result, nil
+}
+
+var _ domain.IndicatorValueStorage = (*Indicator)(nil)
+
+func (repo *Indicator) SaveIndicatorValues(ctx context.Context, values []domain.IndicatorValue) error {
+	rows := make([][]any, 0, len(values))
+	for _, item := range values {
+		outputs, err := json.Marshal(item.Values)
+		if err != nil {
+			return errors.Wrap(err, "marshal outputs")
+		}
+		rows = append(rows, []any{
+			item.InstanceID,
+			item.OpenTime.In(time.UTC).Truncate(time.Second),
+			string(outputs),
+			item.CreatedAt.In(time.UTC).Truncate(time.Second),
+		})
+	}
+	return batchInsert(
+		ctx,
+		repo.db,
+		`
+INSERT INTO crypto_analyst.indicator_values(instance_id, open_time, outputs, created_at)`,
+		`
+ON CONFLICT (instance_id, open_time) DO UPDATE
+    SET outputs    = EXCLUDED.outputs,
+        created_at = EXCLUDED.created_at`,
+		rows,
+	)
+}
+
+func (repo *Indicator) LastIndicatorValue(ctx context.Context, instanceID string) (domain.IndicatorValue, error) {
+	var (
+		query = `
+SELECT instance_id, open_time, outputs::TEXT AS outputs, created_at
+FROM crypto_analyst.indicator_values
+WHERE instance_id = $1
+ORDER BY open_time DESC
+LIMIT 1
+`
+		row struct {
+			InstanceID string    `db:"instance_id"`
+			OpenTime   time.Time `db:"open_time"`
+			Outputs    string    `db:"outputs"`
+			CreatedAt  time.Time `db:"created_at"`
+		}
+	)
+	if err := repo.db.GetContext(ctx, &row, query, instanceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.IndicatorValue{}, domain.ErrIndicatorNotFound
+		}
+		return domain.IndicatorValue{}, err
+	}
+	value := domain.IndicatorValue{InstanceID: row.InstanceID, OpenTime: row.OpenTime, CreatedAt: row.CreatedAt}
+	if err := json.Unmarshal([]byte(row.Outputs), &value.Values); err != nil {
+		return domain.IndicatorValue{}, errors.Wrap(err, "unmarshal outputs")
+	}
+	return value, nil
+}
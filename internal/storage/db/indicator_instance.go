@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var _ domain.IndicatorInstanceStorage = (*IndicatorInstances)(nil)
+
+type IndicatorInstances struct {
+	db *sqlx.DB
+}
+
+func NewIndicatorInstances(db *sqlx.DB) *IndicatorInstances {
+	return &IndicatorInstances{db: db}
+}
+
+func (repo *IndicatorInstances) IndicatorInstances(ctx context.Context) ([]domain.IndicatorInstance, error) {
+	var (
+		query = `
+SELECT id, name, exchange, symbol, candle_interval, params::TEXT AS params, created_at
+FROM crypto_analyst.indicator_instances
+ORDER BY id
+`
+		rows []struct {
+			domain.IndicatorInstance
+			Params string `db:"params"`
+		}
+	)
+	if err := repo.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, err
+	}
+	instances := make([]domain.IndicatorInstance, 0, len(rows))
+	for _, row := range rows {
+		instance := row.IndicatorInstance
+		if err := json.Unmarshal([]byte(row.Params), &instance.Params); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal params of %s", instance.ID)
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func (repo *IndicatorInstances) SaveIndicatorInstance(ctx context.Context, instance domain.IndicatorInstance) error {
+	var query = `
+INSERT INTO crypto_analyst.indicator_instances(id, name, exchange, symbol, candle_interval, params, created_at)
+VALUES ($1, $2, $3, $4, $5, $6::JSONB, $7)
+ON CONFLICT (id) DO NOTHING
+`
+	params, err := json.Marshal(instance.Params)
+	if err != nil {
+		return errors.Wrap(err, "marshal params")
+	}
+	_, err = repo.db.ExecContext(
+		ctx,
+		query,
+		instance.ID,
+		instance.Name,
+		instance.Exchange,
+		instance.Symbol,
+		instance.Interval.String(),
+		string(params),
+		instance.CreatedAt.In(time.UTC).Truncate(time.Second),
+	)
+	return err
+}
+
+func (repo *IndicatorInstances) DeleteIndicatorInstance(ctx context.Context, id string) error {
+	var query = `
+DELETE FROM crypto_analyst.indicator_instances
+WHERE id = $1
+`
+	res, err := repo.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrIndicatorNotFound
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ domain.LevelStorage      = (*Level)(nil)
+	_ domain.LevelEventStorage = (*Level)(nil)
+)
+
+type Level struct {
+	db *sqlx.DB
+}
+
+func NewLevel(db *sqlx.DB) *Level {
+	return &Level{db: db}
+}
+
+func (repo *Level) SaveLevels(
+	ctx context.Context, exchange, symbol string, interval domain.Interval, levels []domain.Level,
+) error {
+	var (
+		deleteQuery = `
+DELETE
+FROM crypto_analyst.levels
+WHERE exchange = $1
+  AND symbol = $2
+  AND candle_interval = $3
+`
+	)
+	rows := make([][]any, 0, len(levels))
+	for _, item := range levels {
+		rows = append(rows, []any{
+			exchange,
+			symbol,
+			interval.String(),
+			string(item.Kind),
+			item.Low,
+			item.High,
+			item.Touches,
+			item.FirstTouch.In(time.UTC).Truncate(time.Second),
+			item.LastTouch.In(time.UTC).Truncate(time.Second),
+			item.Breaks,
+			utcTime(item.LastBreak),
+			item.Retests,
+			utcTime(item.LastRetest),
+			item.UpdatedAt.In(time.UTC).Truncate(time.Second),
+		})
+	}
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, deleteQuery, exchange, symbol, interval.String()); err != nil {
+		return errors.Wrap(err, "delete levels")
+	}
+	err = insertRows(
+		ctx,
+		tx,
+		`
+INSERT INTO crypto_analyst.levels(exchange, symbol, candle_interval, kind, low, high, touches, first_touch, last_touch, breaks, last_break, retests, last_retest, updated_at)`,
+		"",
+		rows,
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert levels")
+	}
+	return tx.Commit()
+}
+
+func (repo *Level) Levels(ctx context.Context, exchange, symbol string) ([]domain.Level, error) {
+	var (
+		query = `
+SELECT exchange, symbol, candle_interval, kind, low, high, touches, first_touch, last_touch,
+       breaks, last_break, retests, last_retest, updated_at
+FROM crypto_analyst.levels
+WHERE exchange = $1
+  AND symbol = $2
+ORDER BY candle_interval, low
+`
+		result []domain.Level
+	)
+	if err := repo.db.SelectContext(ctx, &result, query, exchange, symbol); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SaveLevelEvents inserts level events and returns the inserted ones, events which are already saved are skipped.
+func (repo *Level) SaveLevelEvents(ctx context.Context, events []domain.LevelEvent) ([]domain.LevelEvent, error) {
+	rows := make([][]any, 0, len(events))
+	for _, item := range events {
+		rows = append(rows, []any{
+			item.Exchange,
+			item.Symbol,
+			item.Interval.String(),
+			string(item.Kind),
+			item.Low,
+			item.High,
+			string(item.Event),
+			item.Price,
+			item.PrevPrice,
+			item.Date.In(time.UTC).Truncate(time.Second),
+			item.CreatedAt.In(time.UTC).Truncate(time.Second),
+		})
+	}
+	var inserted []domain.LevelEvent
+	err := batchInsertScan(
+		ctx,
+		repo.db,
+		`
+INSERT INTO crypto_analyst.level_events(exchange, symbol, candle_interval, kind, low, high, event, price, prev_price, datetime, created_at)`,
+		`
+ON CONFLICT (exchange, symbol, candle_interval, low, high, kind, datetime) DO NOTHING
+RETURNING exchange, symbol, candle_interval, kind, low, high, event, price, prev_price, datetime, created_at`,
+		rows,
+		func(row *sqlx.Rows) error {
+			var item domain.LevelEvent
+			if err := row.StructScan(&item); err != nil {
+				return err
+			}
+			inserted = append(inserted, item)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+func (repo *Level) LevelEvents(ctx context.Context, exchange, symbol string, from time.Time) ([]domain.LevelEvent, error) {
+	var (
+		query = `
+SELECT exchange, symbol, candle_interval, kind, low, high, event, price, prev_price, datetime, created_at
+FROM crypto_analyst.level_events
+WHERE exchange = $1
+  AND symbol = $2
+  AND datetime >= $3
+ORDER BY datetime DESC
+`
+		result []domain.LevelEvent
+	)
+	if err := repo.db.SelectContext(ctx, &result, query, exchange, symbol, from.In(time.UTC)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// utcTime converts optional time to value of nullable TIMESTAMP column.
+func utcTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.In(time.UTC).Truncate(time.Second)
+}
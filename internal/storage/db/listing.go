@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultListingLimit is number of listings returned when filter has no limit.
+const DefaultListingLimit = 100
+
+var _ domain.ListingStorage = (*Listing)(nil)
+
+type Listing struct {
+	db *sqlx.DB
+}
+
+func NewListing(db *sqlx.DB) *Listing {
+	return &Listing{db: db}
+}
+
+const listingColumns = `exchange, symbol, first_seen, first_price, high, low, last_price, last_seen, complete,
+       cross_listed, first_exchange, first_listed_at,
+       COALESCE((high - first_price) / NULLIF(first_price, 0) * 100, 0)       AS high_percent,
+       COALESCE((low - first_price) / NULLIF(first_price, 0) * 100, 0)        AS low_percent,
+       COALESCE((last_price - first_price) / NULLIF(first_price, 0) * 100, 0) AS change_percent,
+       updated_at`
+
+const listingFilter = `
+WHERE first_seen >= $1
+  AND ($2 = '' OR exchange = $2)
+  AND ($3 = '' OR symbol = $3)
+  AND ($4::BOOLEAN IS NULL OR cross_listed = $4)
+`
+
+func (repo *Listing) SaveListings(ctx context.Context, listings []domain.Listing) error {
+	if len(listings) == 0 {
+		return nil
+	}
+	rows := make([][]any, 0, len(listings))
+	for _, item := range listings {
+		rows = append(rows, []any{
+			item.Exchange,
+			item.Symbol,
+			item.FirstSeen.In(time.UTC).Truncate(time.Second),
+			item.FirstPrice,
+			item.High,
+			item.Low,
+			item.LastPrice,
+			item.LastSeen.In(time.UTC).Truncate(time.Second),
+			item.Complete,
+			item.CrossListed,
+			item.FirstExchange,
+			utcTime(item.FirstListedAt),
+		})
+	}
+	return batchInsert(
+		ctx,
+		repo.db,
+		`
+INSERT INTO crypto_analyst.listings(exchange, symbol, first_seen, first_price, high, low, last_price, last_seen,
+                                    complete, cross_listed, first_exchange, first_listed_at)`,
+		`
+ON CONFLICT (exchange, symbol) DO UPDATE
+    SET high       = EXCLUDED.high,
+        low        = EXCLUDED.low,
+        last_price = EXCLUDED.last_price,
+        last_seen  = EXCLUDED.last_seen,
+        complete   = EXCLUDED.complete,
+        updated_at = CURRENT_TIMESTAMP`,
+		rows,
+	)
+}
+
+func (repo *Listing) Listings(ctx context.Context, filter domain.ListingFilter) ([]domain.Listing, int, error) {
+	var (
+		query = `
+SELECT ` + listingColumns + `
+FROM crypto_analyst.listings` + listingFilter + `
+ORDER BY first_seen DESC, exchange, symbol
+LIMIT $5 OFFSET $6
+`
+		countQuery = `
+SELECT count(*)
+FROM crypto_analyst.listings` + listingFilter
+		result []domain.Listing
+		total  int
+	)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListingLimit
+	}
+	args := []any{filter.Since.In(time.UTC), filter.Exchange, filter.Symbol, filter.CrossListed}
+	if err := repo.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, err
+	}
+	if err := repo.db.SelectContext(ctx, &result, query, append(args, limit, max(filter.Offset, 0))...); err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+func (repo *Listing) SymbolListings(ctx context.Context, symbols []string) ([]domain.Listing, error) {
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+	var (
+		query = `
+SELECT ` + listingColumns + `
+FROM crypto_analyst.listings
+WHERE symbol = ANY ($1)
+ORDER BY first_seen DESC, exchange, symbol
+`
+		result []domain.Listing
+	)
+	if err := repo.db.SelectContext(ctx, &result, query, symbols); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (repo *Listing) TrackedListings(ctx context.Context) ([]domain.Listing, error) {
+	var (
+		query = `
+SELECT ` + listingColumns + `
+FROM crypto_analyst.listings
+WHERE NOT complete
+ORDER BY first_seen
+`
+		result []domain.Listing
+	)
+	if err := repo.db.SelectContext(ctx, &result, query); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var _ domain.NotificationOutbox = (*NotificationOutbox)(nil)
+
+type NotificationOutbox struct {
+	db *sqlx.DB
+}
+
+func NewNotificationOutbox(db *sqlx.DB) *NotificationOutbox {
+	return &NotificationOutbox{db: db}
+}
+
+const insertOutboxNotifications = `INSERT INTO crypto_analyst.notification_outbox(payload)`
+
+func (repo *NotificationOutbox) AddNotifications(ctx context.Context, alerts []domain.Alert) error {
+	rows, err := outboxRows(alerts)
+	if err != nil {
+		return err
+	}
+	return batchInsert(ctx, repo.db, insertOutboxNotifications, "", rows)
+}
+
+// outboxRows returns rows of notifications with json of alerts.
+func outboxRows(alerts []domain.Alert) ([][]any, error) {
+	rows := make([][]any, 0, len(alerts))
+	for _, alert := range alerts {
+		payload, err := json.Marshal(alert)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal alert")
+		}
+		rows = append(rows, []any{string(payload)})
+	}
+	return rows, nil
+}
+
+// ClaimNotification claims alert with SKIP LOCKED, so notifiers of several processes do not deliver the same alert.
+func (repo *NotificationOutbox) ClaimNotification(ctx context.Context, lease time.Duration) (*domain.OutboxNotification, error) {
+	var (
+		query = `
+UPDATE crypto_analyst.notification_outbox
+SET claimed_at = $1
+WHERE id = (SELECT id
+            FROM crypto_analyst.notification_outbox
+            WHERE claimed_at IS NULL
+               OR claimed_at < $2
+            ORDER BY id
+            LIMIT 1 FOR UPDATE SKIP LOCKED)
+RETURNING id, payload::TEXT
+`
+		id      int64
+		payload string
+		now     = time.Now().In(time.UTC)
+	)
+	err := repo.db.QueryRowxContext(ctx, query, now, now.Add(-lease)).Scan(&id, &payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	notification := &domain.OutboxNotification{ID: id}
+	if err := json.Unmarshal([]byte(payload), &notification.Alert); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal alert of notification %d", id)
+	}
+	return notification, nil
+}
+
+func (repo *NotificationOutbox) DeleteNotification(ctx context.Context, id int64) error {
+	_, err := repo.db.ExecContext(ctx, `DELETE FROM crypto_analyst.notification_outbox WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultPatternLimit is number of patterns returned when filter has no limit.
+const DefaultPatternLimit = 500
+
+var _ domain.PatternStorage = (*Pattern)(nil)
+
+type Pattern struct {
+	db *sqlx.DB
+}
+
+func NewPattern(db *sqlx.DB) *Pattern {
+	return &Pattern{db: db}
+}
+
+func (repo *Pattern) SavePatterns(ctx context.Context, patterns []domain.CandlestickPattern) ([]domain.CandlestickPattern, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	rows := make([][]any, 0, len(patterns))
+	for _, item := range patterns {
+		rows = append(rows, []any{
+			item.Exchange,
+			item.Symbol,
+			item.Interval.String(),
+			item.OpenTime.In(time.UTC).Truncate(time.Second),
+			string(item.Pattern),
+			string(item.Direction),
+			item.Strength,
+			item.CreatedAt.In(time.UTC).Truncate(time.Second),
+		})
+	}
+	var inserted []domain.CandlestickPattern
+	err := batchInsertScan(
+		ctx,
+		repo.db,
+		`
+INSERT INTO crypto_analyst.candlestick_patterns(exchange, symbol, candle_interval, open_time, pattern, direction, strength, created_at)`,
+		`
+ON CONFLICT (exchange, symbol, candle_interval, open_time, pattern) DO UPDATE
+    SET direction  = EXCLUDED.direction,
+        strength   = EXCLUDED.strength,
+        created_at = EXCLUDED.created_at
+RETURNING *, (xmax = 0) AS inserted`,
+		rows,
+		// xmax of row is zero when it is inserted and transaction id when it is updated by conflict.
+		func(row *sqlx.Rows) error {
+			var item struct {
+				domain.CandlestickPattern
+				Inserted bool `db:"inserted"`
+			}
+			if err := row.StructScan(&item); err != nil {
+				return err
+			}
+			if item.Inserted {
+				inserted = append(inserted, item.CandlestickPattern)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+// Patterns returns patterns matched by filter, the latest candlesticks and the strongest patterns first.
+func (repo *Pattern) Patterns(ctx context.Context, filter domain.PatternFilter) ([]domain.CandlestickPattern, error) {
+	var (
+		query = `
+SELECT *
+FROM crypto_analyst.candlestick_patterns
+WHERE open_time >= $1
+  AND ($2 = '' OR exchange = $2)
+  AND ($3 = '' OR symbol = $3)
+  AND ($4 = '' OR candle_interval = $4)
+  AND ($5 = '' OR pattern = $5)
+  AND ($6 = '' OR direction = $6)
+  AND strength >= $7
+ORDER BY open_time DESC, strength DESC, symbol
+LIMIT $8
+`
+		result []domain.CandlestickPattern
+	)
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPatternLimit
+	}
+	err := repo.db.SelectContext(
+		ctx,
+		&result,
+		query,
+		filter.Since.In(time.UTC),
+		filter.Exchange,
+		filter.Symbol,
+		filter.Interval.String(),
+		string(filter.Pattern),
+		string(filter.Direction),
+		filter.MinStrength,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -2,8 +2,6 @@ package db
 
 import (
 	"context"
-	"fmt"
-	"strings"
 	"time"
 
 	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
@@ -47,58 +45,18 @@ ORDER BY  datetime ASC
 	return result, nil
 }
 
-func (repo *PriceRepository) DeleteOldPrices(ctx context.Context, symbol string, to time.Time) error {
-	query := `
-DELETE FROM crypto_analyst.prices WHERE symbol = $1 
-  AND datetime < $2
-`
-	_, err := repo.db.ExecContext(ctx, query, symbol, to)
-
-	return err
-}
-
-func (repo *PriceRepository) ClearOldPrices(ctx context.Context, to time.Time) error {
-	query := `
-DELETE FROM crypto_analyst.prices WHERE  datetime < $1
-`
-	_, err := repo.db.ExecContext(ctx, query, to)
-
-	return err
-}
-
-func (repo *PriceRepository) DeletePrices(ctx context.Context, symbol string, from, to time.Time) error {
-	query := `
-DELETE FROM crypto_analyst.prices WHERE symbol = $1 
-  AND (datetime BETWEEN $2 AND $3)  
-`
-	_, err := repo.db.ExecContext(ctx, query, symbol, from, to)
-
-	return err
-}
-
 func (repo *PriceRepository) SavePrices(ctx context.Context, prices []*domain.SymbolPrice) error {
-	var values []string
-
-	if len(prices) == 0 {
-		return nil
-	}
+	rows := make([][]any, 0, len(prices))
 	for _, price := range prices {
-		values = append(
-			values,
-			fmt.Sprintf(
-				"(%f,'%s', '%s','%s')",
-				price.Price, price.Symbol, price.Exchange, price.Date.Format(DatetimeFormat)),
-		)
+		rows = append(rows, []any{price.Price, price.Symbol, price.Exchange, price.Date.Truncate(time.Second)})
 	}
-	query := fmt.Sprintf(
-		"INSERT INTO crypto_analyst.prices(price, symbol,exchange,datetime) VALUES %s ON CONFLICT (price, symbol, exchange, datetime) DO NOTHING",
-		strings.Join(values, ", "),
+	return batchInsert(
+		ctx,
+		repo.db,
+		"INSERT INTO crypto_analyst.prices(price, symbol, exchange, datetime)",
+		"ON CONFLICT (price, symbol, exchange, datetime) DO NOTHING",
+		rows,
 	)
-	_, err := repo.db.ExecContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	return nil
 }
 
 func (repo *PriceRepository) Prices(ctx context.Context, symbol string) ([]domain.SymbolPrice, error) {
@@ -122,28 +80,17 @@ ORDER BY exchange ASC
 }
 
 func (repo *PriceRepository) AddNewSymbol(ctx context.Context, prices []domain.SymbolPrice) error {
-	var values []string
-
-	if len(prices) == 0 {
-		return nil
-	}
+	rows := make([][]any, 0, len(prices))
 	for _, price := range prices {
-		values = append(
-			values,
-			fmt.Sprintf(
-				"(%f,'%s', '%s','%s')",
-				price.Price, price.Symbol, price.Exchange, price.Date.Format(DatetimeFormat)),
-		)
+		rows = append(rows, []any{price.Price, price.Symbol, price.Exchange, price.Date.Truncate(time.Second)})
 	}
-	query := fmt.Sprintf(
-		"INSERT INTO crypto_analyst.new_symbols(price, symbol,exchange,datetime) VALUES %s ON CONFLICT (symbol, exchange) DO NOTHING",
-		strings.Join(values, ", "),
+	return batchInsert(
+		ctx,
+		repo.db,
+		"INSERT INTO crypto_analyst.new_symbols(price, symbol, exchange, datetime)",
+		"ON CONFLICT (symbol, exchange) DO NOTHING",
+		rows,
 	)
-	_, err := repo.db.ExecContext(ctx, query)
-	if err != nil {
-		return err
-	}
-	return nil
 }
 
 func (repo *PriceRepository) NewSymbols(ctx context.Context, from time.Time) ([]domain.SymbolPrice, error) {
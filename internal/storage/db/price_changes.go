@@ -3,11 +3,19 @@ package db
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// PriceChangesBackfillVersion is migration which swaps text and typed datetime of price changes.
+	PriceChangesBackfillVersion = 15
+	priceChangesBackfillBatch   = 10000
+	priceChangesBackfillPause   = 50 * time.Millisecond
 )
 
 type PriceChanges struct {
@@ -20,121 +28,224 @@ func NewPriceChanges(db *sqlx.DB) *PriceChanges {
 	}
 }
 
-func (repo *PriceChanges) Save(ctx context.Context, data []domain.PriceChange) error {
-	var (
-		query = `
-INSERT INTO 
-    crypto_analyst.price_changes(symbol, exchange, datetime, coefficient_change, price, prev_price, created_at) 
-VALUES %s ON CONFLICT (symbol, exchange, datetime) DO NOTHING
-`
-		params []string
+// Save inserts price changes and returns the inserted ones, changes which are already saved are skipped.
+func (repo *PriceChanges) Save(ctx context.Context, data []domain.PriceChange) ([]domain.PriceChange, error) {
+	type changeKey struct {
+		symbol, exchange string
+		datetime         int64
+	}
+	rows := make([][]any, 0, len(data))
+	for _, item := range data {
+		rows = append(rows, []any{
+			item.Symbol,
+			item.Exchange,
+			item.Date.In(time.UTC),
+			item.CoefficientOfChange,
+			item.Price,
+			item.PrevPrice,
+			item.CreatedAt.Truncate(time.Second),
+		})
+	}
+	inserted := make(map[changeKey]bool)
+	err := batchInsertScan(
+		ctx,
+		repo.db,
+		`INSERT INTO crypto_analyst.price_changes(symbol, exchange, datetime, coefficient_change, price, prev_price, created_at)`,
+		`ON CONFLICT (symbol, exchange, datetime) DO NOTHING RETURNING symbol, exchange, datetime`,
+		rows,
+		func(row *sqlx.Rows) error {
+			var (
+				key      changeKey
+				datetime time.Time
+			)
+			if err := row.Scan(&key.symbol, &key.exchange, &datetime); err != nil {
+				return err
+			}
+			key.datetime = datetime.UnixMicro()
+			inserted[key] = true
+			return nil
+		},
 	)
-	if len(data) == 0 {
-		return nil
+	if err != nil {
+		return nil, err
 	}
+	result := make([]domain.PriceChange, 0, len(inserted))
 	for _, item := range data {
-		params = append(
-			params,
-			fmt.Sprintf(
-				"('%s', '%s', '%s', %d, %f, %f, '%s')",
-				item.Symbol,
-				item.Exchange,
-				item.Date.Format(DatetimeFormat),
-				item.CoefficientOfChange,
-				item.Price,
-				item.PrevPrice,
-				item.CreatedAt.Format(DatetimeFormat),
-			))
-	}
-	if _, err := repo.db.ExecContext(ctx, fmt.Sprintf(query, strings.Join(params, ","))); err != nil {
-		return err
-	}
-	return nil
+		// Postgres keeps timestamps with microsecond precision.
+		if inserted[changeKey{symbol: item.Symbol, exchange: item.Exchange, datetime: item.Date.UnixMicro()}] {
+			result = append(result, item)
+		}
+	}
+	return result, nil
 }
 
+// LastDatetimeSymbolRow returns time of the latest price change of symbol, a year ago when there is none.
 func (repo *PriceChanges) LastDatetimeSymbolRow(ctx context.Context, symbol string) (time.Time, error) {
 	var (
-		query    = `SELECT coalesce(max(created_at), now() - interval '1 year')  FROM crypto_analyst.price_changes WHERE symbol = $1`
+		query    = `SELECT coalesce(max(datetime), now() - interval '1 year') FROM crypto_analyst.price_changes WHERE symbol = $1`
 		datetime time.Time
 	)
 	if err := repo.db.GetContext(ctx, &datetime, query, symbol); err != nil {
-		return time.Time{}, fmt.Errorf("load max created_at for symbol=%s (%s)", symbol, err.Error())
+		return time.Time{}, fmt.Errorf("load max datetime for symbol=%s (%s)", symbol, err.Error())
 	}
-	return datetime, nil
+	return datetime.In(time.UTC), nil
 }
 
 func (repo *PriceChanges) FirstDatetimeRow(ctx context.Context) (time.Time, error) {
 	var (
-		query    = `SELECT TO_TIMESTAMP(datetime, 'YYYY/MM/DD/HH24:MI:ss') FROM crypto_analyst.price_changes ORDER BY datetime LIMIT 1`
+		query    = `SELECT datetime FROM crypto_analyst.price_changes ORDER BY datetime ASC LIMIT 1`
 		datetime time.Time
 	)
 	if err := repo.db.GetContext(ctx, &datetime, query); err != nil {
 		return time.Time{}, err
 	}
-	datetime.In(time.Now().Location())
-	return datetime, nil
+	return datetime.In(time.UTC), nil
 }
 
+// List returns price changes of symbol which happened between from and to, the earliest first.
 func (repo *PriceChanges) List(ctx context.Context, symbol string, from, to time.Time) ([]domain.PriceChange, error) {
 	var (
 		query = `
 SELECT symbol,
        exchange,
-       TO_TIMESTAMP(datetime, 'YYYY/MM/DD/HH24:MI:ss') as datetime,
+       datetime,
        coefficient_change,
        price,
        prev_price,
        created_at
 FROM crypto_analyst.price_changes
 WHERE symbol = $1
-  AND created_at>= $2 AND created_at<=$3
+  AND (datetime BETWEEN $2 AND $3)
+ORDER BY datetime ASC
 `
 		res []domain.PriceChange
 	)
 	if err := repo.db.SelectContext(ctx, &res, query, symbol, from, to); err != nil {
 		return nil, err
 	}
+	for i := range res {
+		res[i].Date = res[i].Date.In(time.UTC)
+	}
 	return res, nil
 }
 
+// Changes returns price changes of symbol on exchange which happened between from and to, the latest first.
 func (repo *PriceChanges) Changes(ctx context.Context, exchange, symbol string, from, to time.Time) ([]domain.PriceChange, error) {
 	var (
 		query = `
 SELECT symbol,
        exchange,
-       TO_TIMESTAMP(datetime, 'YYYY/MM/DD/HH24:MI:ss') as datetime,
+       datetime,
        coefficient_change,
        price,
        prev_price,
        created_at
 FROM crypto_analyst.price_changes
-WHERE exchange  = $1  AND symbol = $2
-  AND created_at>= $3 AND created_at<=$4
-ORDER BY datetime DESC 
+WHERE exchange = $1
+  AND symbol = $2
+  AND (datetime BETWEEN $3 AND $4)
+ORDER BY datetime DESC
 `
 		res []domain.PriceChange
 	)
-	rows, err := repo.db.QueryxContext(ctx, query, exchange, symbol, from, to)
-	if err != nil {
+	if err := repo.db.SelectContext(ctx, &res, query, exchange, symbol, from, to); err != nil {
 		return nil, err
 	}
-	defer func() { _ = rows.Close() }()
-	for rows.Next() {
-		pc := domain.PriceChange{}
-		if err := rows.StructScan(&pc); err != nil {
-			return nil, err
-		}
-		pc.Date = pc.Date.In(time.UTC)
-		res = append(res, pc)
+	for i := range res {
+		res[i].Date = res[i].Date.In(time.UTC)
 	}
 	return res, nil
 }
 
-func (repo *PriceChanges) DeleteOldRows(ctx context.Context, to time.Time) error {
-	query := `
-DELETE FROM crypto_analyst.price_changes WHERE  datetime < $1
+// MinutePrices returns price changes of symbol on all exchanges which happened in the minutes.
+func (repo *PriceChanges) MinutePrices(ctx context.Context, symbol string, dates []time.Time) ([]domain.PriceChange, error) {
+	var (
+		query = `
+SELECT symbol,
+       exchange,
+       datetime,
+       coefficient_change,
+       price,
+       prev_price,
+       created_at
+FROM crypto_analyst.price_changes
+WHERE symbol = $1
+  AND datetime = ANY ($2)
+ORDER BY datetime ASC
 `
-	_, err := repo.db.ExecContext(ctx, query, to.Format(time.DateTime))
+		res []domain.PriceChange
+	)
+	if err := repo.db.SelectContext(ctx, &res, query, symbol, dates); err != nil {
+		return nil, err
+	}
+	for i := range res {
+		res[i].Date = res[i].Date.In(time.UTC)
+	}
+	return res, nil
+}
 
-	return err
+// BackfillPriceChangesTime fills typed copy of text datetime of price changes in short batches and builds
+// its indexes concurrently, so migration which swaps columns does not lock table for long.
+// It is migration hook and is repeated safely.
+func BackfillPriceChangesTime(ctx context.Context, db *sqlx.DB) error {
+	var (
+		queryNullIndex = `
+CREATE INDEX CONCURRENTLY IF NOT EXISTS price_changes_event_time_null_idx
+    ON crypto_analyst.price_changes (symbol) WHERE event_time IS NULL
+`
+		queryBatch = `
+UPDATE crypto_analyst.price_changes
+SET event_time = TO_TIMESTAMP(datetime, 'YYYY-MM-DD HH24:MI:SS')::TIMESTAMP AT TIME ZONE 'UTC'
+WHERE ctid = ANY (ARRAY(SELECT ctid FROM crypto_analyst.price_changes WHERE event_time IS NULL LIMIT $1))
+`
+		queryValidate = `ALTER TABLE crypto_analyst.price_changes VALIDATE CONSTRAINT price_changes_event_time_not_null`
+		indexes       = []struct{ name, query string }{
+			{
+				name: "price_changes_symbol_exchange_event_time_idx",
+				query: `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS price_changes_symbol_exchange_event_time_idx
+    ON crypto_analyst.price_changes (symbol, exchange, event_time)`,
+			},
+			{
+				name: "price_changes_event_time_idx",
+				query: `CREATE INDEX CONCURRENTLY IF NOT EXISTS price_changes_event_time_idx
+    ON crypto_analyst.price_changes (event_time)`,
+			},
+		}
+	)
+	if _, err := db.ExecContext(ctx, queryNullIndex); err != nil {
+		return errors.Wrap(err, "create index of not filled rows")
+	}
+	var total int64
+	for {
+		res, err := db.ExecContext(ctx, queryBatch, priceChangesBackfillBatch)
+		if err != nil {
+			return errors.Wrap(err, "fill event_time")
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		total += rows
+		if rows < priceChangesBackfillBatch {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(priceChangesBackfillPause):
+		}
+	}
+	zap.L().Info("price changes time is filled", zap.Int64("rows", total))
+	if _, err := db.ExecContext(ctx, queryValidate); err != nil {
+		return errors.Wrap(err, "validate event_time")
+	}
+	for _, index := range indexes {
+		if _, err := db.ExecContext(ctx, index.query); err != nil {
+			// Failed concurrent build leaves invalid index, which IF NOT EXISTS would skip on retry.
+			_, _ = db.ExecContext(ctx, `DROP INDEX CONCURRENTLY IF EXISTS crypto_analyst.`+index.name)
+			return errors.Wrapf(err, "create index %s", index.name)
+		}
+	}
+	_, err := db.ExecContext(ctx, `DROP INDEX CONCURRENTLY IF EXISTS crypto_analyst.price_changes_event_time_null_idx`)
+	return errors.Wrap(err, "drop index of not filled rows")
 }
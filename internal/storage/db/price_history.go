@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var _ domain.PriceHistoryStorage = (*PriceHistory)(nil)
+
+// priceHistoryTables are tables of tiers of price history.
+var priceHistoryTables = map[domain.Interval]string{
+	domain.OneMinuteInterval:  "price_history_1m",
+	domain.FiveMinuteInterval: "price_history_5m",
+	domain.OneHourInterval:    "price_history_1h",
+}
+
+// priceHistoryBuckets are expressions of bucket of tier for time column of source.
+var priceHistoryBuckets = map[domain.Interval]string{
+	domain.OneMinuteInterval: "date_trunc('minute', %s)",
+	domain.FiveMinuteInterval: "date_trunc('hour', %[1]s) + " +
+		"floor(date_part('minute', %[1]s) / 5) * INTERVAL '5 minutes'",
+	domain.OneHourInterval: "date_trunc('hour', %s)",
+}
+
+type PriceHistory struct {
+	db *sqlx.DB
+}
+
+func NewPriceHistory(db *sqlx.DB) *PriceHistory {
+	return &PriceHistory{db: db}
+}
+
+func (repo *PriceHistory) RollupPrices(ctx context.Context, tier domain.Interval, from time.Time) error {
+	table, source, err := priceHistorySource(tier)
+	if err != nil {
+		return err
+	}
+	var query string
+	if source == "" {
+		query = fmt.Sprintf(`
+INSERT INTO crypto_analyst.%[1]s(exchange, symbol, bucket, open, high, low, close, samples)
+SELECT exchange,
+       symbol,
+       %[2]s AS bucket,
+       (array_agg(price ORDER BY datetime ASC))[1],
+       max(price),
+       min(price),
+       (array_agg(price ORDER BY datetime DESC))[1],
+       count(*)
+FROM crypto_analyst.prices
+WHERE datetime >= $1
+GROUP BY exchange, symbol, 3
+ON CONFLICT (exchange, symbol, bucket) DO UPDATE SET open    = excluded.open,
+                                                     high    = excluded.high,
+                                                     low     = excluded.low,
+                                                     close   = excluded.close,
+                                                     samples = excluded.samples
+`, table, fmt.Sprintf(priceHistoryBuckets[tier], "datetime"))
+	} else {
+		query = fmt.Sprintf(`
+INSERT INTO crypto_analyst.%[1]s(exchange, symbol, bucket, open, high, low, close, samples)
+SELECT exchange,
+       symbol,
+       %[3]s AS new_bucket,
+       (array_agg(open ORDER BY bucket ASC))[1],
+       max(high),
+       min(low),
+       (array_agg(close ORDER BY bucket DESC))[1],
+       sum(samples)
+FROM crypto_analyst.%[2]s
+WHERE bucket >= $1
+GROUP BY exchange, symbol, 3
+ON CONFLICT (exchange, symbol, bucket) DO UPDATE SET open    = excluded.open,
+                                                     high    = excluded.high,
+                                                     low     = excluded.low,
+                                                     close   = excluded.close,
+                                                     samples = excluded.samples
+`, table, source, fmt.Sprintf(priceHistoryBuckets[tier], "bucket"))
+	}
+	if _, err := repo.db.ExecContext(ctx, query, from.In(time.UTC)); err != nil {
+		return errors.Wrapf(err, "rollup prices to %s", table)
+	}
+	return nil
+}
+
+func (repo *PriceHistory) LastBucket(ctx context.Context, tier domain.Interval) (time.Time, error) {
+	table, has := priceHistoryTables[tier]
+	if !has {
+		return time.Time{}, fmt.Errorf("unknown price history tier: %s", tier)
+	}
+	var (
+		query  = `SELECT max(bucket) FROM crypto_analyst.` + table
+		bucket sql.NullTime
+	)
+	if err := repo.db.GetContext(ctx, &bucket, query); err != nil {
+		return time.Time{}, err
+	}
+	if !bucket.Valid {
+		return time.Time{}, nil
+	}
+	return bucket.Time.In(time.UTC), nil
+}
+
+func (repo *PriceHistory) FirstBucket(
+	ctx context.Context, exchange, symbol string, tier domain.Interval,
+) (time.Time, error) {
+	table, has := priceHistoryTables[tier]
+	if !has {
+		return time.Time{}, fmt.Errorf("unknown price history tier: %s", tier)
+	}
+	var (
+		query  = `SELECT min(bucket) FROM crypto_analyst.` + table + ` WHERE exchange = $1 AND symbol = $2`
+		bucket sql.NullTime
+	)
+	if err := repo.db.GetContext(ctx, &bucket, query, exchange, symbol); err != nil {
+		return time.Time{}, err
+	}
+	if !bucket.Valid {
+		return time.Time{}, nil
+	}
+	return bucket.Time.In(time.UTC), nil
+}
+
+func (repo *PriceHistory) PriceBars(
+	ctx context.Context, exchange, symbol string, tier domain.Interval, from, to time.Time,
+) ([]domain.PriceBar, error) {
+	table, has := priceHistoryTables[tier]
+	if !has {
+		return nil, fmt.Errorf("unknown price history tier: %s", tier)
+	}
+	var (
+		query = `
+SELECT exchange, symbol, bucket, open, high, low, close, samples
+FROM crypto_analyst.` + table + `
+WHERE exchange = $1
+  AND symbol = $2
+  AND (bucket BETWEEN $3 AND $4)
+ORDER BY bucket ASC
+`
+		bars []domain.PriceBar
+	)
+	if err := repo.db.SelectContext(ctx, &bars, query, exchange, symbol, from.In(time.UTC), to.In(time.UTC)); err != nil {
+		return nil, err
+	}
+	for i := range bars {
+		bars[i].Resolution = tier
+		bars[i].OpenTime = bars[i].OpenTime.In(time.UTC)
+	}
+	return bars, nil
+}
+
+// priceHistorySource returns table of tier and table of previous tier, empty source means raw prices.
+func priceHistorySource(tier domain.Interval) (string, string, error) {
+	table, has := priceHistoryTables[tier]
+	if !has {
+		return "", "", fmt.Errorf("unknown price history tier: %s", tier)
+	}
+	for i, item := range domain.PriceHistoryTiers {
+		if item != tier {
+			continue
+		}
+		if i == 0 {
+			return table, "", nil
+		}
+		return table, priceHistoryTables[domain.PriceHistoryTiers[i-1]], nil
+	}
+	return "", "", fmt.Errorf("unknown price history tier: %s", tier)
+}
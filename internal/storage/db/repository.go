@@ -1,8 +1,20 @@
 package db
 
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
 const (
 	DatetimeFormat      = "2006-01-02 15:04:05"
 	SeparateParamsInSQL = ","
+
+	// MaxQueryParams is postgres limit of bind parameters in one statement.
+	MaxQueryParams = 65535
 )
 
 type Config struct {
@@ -13,3 +25,99 @@ type Config struct {
 	Port     string
 	Database string
 }
+
+// batchInsert executes "<insert> VALUES ($1, ...), (...) <onConflict>" with bound parameters.
+// Rows are split into chunks to stay under MaxQueryParams, all chunks are written in one transaction.
+func batchInsert(ctx context.Context, db *sqlx.DB, insert, onConflict string, rows [][]any) error {
+	return batchInsertScan(ctx, db, insert, onConflict, rows, nil)
+}
+
+// batchInsertScan is batchInsert which passes every row returned by RETURNING clause of onConflict to scan.
+func batchInsertScan(
+	ctx context.Context, db *sqlx.DB, insert, onConflict string, rows [][]any, scan func(*sqlx.Rows) error,
+) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := insertRowsScan(ctx, tx, insert, onConflict, rows, scan); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertRows is batchInsert in transaction of caller.
+func insertRows(ctx context.Context, tx *sqlx.Tx, insert, onConflict string, rows [][]any) error {
+	return insertRowsScan(ctx, tx, insert, onConflict, rows, nil)
+}
+
+// insertRowsScan is batchInsertScan in transaction of caller, statements are executed without reading rows
+// when scan is nil.
+func insertRowsScan(
+	ctx context.Context, tx *sqlx.Tx, insert, onConflict string, rows [][]any, scan func(*sqlx.Rows) error,
+) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	columns := len(rows[0])
+	chunkSize := MaxQueryParams / columns
+	for start := 0; start < len(rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk := rows[start:end]
+		args := make([]any, 0, len(chunk)*columns)
+		query := strings.Builder{}
+		query.WriteString(insert)
+		query.WriteString(" VALUES ")
+		for i, row := range chunk {
+			if len(row) != columns {
+				return errors.Errorf("row %d has %d values, expected %d", start+i, len(row), columns)
+			}
+			if i > 0 {
+				query.WriteString(SeparateParamsInSQL)
+			}
+			query.WriteString("(")
+			for j := range row {
+				if j > 0 {
+					query.WriteString(SeparateParamsInSQL)
+				}
+				query.WriteString("$")
+				query.WriteString(strconv.Itoa(len(args) + j + 1))
+			}
+			query.WriteString(")")
+			args = append(args, row...)
+		}
+		query.WriteString(" ")
+		query.WriteString(onConflict)
+		if scan == nil {
+			if _, err := tx.ExecContext(ctx, query.String(), args...); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := queryRows(ctx, tx, query.String(), args, scan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func queryRows(ctx context.Context, tx *sqlx.Tx, query string, args []any, scan func(*sqlx.Rows) error) error {
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return errors.Wrap(err, "scan returned row")
+		}
+	}
+	return rows.Err()
+}
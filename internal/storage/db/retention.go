@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var _ domain.RetentionStorage = (*Retention)(nil)
+
+// retentionTable is time column which age of rows is measured by and symbol column of rows, empty for tables
+// without symbol.
+type retentionTable struct {
+	timeColumn   string
+	symbolColumn string
+}
+
+// retentionTables are tables which rows are deleted by age, tables of settings (watchlist, alert rules, ...)
+// are not listed.
+var retentionTables = map[string]retentionTable{
+	"prices":                    {timeColumn: "datetime", symbolColumn: "symbol"},
+	"price_changes":             {timeColumn: "datetime", symbolColumn: "symbol"},
+	"price_aggregation":         {timeColumn: "updated_at", symbolColumn: "symbol"},
+	"candlesticks":              {timeColumn: "open_time", symbolColumn: "symbol"},
+	"new_symbols":               {timeColumn: "datetime", symbolColumn: "symbol"},
+	"indicators":                {timeColumn: "open_time", symbolColumn: "symbol"},
+	"indicator_values":          {timeColumn: "open_time"},
+	"candlestick_patterns":      {timeColumn: "open_time", symbolColumn: "symbol"},
+	"level_events":              {timeColumn: "datetime", symbolColumn: "symbol"},
+	"alerts":                    {timeColumn: "datetime", symbolColumn: "symbol"},
+	"notification_dead_letters": {timeColumn: "created_at"},
+	"listings":                  {timeColumn: "first_seen", symbolColumn: "symbol"},
+	"spreads":                   {timeColumn: "datetime", symbolColumn: "symbol"},
+	"backfill_jobs":             {timeColumn: "updated_at", symbolColumn: "symbol"},
+	"price_history_1m":          {timeColumn: "bucket", symbolColumn: "symbol"},
+	"price_history_5m":          {timeColumn: "bucket", symbolColumn: "symbol"},
+	"price_history_1h":          {timeColumn: "bucket", symbolColumn: "symbol"},
+}
+
+// Retention deletes old rows in batches by ctid, so every batch is short transaction which does not
+// lock table for long. Rows of hypertables are deleted by tableoid and ctid, as ctid is unique only in chunk.
+type Retention struct {
+	db          *sqlx.DB
+	hypertables map[string]bool
+}
+
+func NewRetention(db *sqlx.DB) *Retention {
+	return &Retention{db: db}
+}
+
+// WithHypertables marks tables which are TimescaleDB hypertables.
+func (repo *Retention) WithHypertables(tables ...string) {
+	repo.hypertables = make(map[string]bool, len(tables))
+	for _, table := range tables {
+		repo.hypertables[table] = true
+	}
+}
+
+func (repo *Retention) RetentionTable(table string) (bool, bool) {
+	item, has := retentionTables[table]
+	return has, item.symbolColumn != ""
+}
+
+func (repo *Retention) CountRows(ctx context.Context, scope domain.RetentionScope, before time.Time) (int64, error) {
+	table, where, args, err := retentionWhere(scope, before)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		query = `SELECT count(*) FROM crypto_analyst.` + table + ` WHERE ` + where
+		count int64
+	)
+	if err := repo.db.GetContext(ctx, &count, query, args...); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (repo *Retention) DeleteRows(
+	ctx context.Context, scope domain.RetentionScope, before time.Time, limit int,
+) (int64, error) {
+	table, where, args, err := retentionWhere(scope, before)
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf(`
+DELETE
+FROM crypto_analyst.%[1]s
+WHERE ctid = ANY (ARRAY(SELECT ctid FROM crypto_analyst.%[1]s WHERE %[2]s LIMIT $%[3]d))
+`, table, where, len(args)+1)
+	if repo.hypertables[table] {
+		query = fmt.Sprintf(`
+DELETE
+FROM crypto_analyst.%[1]s
+WHERE (tableoid, ctid) IN (SELECT tableoid, ctid FROM crypto_analyst.%[1]s WHERE %[2]s LIMIT $%[3]d)
+`, table, where, len(args)+1)
+	}
+	res, err := repo.db.ExecContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (repo *Retention) CapTime(ctx context.Context, scope domain.RetentionScope, maxRows int64) (time.Time, error) {
+	item, has := retentionTables[scope.Table]
+	if !has {
+		return time.Time{}, fmt.Errorf("unknown retention table: %s", scope.Table)
+	}
+	where, args := symbolWhere(item, scope, nil)
+	var (
+		query = fmt.Sprintf(`
+SELECT %[1]s
+FROM crypto_analyst.%[2]s
+WHERE %[3]s
+ORDER BY %[1]s DESC
+OFFSET $%[4]d LIMIT 1
+`, item.timeColumn, scope.Table, where, len(args)+1)
+		capTime time.Time
+	)
+	if err := repo.db.GetContext(ctx, &capTime, query, append(args, maxRows-1)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return capTime.In(time.UTC), nil
+}
+
+// retentionWhere returns table and condition of rows of scope older than before with its args.
+func retentionWhere(scope domain.RetentionScope, before time.Time) (string, string, []any, error) {
+	item, has := retentionTables[scope.Table]
+	if !has {
+		return "", "", nil, fmt.Errorf("unknown retention table: %s", scope.Table)
+	}
+	where, args := symbolWhere(item, scope, []any{before.In(time.UTC)})
+	return scope.Table, item.timeColumn + " < $1 AND " + where, args, nil
+}
+
+func symbolWhere(item retentionTable, scope domain.RetentionScope, args []any) (string, []any) {
+	switch {
+	case item.symbolColumn == "":
+		return "TRUE", args
+	case scope.Symbol != "":
+		args = append(args, scope.Symbol)
+		return fmt.Sprintf("%s = $%d", item.symbolColumn, len(args)), args
+	case len(scope.ExceptSymbols) > 0:
+		args = append(args, scope.ExceptSymbols)
+		return fmt.Sprintf("NOT (%s = ANY ($%d::TEXT[]))", item.symbolColumn, len(args)), args
+	default:
+		return "TRUE", args
+	}
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultSpreadLimit is number of spreads returned when filter has no limit.
+const DefaultSpreadLimit = 500
+
+var _ domain.SpreadStorage = (*Spread)(nil)
+
+type Spread struct {
+	db *sqlx.DB
+}
+
+func NewSpread(db *sqlx.DB) *Spread {
+	return &Spread{db: db}
+}
+
+// SaveSpreads upserts spreads and returns the inserted ones and the ones which changed, spread of symbol minute
+// which is recalculated to the same values is not updated.
+func (repo *Spread) SaveSpreads(ctx context.Context, spreads []domain.Spread) ([]domain.Spread, error) {
+	if len(spreads) == 0 {
+		return nil, nil
+	}
+	rows := make([][]any, 0, len(spreads))
+	for _, item := range spreads {
+		rows = append(rows, []any{
+			item.Symbol,
+			item.BuyExchange,
+			item.SellExchange,
+			item.BuyPrice,
+			item.SellPrice,
+			item.GrossPercent,
+			item.NetPercent,
+			item.Date.In(time.UTC).Truncate(time.Second),
+			item.CreatedAt.In(time.UTC).Truncate(time.Second),
+		})
+	}
+	var saved []domain.Spread
+	err := batchInsertScan(
+		ctx,
+		repo.db,
+		`
+INSERT INTO crypto_analyst.spreads(symbol, buy_exchange, sell_exchange, buy_price, sell_price, gross_percent,
+                                   net_percent, datetime, created_at)`,
+		`
+ON CONFLICT (symbol, datetime) DO UPDATE
+    SET buy_exchange  = EXCLUDED.buy_exchange,
+        sell_exchange = EXCLUDED.sell_exchange,
+        buy_price     = EXCLUDED.buy_price,
+        sell_price    = EXCLUDED.sell_price,
+        gross_percent = EXCLUDED.gross_percent,
+        net_percent   = EXCLUDED.net_percent,
+        created_at    = EXCLUDED.created_at
+WHERE (spreads.buy_exchange, spreads.sell_exchange, spreads.buy_price, spreads.sell_price,
+       spreads.gross_percent, spreads.net_percent)
+          IS DISTINCT FROM
+      (EXCLUDED.buy_exchange, EXCLUDED.sell_exchange, EXCLUDED.buy_price, EXCLUDED.sell_price,
+       EXCLUDED.gross_percent, EXCLUDED.net_percent)
+RETURNING *`,
+		rows,
+		func(row *sqlx.Rows) error {
+			var item domain.Spread
+			if err := row.StructScan(&item); err != nil {
+				return err
+			}
+			saved = append(saved, item)
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (repo *Spread) Spreads(ctx context.Context, filter domain.SpreadFilter) ([]domain.Spread, error) {
+	var (
+		query = `
+SELECT *
+FROM crypto_analyst.spreads
+WHERE datetime >= $1
+  AND ($2 = '' OR symbol = $2)
+  AND ($3::DOUBLE PRECISION IS NULL OR net_percent >= $3)
+ORDER BY datetime DESC, net_percent DESC
+LIMIT $4
+`
+		result []domain.Spread
+	)
+	if err := repo.db.SelectContext(ctx, &result, query, spreadArgs(filter)...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (repo *Spread) TopSpreads(ctx context.Context, filter domain.SpreadFilter) ([]domain.Spread, error) {
+	var (
+		query = `
+SELECT *
+FROM (SELECT DISTINCT ON (symbol) *
+      FROM crypto_analyst.spreads
+      WHERE datetime >= $1
+        AND ($2 = '' OR symbol = $2)
+      ORDER BY symbol, datetime DESC) latest
+WHERE ($3::DOUBLE PRECISION IS NULL OR net_percent >= $3)
+ORDER BY net_percent DESC, symbol
+LIMIT $4
+`
+		result []domain.Spread
+	)
+	if err := repo.db.SelectContext(ctx, &result, query, spreadArgs(filter)...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func spreadArgs(filter domain.SpreadFilter) []any {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultSpreadLimit
+	}
+	return []any{filter.Since.In(time.UTC), filter.Symbol, filter.MinNetPercent, limit}
+}
@@ -0,0 +1,358 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// PricesNumericVersion is migration which changes type of prices of hypertables to NUMERIC.
+const PricesNumericVersion = 16
+
+// hypertable is table converted to TimescaleDB hypertable partitioned by time column, chunks of
+// compressed table are segmented by segmentBy columns.
+type hypertable struct {
+	name       string
+	timeColumn string
+	segmentBy  string
+}
+
+var hypertables = []hypertable{
+	{name: "prices", timeColumn: "datetime", segmentBy: "symbol, exchange"},
+	{name: "price_changes", timeColumn: "datetime", segmentBy: "symbol, exchange"},
+	{name: "candlesticks", timeColumn: "open_time", segmentBy: "symbol, exchange, candle_interval"},
+}
+
+// continuousAggregate is view of average coefficients of price changes by bucket. Key of metric is bucket
+// shifted by keyOffset days as ChangeCoefficient calculates keys: day metrics have monthly buckets and
+// week metrics have buckets from Sunday which key is Saturday.
+type continuousAggregate struct {
+	view        string
+	bucket      string
+	origin      string
+	keyOffset   int
+	startOffset string
+}
+
+var (
+	hourCoefficients  = continuousAggregate{view: "price_change_coefficients_hour", bucket: "1 hour", startOffset: "3 hours"}
+	monthCoefficients = continuousAggregate{view: "price_change_coefficients_month", bucket: "1 month", startOffset: "3 months"}
+	weekCoefficients  = continuousAggregate{
+		view: "price_change_coefficients_week", bucket: "1 week", origin: "2000-01-02 00:00:00+00", keyOffset: 6, startOffset: "3 weeks",
+	}
+	continuousAggregates = []continuousAggregate{hourCoefficients, monthCoefficients, weekCoefficients}
+)
+
+// metricAggregate is continuous aggregate and its column of metric.
+type metricAggregate struct {
+	continuousAggregate
+	column string
+}
+
+var metricAggregates = map[domain.MetricAggregationPrice]metricAggregate{
+	domain.ChangeCoefficientOnHour: {continuousAggregate: hourCoefficients, column: "change_coefficient"},
+	domain.ChangeCoefficientOnDay:  {continuousAggregate: monthCoefficients, column: "change_coefficient"},
+	domain.ChangeCoefficientOnWeek: {continuousAggregate: weekCoefficients, column: "change_coefficient"},
+	domain.IndicatorChangeOnHour:   {continuousAggregate: hourCoefficients, column: "indicator_change"},
+	domain.IndicatorChangeOnDay:    {continuousAggregate: monthCoefficients, column: "indicator_change"},
+	domain.IndicatorChangeOnWeek:   {continuousAggregate: weekCoefficients, column: "indicator_change"},
+}
+
+// TimescaleOptions are options of hypertables, chunks older than CompressAfter are compressed.
+type TimescaleOptions struct {
+	ChunkInterval time.Duration
+	CompressAfter time.Duration
+}
+
+// TimescaleStatus is TimescaleDB objects of schema, zero value is plain Postgres.
+type TimescaleStatus struct {
+	Installed            bool
+	Hypertables          []string
+	ContinuousAggregates []string
+}
+
+// HasContinuousAggregates reports whether all views of coefficient metrics exist.
+func (s TimescaleStatus) HasContinuousAggregates() bool {
+	for _, item := range continuousAggregates {
+		if !slices.Contains(s.ContinuousAggregates, item.view) {
+			return false
+		}
+	}
+	return true
+}
+
+type Timescale struct {
+	db *sqlx.DB
+}
+
+func NewTimescale(db *sqlx.DB) *Timescale {
+	return &Timescale{db: db}
+}
+
+// Available reports whether TimescaleDB extension can be created in database.
+func (repo *Timescale) Available(ctx context.Context) (bool, error) {
+	var (
+		query     = `SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb')`
+		available bool
+	)
+	if err := repo.db.GetContext(ctx, &available, query); err != nil {
+		return false, err
+	}
+	return available, nil
+}
+
+// Status returns hypertables and continuous aggregates of schema, status is empty when extension is not installed.
+func (repo *Timescale) Status(ctx context.Context) (TimescaleStatus, error) {
+	var (
+		queryInstalled = `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb')`
+		queryTables    = `
+SELECT hypertable_name
+FROM timescaledb_information.hypertables
+WHERE hypertable_schema = 'crypto_analyst'
+ORDER BY hypertable_name
+`
+		queryViews = `
+SELECT view_name
+FROM timescaledb_information.continuous_aggregates
+WHERE view_schema = 'crypto_analyst'
+ORDER BY view_name
+`
+		status TimescaleStatus
+	)
+	if err := repo.db.GetContext(ctx, &status.Installed, queryInstalled); err != nil {
+		return TimescaleStatus{}, errors.Wrap(err, "check timescaledb extension")
+	}
+	if !status.Installed {
+		return status, nil
+	}
+	if err := repo.db.SelectContext(ctx, &status.Hypertables, queryTables); err != nil {
+		return TimescaleStatus{}, errors.Wrap(err, "load hypertables")
+	}
+	if err := repo.db.SelectContext(ctx, &status.ContinuousAggregates, queryViews); err != nil {
+		return TimescaleStatus{}, errors.Wrap(err, "load continuous aggregates")
+	}
+	return status, nil
+}
+
+// Setup creates extension, converts tables to compressed hypertables and creates continuous aggregates
+// of coefficient metrics. Every step is idempotent and runs out of transaction as TimescaleDB requires,
+// table which time column is not of time type is skipped with its continuous aggregates.
+func (repo *Timescale) Setup(ctx context.Context, opts TimescaleOptions) ([]string, error) {
+	var steps []string
+	if _, err := repo.db.ExecContext(ctx, `CREATE EXTENSION IF NOT EXISTS timescaledb`); err != nil {
+		return steps, errors.Wrap(err, "create timescaledb extension")
+	}
+	status, err := repo.Status(ctx)
+	if err != nil {
+		return steps, err
+	}
+	for _, table := range hypertables {
+		timeType, err := repo.timeColumn(ctx, table)
+		if err != nil {
+			return steps, err
+		}
+		if !timeType {
+			zap.L().Warn(
+				"table is not converted to hypertable, time column has no time type",
+				zap.String("table", table.name),
+				zap.String("column", table.timeColumn),
+			)
+			continue
+		}
+		if !slices.Contains(status.Hypertables, table.name) {
+			if err := repo.createHypertable(ctx, table, opts.ChunkInterval); err != nil {
+				return steps, err
+			}
+			steps = append(steps, "hypertable "+table.name)
+		}
+		if err := repo.compress(ctx, table, opts.CompressAfter); err != nil {
+			return steps, err
+		}
+	}
+	if status, err = repo.Status(ctx); err != nil {
+		return steps, err
+	}
+	if !slices.Contains(status.Hypertables, "price_changes") {
+		return steps, nil
+	}
+	for _, item := range continuousAggregates {
+		if slices.Contains(status.ContinuousAggregates, item.view) {
+			continue
+		}
+		if err := repo.createContinuousAggregate(ctx, item); err != nil {
+			return steps, err
+		}
+		steps = append(steps, "continuous aggregate "+item.view)
+	}
+	return steps, nil
+}
+
+func (repo *Timescale) timeColumn(ctx context.Context, table hypertable) (bool, error) {
+	var (
+		query = `
+SELECT data_type
+FROM information_schema.columns
+WHERE table_schema = 'crypto_analyst'
+  AND table_name = $1
+  AND column_name = $2
+`
+		dataType string
+	)
+	if err := repo.db.GetContext(ctx, &dataType, query, table.name, table.timeColumn); err != nil {
+		return false, errors.Wrapf(err, "load type of %s.%s", table.name, table.timeColumn)
+	}
+	switch dataType {
+	case "timestamp without time zone", "timestamp with time zone", "date":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func (repo *Timescale) createHypertable(ctx context.Context, table hypertable, chunkInterval time.Duration) error {
+	query := `
+SELECT create_hypertable(
+    $1::REGCLASS, $2::NAME,
+    chunk_time_interval => $3::INTERVAL,
+    if_not_exists => TRUE,
+    migrate_data => TRUE
+)
+`
+	_, err := repo.db.ExecContext(ctx, query, "crypto_analyst."+table.name, table.timeColumn, interval(chunkInterval))
+	return errors.Wrapf(err, "create hypertable %s", table.name)
+}
+
+func (repo *Timescale) compress(ctx context.Context, table hypertable, compressAfter time.Duration) error {
+	var (
+		queryEnabled = `
+SELECT compression_enabled
+FROM timescaledb_information.hypertables
+WHERE hypertable_schema = 'crypto_analyst'
+  AND hypertable_name = $1
+`
+		queryPolicy = `SELECT add_compression_policy($1::REGCLASS, $2::INTERVAL, if_not_exists => TRUE)`
+		enabled     bool
+	)
+	if err := repo.db.GetContext(ctx, &enabled, queryEnabled, table.name); err != nil {
+		return errors.Wrapf(err, "check compression of %s", table.name)
+	}
+	if !enabled {
+		query := fmt.Sprintf(`
+ALTER TABLE crypto_analyst.%s SET (
+    timescaledb.compress,
+    timescaledb.compress_segmentby = '%s',
+    timescaledb.compress_orderby = '%s DESC'
+)
+`, table.name, table.segmentBy, table.timeColumn)
+		if _, err := repo.db.ExecContext(ctx, query); err != nil {
+			return errors.Wrapf(err, "enable compression of %s", table.name)
+		}
+	}
+	_, err := repo.db.ExecContext(ctx, queryPolicy, "crypto_analyst."+table.name, interval(compressAfter))
+	return errors.Wrapf(err, "add compression policy of %s", table.name)
+}
+
+func (repo *Timescale) createContinuousAggregate(ctx context.Context, item continuousAggregate) error {
+	bucket := fmt.Sprintf("time_bucket(INTERVAL '%s', datetime)", item.bucket)
+	if item.origin != "" {
+		bucket = fmt.Sprintf("time_bucket(INTERVAL '%s', datetime, origin => '%s')", item.bucket, item.origin)
+	}
+	query := fmt.Sprintf(`
+CREATE MATERIALIZED VIEW IF NOT EXISTS crypto_analyst.%s
+    WITH (timescaledb.continuous, timescaledb.materialized_only = FALSE) AS
+SELECT symbol,
+       exchange,
+       %s AS bucket,
+       avg(coefficient_change)::DOUBLE PRECISION      AS change_coefficient,
+       avg(abs(coefficient_change))::DOUBLE PRECISION AS indicator_change
+FROM crypto_analyst.price_changes
+GROUP BY symbol, exchange, 3
+WITH NO DATA
+`, item.view, bucket)
+	if _, err := repo.db.ExecContext(ctx, query); err != nil {
+		return errors.Wrapf(err, "create continuous aggregate %s", item.view)
+	}
+	queryPolicy := fmt.Sprintf(`
+SELECT add_continuous_aggregate_policy(
+    'crypto_analyst.%s',
+    start_offset => INTERVAL '%s',
+    end_offset => INTERVAL '1 minute',
+    schedule_interval => INTERVAL '15 minutes',
+    if_not_exists => TRUE
+)
+`, item.view, item.startOffset)
+	_, err := repo.db.ExecContext(ctx, queryPolicy)
+	return errors.Wrapf(err, "add refresh policy of %s", item.view)
+}
+
+// DecompressHypertables decompresses chunks of hypertables one by one and disables their compression,
+// TimescaleDB does not change type of column of hypertable with compression. "migrate up" enables
+// compression and its policy again, so chunks are recompressed by policy. It is migration hook and
+// is repeated safely, it does nothing without TimescaleDB.
+func DecompressHypertables(ctx context.Context, db *sqlx.DB) error {
+	var (
+		queryInstalled = `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'timescaledb')`
+		queryEnabled   = `
+SELECT compression_enabled
+FROM timescaledb_information.hypertables
+WHERE hypertable_schema = 'crypto_analyst'
+  AND hypertable_name = $1
+`
+		queryChunks = `
+SELECT format('%I.%I', chunk_schema, chunk_name)
+FROM timescaledb_information.chunks
+WHERE hypertable_schema = 'crypto_analyst'
+  AND hypertable_name = $1
+  AND is_compressed
+ORDER BY range_start
+`
+		queryPolicy     = `SELECT remove_compression_policy($1::REGCLASS, if_exists => TRUE)`
+		queryDecompress = `SELECT decompress_chunk($1::REGCLASS, if_compressed => TRUE)`
+		installed       bool
+	)
+	if err := db.GetContext(ctx, &installed, queryInstalled); err != nil {
+		return errors.Wrap(err, "check timescaledb extension")
+	}
+	if !installed {
+		return nil
+	}
+	for _, table := range hypertables {
+		var enabled []bool
+		if err := db.SelectContext(ctx, &enabled, queryEnabled, table.name); err != nil {
+			return errors.Wrapf(err, "check compression of %s", table.name)
+		}
+		if len(enabled) == 0 || !enabled[0] {
+			continue
+		}
+		// Policy must not compress chunks again before migration.
+		if _, err := db.ExecContext(ctx, queryPolicy, "crypto_analyst."+table.name); err != nil {
+			return errors.Wrapf(err, "remove compression policy of %s", table.name)
+		}
+		var chunks []string
+		if err := db.SelectContext(ctx, &chunks, queryChunks, table.name); err != nil {
+			return errors.Wrapf(err, "load compressed chunks of %s", table.name)
+		}
+		for _, chunk := range chunks {
+			if _, err := db.ExecContext(ctx, queryDecompress, chunk); err != nil {
+				return errors.Wrapf(err, "decompress chunk %s", chunk)
+			}
+		}
+		query := fmt.Sprintf(`ALTER TABLE crypto_analyst.%s SET (timescaledb.compress = FALSE)`, table.name)
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return errors.Wrapf(err, "disable compression of %s", table.name)
+		}
+		zap.L().Info("hypertable is decompressed", zap.String("table", table.name), zap.Int("chunks", len(chunks)))
+	}
+	return nil
+}
+
+// interval formats duration as INTERVAL input, database/sql passes time.Duration as number.
+func interval(d time.Duration) string {
+	return fmt.Sprintf("%d seconds", int64(d.Seconds()))
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+var _ domain.WatchlistStorage = (*Watchlist)(nil)
+
+type Watchlist struct {
+	db *sqlx.DB
+}
+
+func NewWatchlist(db *sqlx.DB) *Watchlist {
+	return &Watchlist{db: db}
+}
+
+// watchlistRow keeps intervals joined by comma, database/sql does not scan arrays.
+type watchlistRow struct {
+	Exchange  string    `db:"exchange"`
+	Symbol    string    `db:"symbol"`
+	Intervals string    `db:"intervals"`
+	Rank      int       `db:"rank"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (r watchlistRow) toDomain() domain.WatchlistItem {
+	item := domain.WatchlistItem{
+		Exchange:  r.Exchange,
+		Symbol:    r.Symbol,
+		Intervals: []domain.Interval{},
+		Rank:      r.Rank,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+	if r.Intervals == "" {
+		return item
+	}
+	for _, interval := range strings.Split(r.Intervals, SeparateParamsInSQL) {
+		item.Intervals = append(item.Intervals, domain.Interval(interval))
+	}
+	return item
+}
+
+func (repo *Watchlist) Watchlist(ctx context.Context) ([]domain.WatchlistItem, error) {
+	var (
+		query = `
+SELECT exchange, symbol, array_to_string(intervals, ',') AS intervals, rank, created_at, updated_at
+FROM crypto_analyst.watchlist
+ORDER BY rank DESC, exchange, symbol
+`
+		rows []watchlistRow
+	)
+	if err := repo.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, err
+	}
+	items := make([]domain.WatchlistItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, row.toDomain())
+	}
+	return items, nil
+}
+
+func (repo *Watchlist) WatchlistItem(ctx context.Context, exchange, symbol string) (domain.WatchlistItem, error) {
+	var (
+		query = `
+SELECT exchange, symbol, array_to_string(intervals, ',') AS intervals, rank, created_at, updated_at
+FROM crypto_analyst.watchlist
+WHERE exchange = $1 AND symbol = $2
+`
+		row watchlistRow
+	)
+	if err := repo.db.GetContext(ctx, &row, query, exchange, symbol); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.WatchlistItem{}, domain.ErrWatchlistItemNotFound
+		}
+		return domain.WatchlistItem{}, err
+	}
+	return row.toDomain(), nil
+}
+
+func (repo *Watchlist) SaveWatchlistItem(ctx context.Context, item domain.WatchlistItem) error {
+	var query = `
+INSERT INTO crypto_analyst.watchlist(exchange, symbol, intervals, rank)
+VALUES ($1, $2, string_to_array($3, ','), $4)
+ON CONFLICT (exchange, symbol) DO UPDATE SET intervals  = EXCLUDED.intervals,
+                                             rank       = EXCLUDED.rank,
+                                             updated_at = CURRENT_TIMESTAMP
+`
+	intervals := make([]string, 0, len(item.Intervals))
+	for _, interval := range item.Intervals {
+		intervals = append(intervals, interval.String())
+	}
+	_, err := repo.db.ExecContext(
+		ctx, query, item.Exchange, item.Symbol, strings.Join(intervals, SeparateParamsInSQL), item.Rank,
+	)
+	return err
+}
+
+func (repo *Watchlist) DeleteWatchlistItem(ctx context.Context, exchange, symbol string) error {
+	var query = `
+DELETE FROM crypto_analyst.watchlist
+WHERE exchange = $1 AND symbol = $2
+`
+	res, err := repo.db.ExecContext(ctx, query, exchange, symbol)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrWatchlistItemNotFound
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/AlekseyPorandaykin/crypto_analyst/domain"
+	"github.com/pkg/errors"
+)
+
+// IndicatorStorage keeps indicator snapshots and values of indicator instances.
+type IndicatorStorage interface {
+	domain.IndicatorStorage
+	domain.IndicatorValueStorage
+}
+
+type IndicatorComposite struct {
+	fastStorage     IndicatorStorage
+	longTermStorage IndicatorStorage
+}
+
+func NewIndicatorComposite(fastStorage, longTermStorage IndicatorStorage) *IndicatorComposite {
+	return &IndicatorComposite{fastStorage: fastStorage, longTermStorage: longTermStorage}
+}
+
+func (c *IndicatorComposite) SaveIndicators(ctx context.Context, snapshots []domain.IndicatorSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	if err := c.fastStorage.SaveIndicators(ctx, snapshots); err != nil {
+		return errors.Wrap(err, "error save in fastStorage")
+	}
+	if err := c.longTermStorage.SaveIndicators(ctx, snapshots); err != nil {
+		return errors.Wrap(err, "error save in longTermStorage")
+	}
+	return nil
+}
+
+func (c *IndicatorComposite) LastIndicator(
+	ctx context.Context, exchange, symbol string, interval domain.Interval,
+) (domain.IndicatorSnapshot, error) {
+	snapshot, err := c.fastStorage.LastIndicator(ctx, exchange, symbol, interval)
+	if err == nil {
+		return snapshot, nil
+	}
+	if !errors.Is(err, domain.ErrIndicatorNotFound) {
+		return domain.IndicatorSnapshot{}, errors.Wrap(err, "error get indicator from fastStorage")
+	}
+	snapshot, err = c.longTermStorage.LastIndicator(ctx, exchange, symbol, interval)
+	if err != nil {
+		return domain.IndicatorSnapshot{}, errors.Wrap(err, "error get indicator from longTermStorage")
+	}
+	if err := c.fastStorage.SaveIndicators(ctx, []domain.IndicatorSnapshot{snapshot}); err != nil {
+		return domain.IndicatorSnapshot{}, errors.Wrap(err, "error fill fastStorage")
+	}
+	return snapshot, nil
+}
+
+func (c *IndicatorComposite) SaveIndicatorValues(ctx context.Context, values []domain.IndicatorValue) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if err := c.fastStorage.SaveIndicatorValues(ctx, values); err != nil {
+		return errors.Wrap(err, "error save in fastStorage")
+	}
+	if err := c.longTermStorage.SaveIndicatorValues(ctx, values); err != nil {
+		return errors.Wrap(err, "error save in longTermStorage")
+	}
+	return nil
+}
+
+func (c *IndicatorComposite) LastIndicatorValue(ctx context.Context, instanceID string) (domain.IndicatorValue, error) {
+	value, err := c.fastStorage.LastIndicatorValue(ctx, instanceID)
+	if err == nil {
+		return value, nil
+	}
+	if !errors.Is(err, domain.ErrIndicatorNotFound) {
+		return domain.IndicatorValue{}, errors.Wrap(err, "error get indicator value from fastStorage")
+	}
+	value, err = c.longTermStorage.LastIndicatorValue(ctx, instanceID)
+	if err != nil {
+		return domain.IndicatorValue{}, errors.Wrap(err, "error get indicator value from longTermStorage")
+	}
+	if err := c.fastStorage.SaveIndicatorValues(ctx, []domain.IndicatorValue{value}); err != nil {
+		return domain.IndicatorValue{}, errors.Wrap(err, "error fill fastStorage")
+	}
+	return value, nil
+}
@@ -0,0 +1,48 @@
+// Package testutil contains helpers shared by tests of packages.
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// WaitFor polls cond until it is true and fails test when it is not true before timeout.
+func WaitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition is not met before timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// FixtureServer serves files of testdata directory of test by request path and records query of the last request.
+func FixtureServer(t *testing.T, fixtures map[string]string) (*httptest.Server, *url.Values) {
+	t.Helper()
+	query := &url.Values{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, has := fixtures[r.URL.Path]
+		if !has {
+			http.NotFound(w, r)
+			return
+		}
+		*query = r.URL.Query()
+		content, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("read fixture %s: %v", name, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(content)
+	}))
+	t.Cleanup(server.Close)
+	return server, query
+}
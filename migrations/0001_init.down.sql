@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS crypto_analyst.new_symbols;
+DROP TABLE IF EXISTS crypto_analyst.candlesticks;
+DROP TABLE IF EXISTS crypto_analyst.price_aggregation;
+DROP TABLE IF EXISTS crypto_analyst.price_changes;
+DROP TABLE IF EXISTS crypto_analyst.prices;
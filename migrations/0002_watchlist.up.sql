@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.watchlist
+(
+    exchange   VARCHAR(50) NOT NULL,
+    symbol     VARCHAR(50) NOT NULL,
+    intervals  TEXT[]      NOT NULL DEFAULT '{}',
+    rank       INT         NOT NULL DEFAULT 0,
+    created_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (exchange, symbol)
+);
+
+-- Symbols which were hardcoded in domain.SubscribeSymbols and domain.PopularSymbols.
+INSERT INTO crypto_analyst.watchlist(exchange, symbol, intervals, rank)
+VALUES ('binance', 'BTCUSDT', '{4h,1h}', 100),
+       ('binance', 'ETHUSDT', '{4h,1h}', 99),
+       ('binance', 'BTCUSDC', '{}', 98),
+       ('binance', 'LTCUSDT', '{4h,1h}', 97),
+       ('binance', 'ETHUSDC', '{}', 96),
+       ('binance', 'SOLUSDT', '{4h,1h}', 95)
+ON CONFLICT (exchange, symbol) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS crypto_analyst.candlesticks_exchange_symbol_candle_interval_open_time_idx;
+DROP TABLE IF EXISTS crypto_analyst.backfill_jobs;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.backfill_jobs
+(
+    id              BIGSERIAL PRIMARY KEY,
+    exchange        VARCHAR(50) NOT NULL,
+    symbol          VARCHAR(50) NOT NULL,
+    candle_interval VARCHAR(50) NOT NULL,
+    from_time       TIMESTAMP   NOT NULL,
+    to_time         TIMESTAMP   NOT NULL,
+    cursor_time     TIMESTAMP   NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',
+    loaded          INT         NOT NULL DEFAULT 0,
+    created_at      TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (exchange, symbol, candle_interval, from_time, to_time)
+);
+
+CREATE INDEX IF NOT EXISTS backfill_jobs_status_idx ON crypto_analyst.backfill_jobs (status);
+
+-- Gap detection scans candlesticks of one series ordered by open time.
+CREATE INDEX IF NOT EXISTS candlesticks_exchange_symbol_candle_interval_open_time_idx
+    ON crypto_analyst.candlesticks (exchange, symbol, candle_interval, open_time);
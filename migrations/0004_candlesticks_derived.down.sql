@@ -0,0 +1,2 @@
+ALTER TABLE crypto_analyst.candlesticks
+    DROP COLUMN IF EXISTS derived;
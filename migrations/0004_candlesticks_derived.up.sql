@@ -0,0 +1,3 @@
+-- Derived candlesticks are aggregated locally from lower intervals, exchange candlesticks replace them.
+ALTER TABLE crypto_analyst.candlesticks
+    ADD COLUMN IF NOT EXISTS derived BOOLEAN NOT NULL DEFAULT FALSE;
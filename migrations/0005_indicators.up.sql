@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.indicators
+(
+    exchange        VARCHAR(50)      NOT NULL,
+    symbol          VARCHAR(50)      NOT NULL,
+    candle_interval VARCHAR(10)      NOT NULL,
+    open_time       TIMESTAMP        NOT NULL,
+    ma8             double precision NOT NULL DEFAULT 0,
+    ma40            double precision NOT NULL DEFAULT 0,
+    ema             double precision NOT NULL DEFAULT 0,
+    wma             double precision NOT NULL DEFAULT 0,
+    sma             double precision NOT NULL DEFAULT 0,
+    ring_low        double precision NOT NULL DEFAULT 0,
+    ring_high       double precision NOT NULL DEFAULT 0,
+    pin_bar         double precision NOT NULL DEFAULT 0,
+    created_at      TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (exchange, symbol, candle_interval, open_time)
+);
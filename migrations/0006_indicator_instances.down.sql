@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS crypto_analyst.indicator_values;
+DROP TABLE IF EXISTS crypto_analyst.indicator_instances;
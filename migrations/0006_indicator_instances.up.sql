@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.indicator_instances
+(
+    id              VARCHAR(200) PRIMARY KEY,
+    name            VARCHAR(50)  NOT NULL,
+    exchange        VARCHAR(50)  NOT NULL,
+    symbol          VARCHAR(50)  NOT NULL,
+    candle_interval VARCHAR(10)  NOT NULL,
+    params          JSONB        NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Values of instances defined in config are stored too, so instance_id has no foreign key.
+CREATE TABLE IF NOT EXISTS crypto_analyst.indicator_values
+(
+    instance_id VARCHAR(200) NOT NULL,
+    open_time   TIMESTAMP    NOT NULL,
+    outputs     JSONB        NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (instance_id, open_time)
+);
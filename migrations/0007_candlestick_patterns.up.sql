@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.candlestick_patterns
+(
+    exchange        VARCHAR(50)      NOT NULL,
+    symbol          VARCHAR(50)      NOT NULL,
+    candle_interval VARCHAR(10)      NOT NULL,
+    open_time       TIMESTAMP        NOT NULL,
+    pattern         VARCHAR(50)      NOT NULL,
+    direction       VARCHAR(10)      NOT NULL,
+    strength        double precision NOT NULL DEFAULT 0,
+    created_at      TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (exchange, symbol, candle_interval, open_time, pattern)
+);
+
+CREATE INDEX IF NOT EXISTS candlestick_patterns_open_time_idx
+    ON crypto_analyst.candlestick_patterns (open_time DESC, pattern);
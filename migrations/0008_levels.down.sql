@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS crypto_analyst.level_events;
+DROP TABLE IF EXISTS crypto_analyst.levels;
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.levels
+(
+    id              BIGSERIAL PRIMARY KEY,
+    exchange        VARCHAR(50)      NOT NULL,
+    symbol          VARCHAR(50)      NOT NULL,
+    candle_interval VARCHAR(10)      NOT NULL,
+    kind            VARCHAR(20)      NOT NULL,
+    low             double precision NOT NULL,
+    high            double precision NOT NULL,
+    touches         INTEGER          NOT NULL DEFAULT 0,
+    first_touch     TIMESTAMP        NOT NULL,
+    last_touch      TIMESTAMP        NOT NULL,
+    breaks          INTEGER          NOT NULL DEFAULT 0,
+    last_break      TIMESTAMP,
+    retests         INTEGER          NOT NULL DEFAULT 0,
+    last_retest     TIMESTAMP,
+    updated_at      TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS levels_series_idx
+    ON crypto_analyst.levels (exchange, symbol, candle_interval);
+
+CREATE TABLE IF NOT EXISTS crypto_analyst.level_events
+(
+    id              BIGSERIAL PRIMARY KEY,
+    exchange        VARCHAR(50)      NOT NULL,
+    symbol          VARCHAR(50)      NOT NULL,
+    candle_interval VARCHAR(10)      NOT NULL,
+    kind            VARCHAR(20)      NOT NULL,
+    low             double precision NOT NULL,
+    high            double precision NOT NULL,
+    event           VARCHAR(20)      NOT NULL,
+    price           double precision NOT NULL,
+    prev_price      double precision NOT NULL,
+    datetime        TIMESTAMP        NOT NULL,
+    created_at      TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS level_events_symbol_datetime_idx
+    ON crypto_analyst.level_events (exchange, symbol, datetime DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS crypto_analyst.alerts;
+DROP TABLE IF EXISTS crypto_analyst.alert_rules;
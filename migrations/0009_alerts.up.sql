@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.alert_rules
+(
+    id             BIGSERIAL PRIMARY KEY,
+    name           VARCHAR(200)     NOT NULL DEFAULT '',
+    kind           VARCHAR(20)      NOT NULL,
+    exchange       VARCHAR(50)      NOT NULL DEFAULT '',
+    symbol         VARCHAR(50)      NOT NULL DEFAULT '',
+    operator       VARCHAR(20)      NOT NULL DEFAULT '',
+    threshold      double precision NOT NULL DEFAULT 0,
+    indicator_id   VARCHAR(200)     NOT NULL DEFAULT '',
+    output         VARCHAR(50)      NOT NULL DEFAULT '',
+    cooldown_sec   BIGINT           NOT NULL DEFAULT 0,
+    hysteresis     double precision NOT NULL DEFAULT 0,
+    enabled        BOOLEAN          NOT NULL DEFAULT TRUE,
+    active         BOOLEAN          NOT NULL DEFAULT FALSE,
+    last_triggered TIMESTAMP,
+    last_value     double precision,
+    created_at     TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS crypto_analyst.alerts
+(
+    id         BIGSERIAL PRIMARY KEY,
+    rule_id    BIGINT           NOT NULL,
+    rule_name  VARCHAR(200)     NOT NULL DEFAULT '',
+    kind       VARCHAR(20)      NOT NULL,
+    exchange   VARCHAR(50)      NOT NULL DEFAULT '',
+    symbol     VARCHAR(50)      NOT NULL DEFAULT '',
+    value      double precision NOT NULL DEFAULT 0,
+    message    TEXT             NOT NULL DEFAULT '',
+    datetime   TIMESTAMP        NOT NULL,
+    created_at TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS alerts_datetime_idx ON crypto_analyst.alerts (datetime DESC);
+CREATE INDEX IF NOT EXISTS alerts_rule_id_idx ON crypto_analyst.alerts (rule_id);
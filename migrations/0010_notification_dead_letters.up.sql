@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.notification_dead_letters
+(
+    id         BIGSERIAL PRIMARY KEY,
+    sink       VARCHAR(100) NOT NULL DEFAULT '',
+    payload    JSONB        NOT NULL,
+    error      TEXT         NOT NULL DEFAULT '',
+    created_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notification_dead_letters_created_at_idx
+    ON crypto_analyst.notification_dead_letters (created_at DESC);
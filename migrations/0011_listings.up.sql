@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.listings
+(
+    exchange        VARCHAR(50)      NOT NULL,
+    symbol          VARCHAR(50)      NOT NULL,
+    first_seen      TIMESTAMP        NOT NULL,
+    first_price     double precision NOT NULL,
+    high            double precision NOT NULL,
+    low             double precision NOT NULL,
+    last_price      double precision NOT NULL,
+    last_seen       TIMESTAMP        NOT NULL,
+    complete        BOOLEAN          NOT NULL DEFAULT FALSE,
+    cross_listed    BOOLEAN          NOT NULL DEFAULT FALSE,
+    first_exchange  VARCHAR(50)      NOT NULL DEFAULT '',
+    first_listed_at TIMESTAMP,
+    updated_at      TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (exchange, symbol)
+);
+
+CREATE INDEX IF NOT EXISTS listings_first_seen_idx ON crypto_analyst.listings (first_seen DESC);
+CREATE INDEX IF NOT EXISTS listings_symbol_idx ON crypto_analyst.listings (symbol);
+CREATE INDEX IF NOT EXISTS listings_complete_idx ON crypto_analyst.listings (complete) WHERE NOT complete;
+
+-- Price action of symbols detected before listings is unknown, they are imported as complete listings.
+INSERT INTO crypto_analyst.listings(exchange, symbol, first_seen, first_price, high, low, last_price, last_seen,
+                                    complete, cross_listed, first_exchange, first_listed_at)
+SELECT ns.exchange,
+       ns.symbol,
+       ns.datetime,
+       ns.price,
+       ns.price,
+       ns.price,
+       ns.price,
+       ns.datetime,
+       TRUE,
+       earliest.exchange IS NOT NULL,
+       COALESCE(earliest.exchange, ''),
+       earliest.datetime
+FROM crypto_analyst.new_symbols ns
+         LEFT JOIN LATERAL (SELECT other.exchange, other.datetime
+                            FROM crypto_analyst.new_symbols other
+                            WHERE other.symbol = ns.symbol
+                              AND other.exchange <> ns.exchange
+                              AND other.datetime < ns.datetime
+                            ORDER BY other.datetime
+                            LIMIT 1) earliest ON TRUE
+ON CONFLICT (exchange, symbol) DO NOTHING;
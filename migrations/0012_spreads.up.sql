@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.spreads
+(
+    symbol        VARCHAR(50)      NOT NULL,
+    buy_exchange  VARCHAR(50)      NOT NULL,
+    sell_exchange VARCHAR(50)      NOT NULL,
+    buy_price     double precision NOT NULL,
+    sell_price    double precision NOT NULL,
+    gross_percent double precision NOT NULL,
+    net_percent   double precision NOT NULL,
+    datetime      TIMESTAMP        NOT NULL,
+    created_at    TIMESTAMP        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (symbol, datetime)
+);
+
+CREATE INDEX IF NOT EXISTS spreads_datetime_idx ON crypto_analyst.spreads (datetime DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS crypto_analyst.price_history_1h;
+DROP TABLE IF EXISTS crypto_analyst.price_history_5m;
+DROP TABLE IF EXISTS crypto_analyst.price_history_1m;
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.price_history_1m
+(
+    exchange VARCHAR(50)      NOT NULL,
+    symbol   VARCHAR(50)      NOT NULL,
+    bucket   TIMESTAMP        NOT NULL,
+    open     double precision NOT NULL,
+    high     double precision NOT NULL,
+    low      double precision NOT NULL,
+    close    double precision NOT NULL,
+    samples  BIGINT           NOT NULL DEFAULT 0,
+    PRIMARY KEY (exchange, symbol, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS price_history_1m_bucket_idx ON crypto_analyst.price_history_1m (bucket DESC);
+
+CREATE TABLE IF NOT EXISTS crypto_analyst.price_history_5m
+(
+    exchange VARCHAR(50)      NOT NULL,
+    symbol   VARCHAR(50)      NOT NULL,
+    bucket   TIMESTAMP        NOT NULL,
+    open     double precision NOT NULL,
+    high     double precision NOT NULL,
+    low      double precision NOT NULL,
+    close    double precision NOT NULL,
+    samples  BIGINT           NOT NULL DEFAULT 0,
+    PRIMARY KEY (exchange, symbol, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS price_history_5m_bucket_idx ON crypto_analyst.price_history_5m (bucket DESC);
+
+CREATE TABLE IF NOT EXISTS crypto_analyst.price_history_1h
+(
+    exchange VARCHAR(50)      NOT NULL,
+    symbol   VARCHAR(50)      NOT NULL,
+    bucket   TIMESTAMP        NOT NULL,
+    open     double precision NOT NULL,
+    high     double precision NOT NULL,
+    low      double precision NOT NULL,
+    close    double precision NOT NULL,
+    samples  BIGINT           NOT NULL DEFAULT 0,
+    PRIMARY KEY (exchange, symbol, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS price_history_1h_bucket_idx ON crypto_analyst.price_history_1h (bucket DESC);
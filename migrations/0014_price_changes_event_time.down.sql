@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS crypto_analyst.price_changes_event_time_null_idx;
+DROP INDEX IF EXISTS crypto_analyst.price_changes_symbol_exchange_event_time_idx;
+DROP INDEX IF EXISTS crypto_analyst.price_changes_event_time_idx;
+DROP TRIGGER IF EXISTS price_changes_event_time ON crypto_analyst.price_changes;
+DROP FUNCTION IF EXISTS crypto_analyst.price_changes_event_time();
+ALTER TABLE crypto_analyst.price_changes
+    DROP CONSTRAINT IF EXISTS price_changes_event_time_not_null;
+ALTER TABLE crypto_analyst.price_changes
+    DROP COLUMN IF EXISTS event_time;
@@ -0,0 +1,26 @@
+-- Online migration of price_changes.datetime from VARCHAR to TIMESTAMPTZ, step 1 of 2.
+-- Typed copy of datetime is filled by trigger for new rows, existing rows are filled in batches
+-- before step 2, which swaps columns.
+ALTER TABLE crypto_analyst.price_changes
+    ADD COLUMN IF NOT EXISTS event_time TIMESTAMPTZ;
+
+CREATE OR REPLACE FUNCTION crypto_analyst.price_changes_event_time() RETURNS TRIGGER AS
+$$
+BEGIN
+    -- datetime is UTC time formatted as YYYY-MM-DD HH24:MI:SS (or YYYY/MM/DD/HH24:MI:SS by old versions).
+    NEW.event_time := TO_TIMESTAMP(NEW.datetime, 'YYYY-MM-DD HH24:MI:SS')::TIMESTAMP AT TIME ZONE 'UTC';
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS price_changes_event_time ON crypto_analyst.price_changes;
+CREATE TRIGGER price_changes_event_time
+    BEFORE INSERT OR UPDATE OF datetime
+    ON crypto_analyst.price_changes
+    FOR EACH ROW
+EXECUTE FUNCTION crypto_analyst.price_changes_event_time();
+
+-- NOT VALID constraint checks only new rows, it is validated after backfill without lock of writes,
+-- so SET NOT NULL of step 2 does not scan table.
+ALTER TABLE crypto_analyst.price_changes
+    ADD CONSTRAINT price_changes_event_time_not_null CHECK (event_time IS NOT NULL) NOT VALID;
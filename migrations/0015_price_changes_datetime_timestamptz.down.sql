@@ -0,0 +1,37 @@
+-- Restores state of step 1: text datetime and its typed copy event_time filled by trigger.
+ALTER TABLE crypto_analyst.price_changes
+    RENAME COLUMN datetime TO event_time;
+ALTER INDEX crypto_analyst.price_changes_symbol_exchange_datetime_idx
+    RENAME TO price_changes_symbol_exchange_event_time_idx;
+ALTER INDEX crypto_analyst.price_changes_datetime_idx
+    RENAME TO price_changes_event_time_idx;
+ALTER TABLE crypto_analyst.price_changes
+    ALTER COLUMN event_time DROP NOT NULL;
+
+ALTER TABLE crypto_analyst.price_changes
+    ADD COLUMN datetime VARCHAR(50);
+UPDATE crypto_analyst.price_changes
+SET datetime = TO_CHAR(event_time AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS');
+ALTER TABLE crypto_analyst.price_changes
+    ALTER COLUMN datetime SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS price_changes_symbol_exchange_datetime_idx
+    ON crypto_analyst.price_changes (symbol, exchange, datetime);
+CREATE INDEX IF NOT EXISTS price_changes_datetime_idx ON crypto_analyst.price_changes (datetime);
+
+CREATE OR REPLACE FUNCTION crypto_analyst.price_changes_event_time() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.event_time := TO_TIMESTAMP(NEW.datetime, 'YYYY-MM-DD HH24:MI:SS')::TIMESTAMP AT TIME ZONE 'UTC';
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER price_changes_event_time
+    BEFORE INSERT OR UPDATE OF datetime
+    ON crypto_analyst.price_changes
+    FOR EACH ROW
+EXECUTE FUNCTION crypto_analyst.price_changes_event_time();
+
+ALTER TABLE crypto_analyst.price_changes
+    ADD CONSTRAINT price_changes_event_time_not_null CHECK (event_time IS NOT NULL) NOT VALID;
@@ -0,0 +1,32 @@
+-- Online migration of price_changes.datetime from VARCHAR to TIMESTAMPTZ, step 2 of 2.
+-- Rows are backfilled and indexes are built concurrently before this step, statements below
+-- only change catalog and hold lock of table for short time. Backfill and indexes are repeated here
+-- for databases migrated without batched backfill.
+UPDATE crypto_analyst.price_changes
+SET event_time = TO_TIMESTAMP(datetime, 'YYYY-MM-DD HH24:MI:SS')::TIMESTAMP AT TIME ZONE 'UTC'
+WHERE event_time IS NULL;
+
+ALTER TABLE crypto_analyst.price_changes
+    VALIDATE CONSTRAINT price_changes_event_time_not_null;
+
+CREATE UNIQUE INDEX IF NOT EXISTS price_changes_symbol_exchange_event_time_idx
+    ON crypto_analyst.price_changes (symbol, exchange, event_time);
+CREATE INDEX IF NOT EXISTS price_changes_event_time_idx ON crypto_analyst.price_changes (event_time);
+DROP INDEX IF EXISTS crypto_analyst.price_changes_event_time_null_idx;
+
+DROP TRIGGER IF EXISTS price_changes_event_time ON crypto_analyst.price_changes;
+DROP FUNCTION IF EXISTS crypto_analyst.price_changes_event_time();
+
+ALTER TABLE crypto_analyst.price_changes
+    ALTER COLUMN event_time SET NOT NULL;
+ALTER TABLE crypto_analyst.price_changes
+    DROP CONSTRAINT price_changes_event_time_not_null;
+
+ALTER TABLE crypto_analyst.price_changes
+    DROP COLUMN datetime;
+ALTER TABLE crypto_analyst.price_changes
+    RENAME COLUMN event_time TO datetime;
+ALTER INDEX crypto_analyst.price_changes_symbol_exchange_event_time_idx
+    RENAME TO price_changes_symbol_exchange_datetime_idx;
+ALTER INDEX crypto_analyst.price_changes_event_time_idx
+    RENAME TO price_changes_datetime_idx;
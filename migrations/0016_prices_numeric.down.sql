@@ -0,0 +1,50 @@
+-- Prices and volumes are rounded to double precision.
+-- In TimescaleDB mode compressed chunks must be decompressed and compression disabled before rollback.
+ALTER TABLE crypto_analyst.prices
+    ALTER COLUMN price TYPE double precision USING price::double precision;
+
+ALTER TABLE crypto_analyst.new_symbols
+    ALTER COLUMN price TYPE double precision USING price::double precision;
+
+ALTER TABLE crypto_analyst.price_changes
+    ALTER COLUMN price TYPE double precision USING price::double precision,
+    ALTER COLUMN prev_price TYPE double precision USING prev_price::double precision;
+
+ALTER TABLE crypto_analyst.candlesticks
+    ALTER COLUMN open_price TYPE double precision USING open_price::double precision,
+    ALTER COLUMN high_price TYPE double precision USING high_price::double precision,
+    ALTER COLUMN low_price TYPE double precision USING low_price::double precision,
+    ALTER COLUMN close_price TYPE double precision USING close_price::double precision,
+    ALTER COLUMN volume TYPE double precision USING volume::double precision;
+
+ALTER TABLE crypto_analyst.level_events
+    ALTER COLUMN price TYPE double precision USING price::double precision,
+    ALTER COLUMN prev_price TYPE double precision USING prev_price::double precision;
+
+ALTER TABLE crypto_analyst.listings
+    ALTER COLUMN first_price TYPE double precision USING first_price::double precision,
+    ALTER COLUMN high TYPE double precision USING high::double precision,
+    ALTER COLUMN low TYPE double precision USING low::double precision,
+    ALTER COLUMN last_price TYPE double precision USING last_price::double precision;
+
+ALTER TABLE crypto_analyst.spreads
+    ALTER COLUMN buy_price TYPE double precision USING buy_price::double precision,
+    ALTER COLUMN sell_price TYPE double precision USING sell_price::double precision;
+
+ALTER TABLE crypto_analyst.price_history_1m
+    ALTER COLUMN open TYPE double precision USING open::double precision,
+    ALTER COLUMN high TYPE double precision USING high::double precision,
+    ALTER COLUMN low TYPE double precision USING low::double precision,
+    ALTER COLUMN close TYPE double precision USING close::double precision;
+
+ALTER TABLE crypto_analyst.price_history_5m
+    ALTER COLUMN open TYPE double precision USING open::double precision,
+    ALTER COLUMN high TYPE double precision USING high::double precision,
+    ALTER COLUMN low TYPE double precision USING low::double precision,
+    ALTER COLUMN close TYPE double precision USING close::double precision;
+
+ALTER TABLE crypto_analyst.price_history_1h
+    ALTER COLUMN open TYPE double precision USING open::double precision,
+    ALTER COLUMN high TYPE double precision USING high::double precision,
+    ALTER COLUMN low TYPE double precision USING low::double precision,
+    ALTER COLUMN close TYPE double precision USING close::double precision;
@@ -0,0 +1,51 @@
+-- Prices and volumes are exact decimals. Existing values keep digits of double precision.
+-- Type of column of hypertable with compression can not be changed, so hook of migration decompresses chunks
+-- of prices, price_changes and candlesticks and disables compression, "migrate up" enables it again.
+ALTER TABLE crypto_analyst.prices
+    ALTER COLUMN price TYPE NUMERIC USING price::NUMERIC;
+
+ALTER TABLE crypto_analyst.new_symbols
+    ALTER COLUMN price TYPE NUMERIC USING price::NUMERIC;
+
+ALTER TABLE crypto_analyst.price_changes
+    ALTER COLUMN price TYPE NUMERIC USING price::NUMERIC,
+    ALTER COLUMN prev_price TYPE NUMERIC USING prev_price::NUMERIC;
+
+ALTER TABLE crypto_analyst.candlesticks
+    ALTER COLUMN open_price TYPE NUMERIC USING open_price::NUMERIC,
+    ALTER COLUMN high_price TYPE NUMERIC USING high_price::NUMERIC,
+    ALTER COLUMN low_price TYPE NUMERIC USING low_price::NUMERIC,
+    ALTER COLUMN close_price TYPE NUMERIC USING close_price::NUMERIC,
+    ALTER COLUMN volume TYPE NUMERIC USING volume::NUMERIC;
+
+ALTER TABLE crypto_analyst.level_events
+    ALTER COLUMN price TYPE NUMERIC USING price::NUMERIC,
+    ALTER COLUMN prev_price TYPE NUMERIC USING prev_price::NUMERIC;
+
+ALTER TABLE crypto_analyst.listings
+    ALTER COLUMN first_price TYPE NUMERIC USING first_price::NUMERIC,
+    ALTER COLUMN high TYPE NUMERIC USING high::NUMERIC,
+    ALTER COLUMN low TYPE NUMERIC USING low::NUMERIC,
+    ALTER COLUMN last_price TYPE NUMERIC USING last_price::NUMERIC;
+
+ALTER TABLE crypto_analyst.spreads
+    ALTER COLUMN buy_price TYPE NUMERIC USING buy_price::NUMERIC,
+    ALTER COLUMN sell_price TYPE NUMERIC USING sell_price::NUMERIC;
+
+ALTER TABLE crypto_analyst.price_history_1m
+    ALTER COLUMN open TYPE NUMERIC USING open::NUMERIC,
+    ALTER COLUMN high TYPE NUMERIC USING high::NUMERIC,
+    ALTER COLUMN low TYPE NUMERIC USING low::NUMERIC,
+    ALTER COLUMN close TYPE NUMERIC USING close::NUMERIC;
+
+ALTER TABLE crypto_analyst.price_history_5m
+    ALTER COLUMN open TYPE NUMERIC USING open::NUMERIC,
+    ALTER COLUMN high TYPE NUMERIC USING high::NUMERIC,
+    ALTER COLUMN low TYPE NUMERIC USING low::NUMERIC,
+    ALTER COLUMN close TYPE NUMERIC USING close::NUMERIC;
+
+ALTER TABLE crypto_analyst.price_history_1h
+    ALTER COLUMN open TYPE NUMERIC USING open::NUMERIC,
+    ALTER COLUMN high TYPE NUMERIC USING high::NUMERIC,
+    ALTER COLUMN low TYPE NUMERIC USING low::NUMERIC,
+    ALTER COLUMN close TYPE NUMERIC USING close::NUMERIC;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS crypto_analyst.level_events_crossing_uidx;
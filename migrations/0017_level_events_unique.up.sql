@@ -0,0 +1,15 @@
+-- Price changes of the last minutes are recalculated, crossing of level by the same change is saved once.
+DELETE
+FROM crypto_analyst.level_events e
+    USING crypto_analyst.level_events d
+WHERE e.exchange = d.exchange
+  AND e.symbol = d.symbol
+  AND e.candle_interval = d.candle_interval
+  AND e.low = d.low
+  AND e.high = d.high
+  AND e.kind = d.kind
+  AND e.datetime = d.datetime
+  AND e.id > d.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS level_events_crossing_uidx
+    ON crypto_analyst.level_events (exchange, symbol, candle_interval, low, high, kind, datetime);
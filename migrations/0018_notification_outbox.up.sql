@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS crypto_analyst.notification_outbox
+(
+    id         BIGSERIAL PRIMARY KEY,
+    payload    JSONB     NOT NULL,
+    claimed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS notification_outbox_claimed_at_idx
+    ON crypto_analyst.notification_outbox (claimed_at NULLS FIRST, id);
@@ -0,0 +1,13 @@
+package migrations
+
+import "embed"
+
+// FS contains versioned migrations: <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS
+
+const (
+	Schema = "crypto_analyst"
+	Table  = "schema_migrations"
+)
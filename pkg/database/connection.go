@@ -9,14 +9,14 @@ import (
 )
 
 type Config struct {
-	Driver             string
-	Username           string
-	Password           string
-	Host               string
-	Port               string
-	Database           string
-	MaxOpenConnections int
-	MaxIdleConnections int
+	Driver             string `mapstructure:"driver"`
+	Username           string `mapstructure:"username"`
+	Password           string `mapstructure:"password"`
+	Host               string `mapstructure:"host"`
+	Port               string `mapstructure:"port"`
+	Database           string `mapstructure:"database"`
+	MaxOpenConnections int    `mapstructure:"max_open_connections"`
+	MaxIdleConnections int    `mapstructure:"max_idle_connections"`
 }
 
 func CreateConnection(conf Config) (*sqlx.DB, error) {
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"context"
 	"errors"
 	"net"
 	"net/http"
@@ -10,18 +11,25 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func Handler(host, port string) error {
-	http.Handle("/metrics", promhttp.Handler())
-	http.HandleFunc("/healthz", func(writer http.ResponseWriter, request *http.Request) {
+// Handler serves metrics and health checks until ctx is done, error of listening is returned.
+func Handler(ctx context.Context, host, port string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", func(writer http.ResponseWriter, request *http.Request) {
 		writer.WriteHeader(http.StatusOK)
 	})
-	http.HandleFunc("/readyz", func(writer http.ResponseWriter, request *http.Request) {
+	mux.HandleFunc("/readyz", func(writer http.ResponseWriter, request *http.Request) {
 		writer.WriteHeader(http.StatusOK)
 	})
-	if err := http.ListenAndServe(net.JoinHostPort(host, port), nil); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	server := &http.Server{Addr: net.JoinHostPort(host, port), Handler: mux}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
-	return nil
+	return ctx.Err()
 }
 
 func EchoHandler(e *echo.Echo, subsystem, host, port string) func() error {
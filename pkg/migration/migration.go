@@ -0,0 +1,255 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// lockID is a key for pg_advisory_lock, it protects from parallel migrations of several instances.
+const lockID = 7_340_211
+
+var fileNameRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Hook is data migration which runs before migration of version out of its transaction, e.g. batched
+// backfill which must not hold locks of one long transaction. Hook must be idempotent, it runs again
+// when migration fails.
+type Hook func(ctx context.Context, db *sqlx.DB) error
+
+type Migrator struct {
+	db         *sqlx.DB
+	schema     string
+	table      string
+	migrations []Migration
+	hooks      map[int64]Hook
+}
+
+func New(db *sqlx.DB, fsys fs.FS, schema, table string) (*Migrator, error) {
+	migrations, err := parse(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, schema: schema, table: table, migrations: migrations}, nil
+}
+
+// WithHook runs hook before migration of version is applied.
+func (m *Migrator) WithHook(version int64, hook Hook) {
+	if m.hooks == nil {
+		m.hooks = make(map[int64]Hook)
+	}
+	m.hooks[version] = hook
+}
+
+// Up applies all not applied migrations, every migration in own transaction.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, has := versions[migration.Version]; has {
+				continue
+			}
+			if hook, has := m.hooks[migration.Version]; has {
+				if err := hook(ctx, m.db); err != nil {
+					return errors.Wrapf(err, "run hook of migration %d_%s", migration.Version, migration.Name)
+				}
+			}
+			insert := fmt.Sprintf(`INSERT INTO %s(version, name) VALUES ($1, $2)`, m.tableName())
+			if err := m.execTx(ctx, conn, migration.Up, insert, migration.Version, migration.Name); err != nil {
+				return errors.Wrapf(err, "apply migration %d_%s", migration.Version, migration.Name)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back last applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			migration := m.migrations[i]
+			if _, has := versions[migration.Version]; !has {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+			}
+			remove := fmt.Sprintf(`DELETE FROM %s WHERE version = $1`, m.tableName())
+			if err := m.execTx(ctx, conn, migration.Down, remove, migration.Version); err != nil {
+				return errors.Wrapf(err, "rollback migration %d_%s", migration.Version, migration.Name)
+			}
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var result []Status
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		versions, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if appliedAt, has := versions[migration.Version]; has {
+				status.AppliedAt = &appliedAt
+			}
+			result = append(result, status)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get connection")
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return errors.Wrap(err, "lock migrations")
+	}
+	defer func() { _, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID) }()
+	if err := m.createTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) createTable(ctx context.Context, conn *sqlx.Conn) error {
+	query := fmt.Sprintf(`
+CREATE SCHEMA IF NOT EXISTS %s;
+CREATE TABLE IF NOT EXISTS %s
+(
+    version    BIGINT       NOT NULL PRIMARY KEY,
+    name       VARCHAR(250) NOT NULL,
+    applied_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`, m.schema, m.tableName())
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return errors.Wrap(err, "create migrations table")
+	}
+	return nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sqlx.Conn) (map[int64]time.Time, error) {
+	var (
+		query = fmt.Sprintf(`SELECT version, applied_at FROM %s`, m.tableName())
+		rows  []struct {
+			Version   int64     `db:"version"`
+			AppliedAt time.Time `db:"applied_at"`
+		}
+	)
+	if err := conn.SelectContext(ctx, &rows, query); err != nil {
+		return nil, errors.Wrap(err, "load applied migrations")
+	}
+	versions := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+	return versions, nil
+}
+
+func (m *Migrator) execTx(ctx context.Context, conn *sqlx.Conn, script, query string, args ...any) error {
+	tx, err := conn.BeginTxx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) tableName() string {
+	return m.schema + "." + m.table
+}
+
+func parse(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "read migrations dir")
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		parts := fileNameRe.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse version %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "read %s", entry.Name())
+		}
+		migration, has := byVersion[version]
+		if !has {
+			migration = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != parts[2] {
+			return nil, fmt.Errorf("different names for migration version %d", version)
+		}
+		switch parts[3] {
+		case "up":
+			migration.Up = string(content)
+		case "down":
+			migration.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
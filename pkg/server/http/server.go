@@ -1,6 +1,7 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -108,9 +109,13 @@ func errorApiMiddleware() echo.MiddlewareFunc {
 				return nil
 			}
 			httpErr, ok := err.(*echo.HTTPError)
-			if ok && httpErr.Code == http.StatusNotFound {
+			if err == echo.ErrNotFound {
 				return c.JSON(http.StatusNotFound, nil)
 			}
+			// Handlers return 4xx errors created by echo.NewHTTPError to show message to client.
+			if ok && httpErr.Code < http.StatusInternalServerError {
+				return c.JSON(httpErr.Code, ErrorMessage{Code: httpErr.Code, Message: fmt.Sprint(httpErr.Message)})
+			}
 			zap.L().Error("error api http execute", zap.Error(err), zap.String("url", c.Request().URL.String()))
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "internal error"})
 		}